@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// 流式聊天推送给前端的事件，payload 都带 requestId
+const (
+	ChatStreamDeltaEvent = "chat:delta"
+	ChatStreamDoneEvent  = "chat:done"
+	ChatStreamErrorEvent = "chat:error"
+)
+
+const sseMaxLineSize = 1024 * 1024
+
+// errSSEDone 表示收到了 data: [DONE]
+var errSSEDone = errors.New("sse done")
+
+type chatCompletionChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// readSSE 按 SSE 规范解析事件流，把每个事件的 data 交给 onData；
+// onData 返回 errSSEDone 时正常结束
+func readSSE(r io.Reader, onData func(data []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), sseMaxLineSize)
+	var data []byte
+	dispatch := func() error {
+		if data == nil {
+			return nil
+		}
+		payload := data
+		data = nil
+		return onData(payload)
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if err := dispatch(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+			// 注释/心跳
+		case strings.HasPrefix(line, "data:"):
+			value := strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")
+			if data != nil {
+				data = append(data, '\n')
+			}
+			data = append(data, value...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read stream: %w", err)
+	}
+	return dispatch()
+}
+
+// readChatCompletionStream 解析 OpenAI 风格的 chat.completion.chunk 流，返回拼接后的完整内容
+func readChatCompletionStream(r io.Reader, onDelta func(string)) (string, error) {
+	var content strings.Builder
+	err := readSSE(r, func(data []byte) error {
+		if string(data) == "[DONE]" {
+			return errSSEDone
+		}
+		var chunk chatCompletionChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("unmarshal chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("stream error: %s", chunk.Error.Message)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			return nil
+		}
+		delta := chunk.Choices[0].Delta.Content
+		content.WriteString(delta)
+		onDelta(delta)
+		return nil
+	})
+	if err != nil && !errors.Is(err, errSSEDone) {
+		return content.String(), err
+	}
+	return content.String(), nil
+}
+
+func isEventStream(response *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// openStream 发起流式请求；状态码 >= 400 时读取完整错误体并返回错误
+func (api *APIService) openStream(opts HTTPRequestOptions) (*http.Response, error) {
+	api.logSvc.Info("Opening %s stream to: %s", opts.Method, opts.URL)
+	if api.IsDevelopment() && len(opts.Payload) > 0 {
+		api.logSvc.Info("Request body: %s", string(opts.Payload))
+	}
+	var response *http.Response
+	err := api.withRetry(opts, func() error {
+		req, err := api.buildHTTPRequest(opts)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		_, streamClient := api.httpClients()
+		response, err = streamClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("request failed: %w", err)
+		}
+		if response.StatusCode >= 400 {
+			defer response.Body.Close()
+			body, _ := io.ReadAll(response.Body)
+			return &HTTPStatusError{StatusCode: response.StatusCode, Header: response.Header, Body: body}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// chatCompletionsStream 以 stream: true 调用 OpenAI 兼容接口，每收到一段内容就回调 onDelta。
+// 服务端忽略 stream 而返回普通 JSON 时，整段内容作为一次增量回调。
+func (api *APIService) chatCompletionsStream(opts ChatCompletionsOptions, onDelta func(string)) (string, error) {
+	requestBody, err := json.Marshal(OpenAIChatRequest{Messages: opts.Messages, Model: opts.Model, Stream: true, samplingParams: opts.Sampling.sampling()})
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+	response, err := api.openStream(HTTPRequestOptions{
+		Context: opts.Context, Method: "POST", URL: opts.URL, Token: opts.Token, Headers: opts.Headers, Payload: requestBody,
+		Retry: opts.Retry,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if !isEventStream(response) {
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			return "", fmt.Errorf("read body: %w", err)
+		}
+		content, err := parseChatCompletion(body, nil)
+		if err != nil {
+			return "", err
+		}
+		onDelta(content)
+		return content, nil
+	}
+	return readChatCompletionStream(response.Body, onDelta)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// streamFunc 执行一次流式对话，返回完整内容和实际回答的提供方
+type streamFunc func(ctx context.Context, onDelta func(string)) (content, providerID string, err error)
+
+// runStream 在后台执行 stream，把增量、完成和失败依次以事件推送给前端，返回本次请求的 requestID；
+// 请求可以通过 CancelRequest(requestID) 取消，取消时 chat:error 带 cancelled: true
+func (api *APIService) runStream(requestID, name string, stream streamFunc) string {
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	ctx, done := api.requests.Begin(api.ctx, requestID)
+	api.logSvc.Info("Starting %s stream, requestId: %s", name, requestID)
+	go func() {
+		defer done()
+		content, providerID, err := stream(ctx, func(delta string) {
+			api.emit(api.ctx, ChatStreamDeltaEvent, map[string]interface{}{
+				"requestId": requestID,
+				"delta":     delta,
+			})
+		})
+		if err = cancelledError(ctx, requestID, err); err != nil {
+			apiErr := api.classifyError(name, err)
+			payload := map[string]interface{}{
+				"requestId": requestID,
+				"error":     apiErr.Message,
+				"errorInfo": apiErr,
+				"cancelled": apiErr.Category == ErrorCategoryCancelled,
+			}
+			if apiErr.Category == ErrorCategoryCancelled {
+				api.logSvc.Info("%s stream cancelled, requestId: %s", name, requestID)
+				payload["code"] = ChatCodeCancelled
+			} else {
+				api.logSvc.Error("%s stream failed, requestId: %s, error: %v", name, requestID, err)
+			}
+			api.emit(api.ctx, ChatStreamErrorEvent, payload)
+			return
+		}
+		api.logSvc.Info("%s stream completed, requestId: %s, content length: %d", name, requestID, len(content))
+		api.emit(api.ctx, ChatStreamDoneEvent, map[string]interface{}{
+			"requestId": requestID,
+			"content":   content,
+			"provider":  providerID,
+		})
+	}()
+	return requestID
+}
+
+func parseMessages(messages string) ([]map[string]interface{}, error) {
+	var parsedMessages []map[string]interface{}
+	if err := json.Unmarshal([]byte(messages), &parsedMessages); err != nil {
+		return nil, fmt.Errorf("unmarshal messages: %w", err)
+	}
+	return parsedMessages, nil
+}
+
+// streamWithProvider 解析 JSON 消息列表，在后台流式调用指定提供方
+func (api *APIService) streamWithProvider(requestID, providerID string, opts ChatOptions, messages, apiKey string) (string, error) {
+	parsedMessages, err := parseMessages(messages)
+	if err != nil {
+		return "", err
+	}
+	if _, err := api.providers.Get(providerID); err != nil {
+		return "", err
+	}
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	return api.runStream(requestID, providerID, func(ctx context.Context, onDelta func(string)) (string, string, error) {
+		content, err := api.ChatStream(ctx, providerID, opts, parsedMessages, apiKey, onDelta)
+		return content, providerID, err
+	}), nil
+}
+
+// StreamChatAPI 流式调用默认的 pop-ask 后端
+func (api *APIService) StreamChatAPI(requestID, message string) (string, error) {
+	messages := []map[string]interface{}{{"role": "user", "content": message}}
+	return api.runStream(requestID, PopAskProviderID, func(ctx context.Context, onDelta func(string)) (string, string, error) {
+		content, err := api.ChatStream(ctx, PopAskProviderID, ChatOptions{}, messages, "", onDelta)
+		return content, PopAskProviderID, err
+	}), nil
+}
+
+// StreamCustomOpenAIAPI 使用用户自己的 key 流式调用 OpenAI；apiKey 为空时使用 vault 中保存的 key。
+// vault 锁定时照常开始，由 chat:error 推送 vault_locked（status 423）；任何地方都没有 key 时直接返回错误
+func (api *APIService) StreamCustomOpenAIAPI(requestID, messages, apiKey string) (string, error) {
+	key, err := api.openAIKey(apiKey)
+	if err != nil && !errors.Is(err, ErrVaultLocked) {
+		return "", err
+	}
+	if key == "" && err == nil {
+		return "", fmt.Errorf("API key is required")
+	}
+	return api.streamWithProvider(requestID, OpenAIProviderID, ChatOptions{}, messages, apiKey)
+}
+
+func (api *APIService) StreamAIBianxieAPI(requestID, messages string) (string, error) {
+	return api.streamWithProvider(requestID, BianxieProviderID, ChatOptions{}, messages, "")
+}
+
+func (api *APIService) StreamAIOpenHubAPI(requestID, messages string) (string, error) {
+	return api.streamWithProvider(requestID, OpenHubProviderID, ChatOptions{}, messages, "")
+}
+
+// 以下绑定立即返回 requestID，结果通过 chat:delta / chat:done / chat:error 事件推送
+func (a *App) ChatStream(requestID, providerID, model, messages string) (string, error) {
+	return a.apiSvc.streamWithProvider(requestID, providerID, ChatOptions{Model: model}, messages, "")
+}
+
+func (a *App) ChatStreamWithOptions(requestID, providerID, messages string, options ChatOptions) (string, error) {
+	return a.apiSvc.streamWithProvider(requestID, providerID, options, messages, "")
+}
+
+func (a *App) StreamChatAPI(requestID, message string) (string, error) {
+	return a.apiSvc.StreamChatAPI(requestID, message)
+}
+
+func (a *App) StreamCustomOpenAIAPI(requestID, messages, apiKey string) (string, error) {
+	return a.apiSvc.StreamCustomOpenAIAPI(requestID, messages, apiKey)
+}
+
+func (a *App) StreamAIBianxieAPI(requestID, messages string) (string, error) {
+	return a.apiSvc.StreamAIBianxieAPI(requestID, messages)
+}
+
+func (a *App) StreamAIOpenHubAPI(requestID, messages string) (string, error) {
+	return a.apiSvc.StreamAIOpenHubAPI(requestID, messages)
+}
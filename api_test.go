@@ -2,10 +2,16 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/zalando/go-keyring"
 )
 
 func TestAPIService_makeRequest_statusCode(t *testing.T) {
@@ -47,7 +53,7 @@ func TestAPIService_makeRequest_statusCode(t *testing.T) {
 			}))
 			defer server.Close()
 
-			body, err := api.makeRequest("GET", server.URL, "", nil)
+			body, err := api.makeRequest(HTTPRequestOptions{Method: "GET", URL: server.URL})
 			if tt.wantErr {
 				if err == nil {
 					t.Errorf("makeRequest() expected error for status %d", tt.statusCode)
@@ -81,7 +87,7 @@ func TestAPIService_chatCompletions_requestBody(t *testing.T) {
 	messages := []map[string]interface{}{
 		{"role": "user", "content": "hello"},
 	}
-	content, err := api.chatCompletions(server.URL, "token", messages, "gpt-3.5-turbo")
+	content, err := api.chatCompletions(ChatCompletionsOptions{URL: server.URL, Token: "token", Model: "gpt-3.5-turbo", Messages: messages})
 	if err != nil {
 		t.Fatalf("chatCompletions() error: %v", err)
 	}
@@ -92,3 +98,361 @@ func TestAPIService_chatCompletions_requestBody(t *testing.T) {
 		t.Error("chatCompletions() did not send request body")
 	}
 }
+
+func TestAPIService_chatCompletionsStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(": keep-alive\n\n"))
+		_, _ = w.Write([]byte(`data: {"choices":[{"delta":{"role":"assistant"}}]}` + "\n\n"))
+		_, _ = w.Write([]byte(`data: {"choices":[{"delta":{"content":"Hel"}}]}` + "\n\n"))
+		_, _ = w.Write([]byte(`data: {"choices":[{"delta":{"content":"lo"}}]}` + "\n\n"))
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	app := NewApp()
+	api := NewAPIService(context.Background(), app)
+
+	var deltas []string
+	content, err := api.chatCompletionsStream(ChatCompletionsOptions{
+		URL: server.URL, Token: "token", Model: "gpt-3.5-turbo",
+		Messages: []map[string]interface{}{{"role": "user", "content": "hello"}},
+	}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+	if err != nil {
+		t.Fatalf("chatCompletionsStream() error: %v", err)
+	}
+	if content != "Hello" {
+		t.Errorf("chatCompletionsStream() content = %q, want %q", content, "Hello")
+	}
+	if strings.Join(deltas, "|") != "Hel|lo" {
+		t.Errorf("chatCompletionsStream() deltas = %v, want [Hel lo]", deltas)
+	}
+}
+
+func TestAPIService_CancelRequest(t *testing.T) {
+	received := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		close(received)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	app := NewApp()
+	api := NewAPIService(context.Background(), app)
+	if err := api.providers.Register(ProviderConfig{ID: "slow", BaseURL: server.URL, AuthScheme: AuthSchemeNone}); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+
+	go func() {
+		<-received
+		if !api.CancelRequest("req-1") {
+			t.Error("CancelRequest() = false, want true")
+		}
+	}()
+	response, err := api.chatResponse("req-1", "slow", ChatOptions{}, []map[string]interface{}{{"role": "user", "content": "hi"}}, "")
+	if err != nil {
+		t.Fatalf("chatResponse() error: %v", err)
+	}
+	if response.Code != ChatCodeCancelled {
+		t.Errorf("chatResponse() code = %d, want %d", response.Code, ChatCodeCancelled)
+	}
+	if api.CancelRequest("req-1") {
+		t.Error("CancelRequest() after completion = true, want false")
+	}
+}
+
+func TestAPIService_makeRequest_retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		statusCode   int
+		retryAfter   string
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "503 retried until success", statusCode: 503, retryAfter: "0", wantAttempts: 3},
+		{name: "429 with long Retry-After", statusCode: 429, retryAfter: "3600", wantAttempts: 1, wantErr: true},
+		{name: "500 not retried", statusCode: 500, wantAttempts: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts < 3 {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(tt.statusCode)
+					return
+				}
+				_, _ = w.Write([]byte(`{"ok":true}`))
+			}))
+			defer server.Close()
+
+			api := NewAPIService(context.Background(), NewApp())
+			_, err := api.makeRequest(HTTPRequestOptions{
+				Method: "POST", URL: server.URL, Payload: []byte(`{}`),
+				Retry: &RetryPolicy{MaxAttempts: 3, BaseDelayMs: 1, MaxDelayMs: 1},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("makeRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("makeRequest() attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestAPIService_chatResponse_errorCategory(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		body          string
+		wantCategory  ErrorCategory
+		wantCode      string
+		wantRetryable bool
+	}{
+		{
+			name: "invalid key", statusCode: 401,
+			body:         `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`,
+			wantCategory: ErrorCategoryAuth, wantCode: "invalid_api_key",
+		},
+		{
+			name: "quota", statusCode: 429,
+			body:         `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`,
+			wantCategory: ErrorCategoryQuota, wantCode: "insufficient_quota",
+		},
+		{
+			name: "context length", statusCode: 400,
+			body:         `{"error":{"message":"This model's maximum context length is 16385 tokens","type":"invalid_request_error","code":"context_length_exceeded"}}`,
+			wantCategory: ErrorCategoryContextLength, wantCode: "context_length_exceeded",
+		},
+		{
+			name: "bad gateway", statusCode: 502, body: `<html>bad gateway</html>`,
+			wantCategory: ErrorCategoryServer, wantRetryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			api := NewAPIService(context.Background(), NewApp())
+			_ = api.providers.Register(ProviderConfig{ID: "test", BaseURL: server.URL, Retry: &RetryPolicy{MaxAttempts: 1}})
+
+			response, err := api.chatResponse("req", "test", ChatOptions{}, []map[string]interface{}{{"role": "user", "content": "hi"}}, "")
+			if err != nil {
+				t.Fatalf("chatResponse() error: %v", err)
+			}
+			if response.Error == nil {
+				t.Fatalf("chatResponse() Error = nil, want %s", tt.wantCategory)
+			}
+			got := response.Error
+			if got.Category != tt.wantCategory || got.ProviderCode != tt.wantCode || got.Status != tt.statusCode || got.Retryable != tt.wantRetryable {
+				t.Errorf("chatResponse() Error = %+v", got)
+			}
+		})
+	}
+}
+
+func TestAPIService_ChatWithFallback(t *testing.T) {
+	newServer := func(status int, body string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	overloaded := newServer(503, `{"error":{"message":"overloaded"}}`)
+	unauthorized := newServer(401, `{"error":{"message":"bad key","code":"invalid_api_key"}}`)
+	healthy := newServer(200, `{"choices":[{"message":{"content":"from backup"}}]}`)
+
+	api := NewAPIService(context.Background(), NewApp())
+	var events []string
+	api.emit = func(ctx context.Context, name string, data ...interface{}) { events = append(events, name) }
+	for id, server := range map[string]*httptest.Server{"primary": overloaded, "denied": unauthorized, "backup": healthy} {
+		_ = api.providers.Register(ProviderConfig{ID: id, BaseURL: server.URL, AuthScheme: AuthSchemeNone, Retry: &RetryPolicy{MaxAttempts: 1}})
+	}
+	messages := `[{"role":"user","content":"hi"}]`
+
+	if err := api.SetFallbackChain([]string{"primary", "backup:gpt-4o-mini"}); err != nil {
+		t.Fatalf("SetFallbackChain() error: %v", err)
+	}
+	response, _ := api.ChatWithFallback("req", messages, ChatOptions{})
+	if response.Code != 200 || response.Data != "from backup" || response.Provider != "backup" || response.Model != "gpt-4o-mini" {
+		t.Errorf("ChatWithFallback() = %+v, want answer from backup with gpt-4o-mini", response)
+	}
+	if len(events) != 1 || events[0] != ChatFallbackEvent {
+		t.Errorf("events = %v, want [%s]", events, ChatFallbackEvent)
+	}
+
+	// 鉴权失败不是可重试错误，不应切换到下一个提供方
+	if err := api.SetFallbackChain([]string{"denied", "backup"}); err != nil {
+		t.Fatalf("SetFallbackChain() error: %v", err)
+	}
+	response, _ = api.ChatWithFallback("req", messages, ChatOptions{})
+	if response.Code != 500 || response.Provider != "denied" || response.Error == nil || response.Error.Category != ErrorCategoryAuth {
+		t.Errorf("ChatWithFallback() = %+v, want auth error from denied", response)
+	}
+
+	if err := api.SetFallbackChain([]string{"missing"}); err == nil {
+		t.Error("SetFallbackChain() with unknown provider error = nil")
+	}
+}
+
+func TestAPIService_OpenAIAPI_sendsHistory(t *testing.T) {
+	var got ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+		_, _ = w.Write([]byte(`{"code":200,"data":"Paris","message":"ok"}`))
+	}))
+	defer server.Close()
+
+	api := NewAPIService(context.Background(), NewApp())
+	api.emit = func(ctx context.Context, name string, data ...interface{}) {}
+	_ = api.providers.Register(ProviderConfig{ID: PopAskProviderID, Kind: ProviderKindPopAsk, BaseURL: server.URL, AuthScheme: AuthSchemeNone})
+
+	response, err := api.OpenAIAPI(`[{"role":"user","content":"What is the capital of France?"},{"role":"assistant","content":"Paris."},{"role":"user","content":"And its population?"}]`)
+	if err != nil {
+		t.Fatalf("OpenAIAPI() error: %v", err)
+	}
+	if response.Code != 200 || response.Data != "Paris" {
+		t.Errorf("OpenAIAPI() = %+v, want code 200 with data", response)
+	}
+	if len(got.Messages) != 3 || got.Messages[1]["role"] != "assistant" {
+		t.Errorf("request messages = %v, want full history", got.Messages)
+	}
+	if got.Message != "And its population?" {
+		t.Errorf("request message = %q, want last user message", got.Message)
+	}
+}
+
+func TestAPIService_chatResponse_usage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/chat":
+			_, _ = w.Write([]byte(`{"message":{"content":"ok"},"done":true,"prompt_eval_count":12,"eval_count":3}`))
+		case "/v1/messages":
+			_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":8,"output_tokens":2}}`))
+		case "/pop-ask":
+			_, _ = w.Write([]byte(`{"code":200,"data":"ok"}`))
+		default:
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}],"usage":{"prompt_tokens":5,"completion_tokens":4,"total_tokens":9}}`))
+		}
+	}))
+	defer server.Close()
+
+	api := NewAPIService(context.Background(), NewApp())
+	api.emit = func(ctx context.Context, name string, data ...interface{}) {}
+	for _, cfg := range []ProviderConfig{
+		{ID: "oai", Kind: ProviderKindOpenAI, DefaultModel: "gpt-test"},
+		{ID: "local", Kind: ProviderKindOllama, DefaultModel: "llama-test"},
+		{ID: "claude", Kind: ProviderKindAnthropic, DefaultModel: "claude-test"},
+		{ID: "edge", Kind: ProviderKindPopAsk, ChatPath: "/pop-ask", DefaultModel: "edge-test"},
+	} {
+		cfg.BaseURL, cfg.AuthScheme, cfg.Retry = server.URL, AuthSchemeNone, &RetryPolicy{MaxAttempts: 1}
+		if err := api.providers.Register(cfg); err != nil {
+			t.Fatalf("Register(%s) error: %v", cfg.ID, err)
+		}
+	}
+
+	tests := []struct {
+		provider, model, wantModel string
+		want                       *TokenUsage
+	}{
+		{provider: "oai", wantModel: "gpt-test", want: &TokenUsage{PromptTokens: 5, CompletionTokens: 4, TotalTokens: 9}},
+		{provider: "local", model: "qwen", wantModel: "qwen", want: &TokenUsage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15}},
+		{provider: "claude", wantModel: "claude-test", want: &TokenUsage{PromptTokens: 8, CompletionTokens: 2, TotalTokens: 10}},
+		{provider: "edge", wantModel: "edge-test"},
+	}
+	messages := []map[string]interface{}{{"role": "user", "content": "hi"}}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			response, _ := api.chatResponse("", tt.provider, ChatOptions{Model: tt.model}, messages, "")
+			if response.Code != 200 || response.Model != tt.wantModel {
+				t.Fatalf("chatResponse() = %+v, want code 200 and model %q", response, tt.wantModel)
+			}
+			if (response.Usage == nil) != (tt.want == nil) || (tt.want != nil && *response.Usage != *tt.want) {
+				t.Errorf("usage = %+v, want %+v", response.Usage, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIService_CustomOpenAIAPI_vaultLocked(t *testing.T) {
+	keyring.MockInitWithError(errors.New("secret service not available"))
+	t.Setenv(DataDirEnv, t.TempDir())
+	app := NewApp()
+	app.vaultSvc = NewVaultService(context.Background(), app)
+	if err := app.vaultSvc.SetPassphrase("correct horse"); err != nil {
+		t.Fatalf("SetPassphrase() error: %v", err)
+	}
+	if err := app.vaultSvc.SetSecret(OpenAIProviderID, "sk-test"); err != nil {
+		t.Fatalf("SetSecret() error: %v", err)
+	}
+	app.vaultSvc.Lock()
+
+	// 环境变量中的 key 不应掩盖 vault 锁定
+	t.Setenv("OPENAI_API_KEY", "sk-env")
+	api := NewAPIService(context.Background(), app)
+	streamErrors := make(chan *APIError, 1)
+	api.emit = func(ctx context.Context, name string, data ...interface{}) {
+		if name == ChatStreamErrorEvent {
+			streamErrors <- data[0].(map[string]interface{})["errorInfo"].(*APIError)
+		}
+	}
+	messages := `[{"role":"user","content":"hi"}]`
+	response, err := api.CustomOpenAIAPI(messages, "")
+	if err != nil {
+		t.Fatalf("CustomOpenAIAPI() error: %v", err)
+	}
+	if response.Code != http.StatusLocked || response.Error == nil || response.Error.Category != ErrorCategoryVaultLocked {
+		t.Errorf("CustomOpenAIAPI() with locked vault = %+v, want 423 vault_locked", response)
+	}
+	response, _ = api.chatResponse("", OpenAIProviderID, ChatOptions{}, []map[string]interface{}{{"role": "user", "content": "hi"}}, "")
+	if response.Code != http.StatusLocked || response.Error == nil || response.Error.Category != ErrorCategoryVaultLocked {
+		t.Errorf("chatResponse() with locked vault = %+v, want 423 vault_locked", response)
+	}
+	if err := api.SetFallbackChain([]string{OpenAIProviderID}); err != nil {
+		t.Fatal(err)
+	}
+	response, _ = api.ChatWithFallback("", messages, ChatOptions{})
+	if response.Code != http.StatusLocked || response.Error == nil || response.Error.Category != ErrorCategoryVaultLocked {
+		t.Errorf("ChatWithFallback() with locked vault = %+v, want 423 vault_locked", response)
+	}
+	if _, err := api.StreamCustomOpenAIAPI("stream", messages, ""); err != nil {
+		t.Fatalf("StreamCustomOpenAIAPI() error: %v", err)
+	}
+	select {
+	case apiErr := <-streamErrors:
+		if apiErr.Category != ErrorCategoryVaultLocked || apiErr.Status != http.StatusLocked {
+			t.Errorf("chat:error = %+v, want 423 vault_locked", apiErr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no chat:error for a locked vault")
+	}
+
+	// 没有任何 key 时流式接口直接拒绝
+	if err := app.vaultSvc.Unlock("correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.vaultSvc.DeleteSecret(OpenAIProviderID); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OPENAI_API_KEY", "")
+	if _, err := api.StreamCustomOpenAIAPI("", messages, ""); err == nil {
+		t.Error("StreamCustomOpenAIAPI() without any key should fail")
+	}
+}
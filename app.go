@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sync"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -20,7 +22,16 @@ type App struct {
 	apiSvc        *APIService
 	windowSvc     *WindowService
 	networkSvc    *NetworkService
+	ocrSvc        *OCRService
+	historySvc    *HistoryService
+	configSvc     *ConfigService
+	profileSvc    *ProfileService
+	vaultSvc      *VaultService
 	logSvc        *LogService
+	// profileMu 保护切换方案时替换的 configSvc、historySvc 和 vaultSvc，运行中通过 config/history/vault 读取；
+	// switchMu 保证同一时间只有一次切换
+	profileMu sync.RWMutex
+	switchMu  sync.Mutex
 }
 
 // NewApp creates a new App application struct
@@ -32,6 +43,9 @@ func NewApp() *App {
 
 func (a *App) initServices(ctx context.Context) {
 	a.ctx = ctx
+	a.profileSvc = NewProfileService(ctx, a)
+	// 依赖方案的配置、历史和密钥库最先创建，其他服务创建时读取配置
+	a.openProfileServices(ctx)
 	a.hardwareSvc = NewHardwareService(ctx, a)
 	a.screenshotSvc = NewScreenshotService(ctx, a)
 	a.promptSvc = NewPromptService(ctx, a)
@@ -40,6 +54,7 @@ func (a *App) initServices(ctx context.Context) {
 	a.apiSvc = NewAPIService(ctx, a)
 	a.windowSvc = NewWindowService(ctx, a)
 	a.networkSvc = NewNetworkService(ctx, a)
+	a.ocrSvc = NewOCRService(ctx, a)
 }
 
 func (a *App) registerSyncShortcutList(ctx context.Context) {
@@ -54,11 +69,56 @@ func (a *App) registerSyncShortcutList(ctx context.Context) {
 	})
 }
 
+// registerSyncDailyUsage 前端启动时和每次计入用量后推送今日剩余次数
+func (a *App) registerSyncDailyUsage(ctx context.Context) {
+	runtime.EventsOn(ctx, "syncDailyUsage", func(data ...interface{}) {
+		if len(data) > 0 {
+			if remaining, ok := data[0].(float64); ok {
+				a.shortcutSvc.setDailyRemaining(int(remaining))
+			}
+		}
+	})
+}
+
+func (a *App) registerSyncOCRLang(ctx context.Context) {
+	runtime.EventsOn(ctx, "syncOCRLang", func(data ...interface{}) {
+		if len(data) > 0 {
+			if s, ok := data[0].(string); ok {
+				var languages []string
+				if err := json.Unmarshal([]byte(s), &languages); err != nil {
+					a.logSvc.Error("Failed to unmarshal OCR languages: %v", err)
+					return
+				}
+				a.ocrSvc.SetLanguages(languages)
+			}
+		}
+	})
+}
+
+// registerImportLocalStorageHistory 前端启动时推送 localStorage 中的历史，首次启动新版本时导入
+func (a *App) registerImportLocalStorageHistory(ctx context.Context) {
+	runtime.EventsOn(ctx, "importLocalStorageHistory", func(data ...interface{}) {
+		if len(data) < 2 {
+			return
+		}
+		historyList, _ := data[0].(string)
+		chatHistoryList, _ := data[1].(string)
+		if _, err := a.history().ImportLocalStorage(historyList, chatHistoryList); err != nil {
+			a.logSvc.Error("Failed to import localStorage history: %v", err)
+		}
+	})
+}
+
 func (a *App) startup(ctx context.Context) {
 	a.logSvc.Info("Starting PopAsk application")
 	a.initServices(ctx)
 	a.logSvc.Info("All services initialized successfully")
+	// 提前检测网络区域，避免第一次 fallback 对话等待检测
+	go a.apiSvc.FallbackChain()
 	a.registerSyncShortcutList(ctx)
+	a.registerSyncOCRLang(ctx)
+	a.registerSyncDailyUsage(ctx)
+	a.registerImportLocalStorageHistory(ctx)
 	a.logSvc.Info("PopAsk application startup completed")
 }
 
@@ -74,6 +134,8 @@ func (a *App) beforeClose(ctx context.Context) (prevent bool) {
 func (a *App) shutdown(ctx context.Context) {
 	a.logSvc.Info("Shutting down PopAsk application")
 
+	a.closeProfileServices()
+
 	// 关闭日志文件
 	if err := a.logSvc.Close(); err != nil {
 		fmt.Printf("Failed to close log file: %v\n", err)
@@ -10,6 +10,11 @@ func SendCtrlC() error {
 	return fmt.Errorf("SendCtrlC not implemented on this platform")
 }
 
+// SendCtrlV 非 Windows 平台的存根实现
+func SendCtrlV() error {
+	return fmt.Errorf("SendCtrlV not implemented on this platform")
+}
+
 // SendShiftWinS 发送 Shift+Win+S 快捷键 (非 Windows 平台)
 func SendShiftWinS() {
 	// 非 Windows 平台，不做任何操作
@@ -25,3 +30,13 @@ func HasClipboardImage() bool {
 	// 非 Windows 平台，返回 false
 	return false
 }
+
+// readClipboardHTML 非 Windows 平台通过命令行工具读取 HTML，见 readSelectionHTML
+func readClipboardHTML() ([]byte, error) {
+	return nil, errNativeClipboardUnsupported
+}
+
+// clipboardMarkedSensitiveNative 非 Windows 平台通过剪贴板类型判断，见 clipboardMarkedSensitive
+func clipboardMarkedSensitiveNative() (bool, error) {
+	return false, errNativeClipboardUnsupported
+}
@@ -9,6 +9,8 @@ package main
 #include <windows.h>
 #include <winuser.h>
 #include <stdint.h>
+#include <stdlib.h>
+#include <string.h>
 void sendCtrlC() {
     INPUT inputs[2] = {0};
     inputs[0].type = INPUT_KEYBOARD;
@@ -24,6 +26,21 @@ void sendCtrlC() {
     SendInput(2, inputs, sizeof(INPUT));
 }
 
+void sendCtrlV() {
+    INPUT inputs[2] = {0};
+    inputs[0].type = INPUT_KEYBOARD;
+    inputs[0].ki.wVk = VK_CONTROL;
+    inputs[1].type = INPUT_KEYBOARD;
+    inputs[1].ki.wVk = 0x56; // 'V' key
+    SendInput(2, inputs, sizeof(INPUT));
+
+    Sleep(50);
+
+    inputs[0].ki.dwFlags = KEYEVENTF_KEYUP;
+    inputs[1].ki.dwFlags = KEYEVENTF_KEYUP;
+    SendInput(2, inputs, sizeof(INPUT));
+}
+
 void sendShiftWinS() {
     INPUT inputs[6] = {0};
 
@@ -65,14 +82,96 @@ int hasClipboardImage() {
            IsClipboardFormatAvailable(CF_DIB) ||
            IsClipboardFormatAvailable(CF_DIBV5);
 }
+
+// 位图、调色板、图元文件等格式的数据是 GDI 句柄而不是 HGLOBAL，无法按字节复制；
+// 恢复 CF_DIB 等格式后系统会自动合成它们
+int isHandleClipboardFormat(UINT format) {
+    switch (format) {
+    case CF_BITMAP: case CF_PALETTE: case CF_METAFILEPICT: case CF_ENHMETAFILE:
+    case CF_DSPBITMAP: case CF_DSPMETAFILEPICT: case CF_DSPENHMETAFILE: case CF_OWNERDISPLAY:
+        return 1;
+    }
+    return format >= CF_GDIOBJFIRST && format <= CF_GDIOBJLAST;
+}
+
+// 复制一个剪贴板格式的数据，调用方负责 free；读取失败时返回 NULL
+void* readClipboardFormat(UINT format, SIZE_T* size) {
+    HANDLE h = GetClipboardData(format);
+    if (h == NULL) return NULL;
+    SIZE_T n = GlobalSize(h);
+    void* src = GlobalLock(h);
+    if (src == NULL) return NULL;
+    void* dst = malloc(n > 0 ? n : 1);
+    if (dst != NULL) {
+        memcpy(dst, src, n);
+        *size = n;
+    }
+    GlobalUnlock(h);
+    return dst;
+}
+
+// 写入一个剪贴板格式，成功后内存归剪贴板所有
+int writeClipboardFormat(UINT format, const void* data, SIZE_T size) {
+    HGLOBAL h = GlobalAlloc(GMEM_MOVEABLE, size > 0 ? size : 1);
+    if (h == NULL) return 0;
+    void* dst = GlobalLock(h);
+    if (dst == NULL) {
+        GlobalFree(h);
+        return 0;
+    }
+    if (size > 0) memcpy(dst, data, size);
+    GlobalUnlock(h);
+    if (SetClipboardData(format, h) == NULL) {
+        GlobalFree(h);
+        return 0;
+    }
+    return 1;
+}
+
+// 密码管理器复制密码时附带这些格式，要求剪贴板历史和监控程序忽略本次内容
+int isClipboardSensitive() {
+    if (IsClipboardFormatAvailable(RegisterClipboardFormatA("ExcludeClipboardContentFromMonitorProcessing")) ||
+        IsClipboardFormatAvailable(RegisterClipboardFormatA("Clipboard Viewer Ignore"))) {
+        return 1;
+    }
+    UINT history = RegisterClipboardFormatA("CanIncludeInClipboardHistory");
+    if (!IsClipboardFormatAvailable(history) || !OpenClipboard(NULL)) return 0;
+    int excluded = 0;
+    HANDLE h = GetClipboardData(history);
+    if (h != NULL) {
+        DWORD* value = (DWORD*)GlobalLock(h);
+        if (value != NULL) {
+            excluded = *value == 0;
+            GlobalUnlock(h);
+        }
+    }
+    CloseClipboard();
+    return excluded;
+}
+
+// 浏览器、Office 等复制的富文本以注册格式 "HTML Format"（CF_HTML）存放
+UINT htmlClipboardFormat() {
+    return RegisterClipboardFormatA("HTML Format");
+}
 */
 import "C"
 
+import (
+	"fmt"
+	"time"
+	"unsafe"
+)
+
 // SendCtrlC 使用 Windows API 发送 Ctrl+C
 func SendCtrlC() {
 	C.sendCtrlC()
 }
 
+// SendCtrlV 使用 Windows API 发送 Ctrl+V
+func SendCtrlV() {
+	C.sendCtrlV()
+}
+
 // SendShiftWinS 发送 Shift+Win+S 快捷键
 func SendShiftWinS() {
 	C.sendShiftWinS()
@@ -93,3 +192,88 @@ func SendShiftWinSAlternative() {
 func HasClipboardImage() bool {
 	return C.hasClipboardImage() != 0
 }
+
+// openClipboard 剪贴板可能正被其他程序占用，短暂重试
+func openClipboard() error {
+	for attempt := 0; attempt < 10; attempt++ {
+		if C.OpenClipboard(nil) != 0 {
+			return nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return fmt.Errorf("open clipboard failed")
+}
+
+// snapshotNativeClipboard 复制剪贴板中所有基于 HGLOBAL 的格式（文本、HTML、RTF、DIB、文件列表等）
+func snapshotNativeClipboard() ([]clipboardFormat, error) {
+	if err := openClipboard(); err != nil {
+		return nil, err
+	}
+	defer C.CloseClipboard()
+	var formats []clipboardFormat
+	for format := C.EnumClipboardFormats(0); format != 0; format = C.EnumClipboardFormats(format) {
+		if C.isHandleClipboardFormat(format) != 0 {
+			continue
+		}
+		var size C.SIZE_T
+		data := C.readClipboardFormat(format, &size)
+		if data == nil {
+			continue
+		}
+		formats = append(formats, clipboardFormat{ID: uint32(format), Data: C.GoBytes(data, C.int(size))})
+		C.free(data)
+	}
+	return formats, nil
+}
+
+// restoreNativeClipboard 清空剪贴板后按原顺序写回全部格式
+func restoreNativeClipboard(formats []clipboardFormat) error {
+	if err := openClipboard(); err != nil {
+		return err
+	}
+	defer C.CloseClipboard()
+	if C.EmptyClipboard() == 0 {
+		return fmt.Errorf("empty clipboard failed")
+	}
+	for _, format := range formats {
+		var data unsafe.Pointer
+		if len(format.Data) > 0 {
+			data = C.CBytes(format.Data)
+		}
+		ok := C.writeClipboardFormat(C.UINT(format.ID), data, C.SIZE_T(len(format.Data)))
+		if data != nil {
+			C.free(data)
+		}
+		if ok == 0 {
+			return fmt.Errorf("restore clipboard format %d failed", format.ID)
+		}
+	}
+	return nil
+}
+
+// readClipboardHTML 读取剪贴板中的 CF_HTML 原始数据（含偏移头部），剪贴板没有 HTML 时返回 nil
+func readClipboardHTML() ([]byte, error) {
+	format := C.htmlClipboardFormat()
+	if format == 0 {
+		return nil, fmt.Errorf("register HTML clipboard format failed")
+	}
+	if C.IsClipboardFormatAvailable(format) == 0 {
+		return nil, nil
+	}
+	if err := openClipboard(); err != nil {
+		return nil, err
+	}
+	defer C.CloseClipboard()
+	var size C.SIZE_T
+	data := C.readClipboardFormat(format, &size)
+	if data == nil {
+		return nil, nil
+	}
+	defer C.free(data)
+	return C.GoBytes(data, C.int(size)), nil
+}
+
+// clipboardMarkedSensitiveNative 剪贴板内容是否被来源程序标记为不应记录
+func clipboardMarkedSensitiveNative() (bool, error) {
+	return C.isClipboardSensitive() != 0, nil
+}
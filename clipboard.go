@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,16 +14,26 @@ import (
 // ClipboardService 剪贴板服务
 type ClipboardService struct {
 	BaseService
+	commandRunner
+	history *clipboardHistory
+	watcher *clipboardWatcher
 }
 
 // NewClipboardService 创建新的剪贴板服务
 func NewClipboardService(ctx context.Context, app *App) *ClipboardService {
-	service := &ClipboardService{}
+	service := &ClipboardService{
+		commandRunner: newCommandRunner(),
+		history:       newClipboardHistory(currentLimits().ClipboardHistorySize),
+		watcher:       &clipboardWatcher{},
+	}
 	service.SetContext(ctx)
 	service.SetApp(app)
 	return service
 }
 
+const pasteRestoreDelay = 500 * time.Millisecond
+const focusReturnDelay = 200 * time.Millisecond
+
 // simulateCopy 模拟复制操作，根据操作系统选择不同的实现
 func (c *ClipboardService) simulateCopy() error {
 	if c.IsWindows() {
@@ -33,26 +44,145 @@ func (c *ClipboardService) simulateCopy() error {
 		var cmd *exec.Cmd
 		cmd = exec.Command("osascript", "-e", `tell application "System Events" to keystroke "c" using command down`)
 		return cmd.Run()
+	} else if c.IsLinux() {
+		return c.simulateCopyLinux()
 	}
 	// 其他平台...
 	return nil
 }
 
+// simulatePaste 模拟粘贴操作，根据操作系统选择不同的实现
+func (c *ClipboardService) simulatePaste() error {
+	if c.IsWindows() {
+		SendCtrlV()
+		return nil
+	} else if c.IsMacOS() {
+		return exec.Command("osascript", "-e", `tell application "System Events" to keystroke "v" using command down`).Run()
+	} else if c.IsLinux() {
+		return c.simulatePasteLinux()
+	}
+	return fmt.Errorf("paste is not supported on this platform")
+}
+
+// linuxKeyTool 发送合成按键的工具；xdotool 只能作用于 X11（含 XWayland）窗口，
+// ydotool 通过 uinput 工作，Wayland 下也可用但需要 ydotoold 在运行
+type linuxKeyTool struct {
+	name    string
+	wayland bool
+	args    []string
+}
+
+var linuxCopyTools = []linuxKeyTool{
+	{name: "xdotool", args: []string{"key", "--clearmodifiers", "ctrl+c"}},
+	// 29 = KEY_LEFTCTRL, 46 = KEY_C
+	{name: "ydotool", wayland: true, args: []string{"key", "29:1", "46:1", "46:0", "29:0"}},
+}
+
+var linuxPasteTools = []linuxKeyTool{
+	{name: "xdotool", args: []string{"key", "--clearmodifiers", "ctrl+v"}},
+	// 29 = KEY_LEFTCTRL, 47 = KEY_V
+	{name: "ydotool", wayland: true, args: []string{"key", "29:1", "47:1", "47:0", "29:0"}},
+}
+
+// linuxKeyToolFor Wayland 优先 ydotool，X11 优先 xdotool
+func (c *ClipboardService) linuxKeyToolFor(tools []linuxKeyTool) (linuxKeyTool, error) {
+	wayland := c.IsWayland()
+	ordered := append([]linuxKeyTool(nil), tools...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].wayland == wayland && ordered[j].wayland != wayland
+	})
+	var names []string
+	for _, tool := range ordered {
+		if c.hasBinaries(tool.name) {
+			return tool, nil
+		}
+		names = append(names, tool.name)
+	}
+	return linuxKeyTool{}, fmt.Errorf("no key simulation tool found, please install one of: %s", strings.Join(names, ", "))
+}
+
+// simulateCopyLinux 通过 xdotool 或 ydotool 发送 Ctrl+C
+func (c *ClipboardService) simulateCopyLinux() error {
+	return c.simulateKeyLinux(linuxCopyTools, "Ctrl+C")
+}
+
+// simulatePasteLinux 通过 xdotool 或 ydotool 发送 Ctrl+V
+func (c *ClipboardService) simulatePasteLinux() error {
+	return c.simulateKeyLinux(linuxPasteTools, "Ctrl+V")
+}
+
+func (c *ClipboardService) simulateKeyLinux(tools []linuxKeyTool, keys string) error {
+	tool, err := c.linuxKeyToolFor(tools)
+	if err != nil {
+		return err
+	}
+	c.logSvc.Info("Simulating %s with %s", keys, tool.name)
+	if _, err := c.runCommand(tool.name, tool.args...); err != nil {
+		return fmt.Errorf("%s: %w", tool.name, err)
+	}
+	return nil
+}
+
+// readPrimarySelection 读取 PRIMARY 选区（鼠标选中即有内容），不会改动剪贴板；
+// Wayland 使用 wl-paste --primary，X11 使用 xclip 或 xsel。选区为空或没有可用工具时返回空字符串
+func (c *ClipboardService) readPrimarySelection() string {
+	commands := [][]string{
+		{"xclip", "-o", "-selection", "primary"},
+		{"xsel", "--primary", "--output"},
+	}
+	if c.IsWayland() {
+		commands = append([][]string{{"wl-paste", "--primary", "--no-newline"}}, commands...)
+	}
+	for _, command := range commands {
+		if !c.hasBinaries(command[0]) {
+			continue
+		}
+		// 选区为空时 wl-paste、xclip 以非零状态退出，按空选区处理
+		output, err := c.runCommand(command[0], command[1:]...)
+		if err != nil {
+			c.logSvc.Info("%s returned no PRIMARY selection: %v", command[0], err)
+			continue
+		}
+		if text := strings.TrimSpace(string(output)); text != "" {
+			c.logSvc.Info("Got PRIMARY selection via %s, length: %d", command[0], len(text))
+			return text
+		}
+	}
+	return ""
+}
+
 // GetSelection 获取选中的文本
 func (c *ClipboardService) GetSelection() (string, error) {
+	selection, err := c.GetSelectionContent()
+	return selection.Text, err
+}
+
+// GetSelectionContent 获取选中的内容，来源提供 HTML 时同时返回 HTML 和 Markdown
+func (c *ClipboardService) GetSelectionContent() (Selection, error) {
 	c.logSvc.Info("Getting text selection from clipboard")
 
-	// 保存当前剪贴板内容
-	originalText, err := runtime.ClipboardGetText(c.ctx)
+	// Linux 优先读取 PRIMARY 选区，不需要模拟复制，也不会覆盖用户的剪贴板
+	if c.IsLinux() {
+		if text := c.readPrimarySelection(); text != "" {
+			return c.newSelection(text, c.readLinuxHTML(true)), nil
+		}
+		c.logSvc.Info("PRIMARY selection is empty, falling back to synthetic copy")
+	}
+
+	// 复制选区和恢复快照都会改写剪贴板，期间不记录剪贴板历史
+	defer c.pauseClipboardHistory()()
+
+	// 保存当前剪贴板的全部格式（文本、图片、HTML、文件列表等），复制选区后恢复
+	snapshot, err := takeClipboardSnapshot()
 	if err != nil {
-		originalText = ""
+		c.logSvc.Error("Failed to snapshot clipboard, it will not be restored: %v", err)
 	}
 
 	// 根据操作系统选择不同的复制命令
 	err = c.simulateCopy()
 	if err != nil {
 		c.logSvc.Error("Failed to simulate copy: %v", err)
-		return "", fmt.Errorf("failed to simulate copy: %v", err)
+		return Selection{}, fmt.Errorf("failed to simulate copy: %v", err)
 	}
 
 	// 添加短暂延迟确保复制完成
@@ -62,18 +192,50 @@ func (c *ClipboardService) GetSelection() (string, error) {
 	text, err := runtime.ClipboardGetText(c.ctx)
 	if err != nil {
 		c.logSvc.Error("Failed to get clipboard text: %v", err)
-		return "", fmt.Errorf("failed to get clipboard text: %v", err)
+		return Selection{}, fmt.Errorf("failed to get clipboard text: %v", err)
 	}
-
-	// 恢复原始剪贴板内容
-	if err := runtime.ClipboardSetText(c.ctx, originalText); err != nil {
-		c.logSvc.Error("Failed to restore original clipboard text: %v", err)
-		return "", fmt.Errorf("failed to restore original clipboard text: %v", err)
+	source, err := c.readSelectionHTML(false)
+	if err != nil {
+		c.logSvc.Error("Failed to get clipboard HTML: %v", err)
 	}
+	copied := clipboardFingerprint()
+
+	// 恢复原始剪贴板内容；读取后用户又复制了别的内容时不覆盖
+	c.restoreClipboardSnapshot(snapshot, copied)
 
 	trimmedText := strings.TrimSpace(text)
 	c.logSvc.Info("Successfully got text selection, length: %d", len(trimmedText))
-	return trimmedText, nil
+	if trimmedText == "" {
+		return Selection{}, nil
+	}
+	return c.newSelection(trimmedText, source), nil
+}
+
+// ReplaceSelection 把 text 写入剪贴板并模拟粘贴，替换当前焦点程序中选中的内容，之后恢复原剪贴板
+func (c *ClipboardService) ReplaceSelection(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("nothing to paste")
+	}
+	defer c.pauseClipboardHistory()()
+	snapshot, err := takeClipboardSnapshot()
+	if err != nil {
+		c.logSvc.Error("Failed to snapshot clipboard, it will not be restored: %v", err)
+	}
+	if err := runtime.ClipboardSetText(c.ctx, text); err != nil {
+		return fmt.Errorf("failed to set clipboard text: %w", err)
+	}
+	pasted := clipboardFingerprint()
+
+	if err := c.simulatePaste(); err != nil {
+		c.logSvc.Error("Failed to simulate paste: %v", err)
+		c.restoreClipboardSnapshot(snapshot, pasted)
+		return fmt.Errorf("failed to simulate paste: %w", err)
+	}
+	// 目标程序异步读取剪贴板，过早恢复会粘贴出原来的内容
+	time.Sleep(pasteRestoreDelay)
+	c.restoreClipboardSnapshot(snapshot, pasted)
+	c.logSvc.Info("Replaced selection with %d characters", len(text))
+	return nil
 }
 
 // 保持向后兼容的方法
@@ -86,3 +248,20 @@ func (a *App) GetSelection(ctx context.Context) (string, error) {
 	clipboardSvc := NewClipboardService(ctx, a)
 	return clipboardSvc.GetSelection()
 }
+
+// getSelectionContent 供快捷键流程使用，不作为前端绑定
+func (a *App) getSelectionContent(ctx context.Context) (Selection, error) {
+	clipboardSvc := NewClipboardService(ctx, a)
+	return clipboardSvc.GetSelectionContent()
+}
+
+// ReplaceSelection 前端拿到回答后调用：先隐藏窗口让焦点回到之前的程序，再粘贴回答
+func (a *App) ReplaceSelection(text string) error {
+	runtime.WindowHide(a.ctx)
+	if a.clipboardSvc.IsMacOS() {
+		// 只隐藏窗口时应用仍处于激活状态，需要隐藏整个应用焦点才会回到原程序
+		runtime.Hide(a.ctx)
+	}
+	time.Sleep(focusReturnDelay)
+	return a.clipboardSvc.ReplaceSelection(text)
+}
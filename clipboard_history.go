@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.design/x/clipboard"
+)
+
+const (
+	ClipboardEntryText  = "text"
+	ClipboardEntryImage = "image"
+)
+
+// clipboardWatchGrace x/clipboard 每秒轮询一次，我们自己改写剪贴板后再多忽略一个轮询周期
+const clipboardWatchGrace = 1500 * time.Millisecond
+
+// ClipboardEntry 剪贴板历史中的一条记录，ID 由内容摘要生成，重复复制同一内容时 ID 不变
+type ClipboardEntry struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	Text string `json:"text,omitempty"`
+	// Image 为 PNG data URL，可直接交给 AskAboutImage
+	Image    string    `json:"image,omitempty"`
+	CopiedAt time.Time `json:"copiedAt"`
+}
+
+// clipboardHistory 有上限、去重的剪贴板历史，最新的在前
+type clipboardHistory struct {
+	mu         sync.RWMutex
+	entries    []ClipboardEntry
+	limit      int
+	exclusions []*regexp.Regexp
+}
+
+// clipboardWatcher 监听的运行状态；paused 期间（复制选区、粘贴回答、恢复快照）的变化不记录
+type clipboardWatcher struct {
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	paused   int
+	resumeAt time.Time
+}
+
+// sensitiveTokenPattern 常见的 API key、访问令牌和 JWT
+var sensitiveTokenPattern = regexp.MustCompile(`^(sk-[A-Za-z0-9_-]{20,}|gh[pousr]_[A-Za-z0-9]{30,}|github_pat_\w{20,}|xox[abprs]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16}|AIza[0-9A-Za-z_-]{35}|eyJ[\w-]+\.[\w-]+\.[\w-]+)$`)
+
+var privateKeyPattern = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+
+// sensitiveClipboardTypes 密码管理器（1Password、KeePassXC、Bitwarden 等）复制密码时附带的标记类型
+var sensitiveClipboardTypes = []string{
+	"org.nspasteboard.ConcealedType",
+	"org.nspasteboard.TransientType",
+	"org.nspasteboard.AutoGeneratedType",
+	"com.agilebits.onepassword",
+	"x-kde-passwordManagerHint",
+}
+
+// looksSensitive 判断文本是否像密码、密钥或令牌
+func looksSensitive(text string) bool {
+	text = strings.TrimSpace(text)
+	if privateKeyPattern.MatchString(text) || sensitiveTokenPattern.MatchString(text) {
+		return true
+	}
+	// 8-64 个字符、没有空白且同时包含大小写字母、数字和符号的单个词，视为密码
+	if len(text) < 8 || len(text) > 64 || strings.ContainsAny(text, " \t\r\n") || strings.Contains(text, "://") {
+		return false
+	}
+	var lower, upper, digit, symbol bool
+	for _, r := range text {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	return lower && upper && digit && symbol
+}
+
+func newClipboardHistory(limit int) *clipboardHistory {
+	return &clipboardHistory{limit: limit}
+}
+
+// excluded 判断文本是否命中内置规则或用户设置的排除规则
+func (h *clipboardHistory) excluded(text string) bool {
+	if looksSensitive(text) {
+		return true
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, pattern := range h.exclusions {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// add 记录一条内容；已存在时移到最前并更新时间，超过上限时丢弃最旧的
+func (h *clipboardHistory) add(entry ClipboardEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, existing := range h.entries {
+		if existing.ID == entry.ID {
+			h.entries = append(h.entries[:i], h.entries[i+1:]...)
+			break
+		}
+	}
+	h.entries = append([]ClipboardEntry{entry}, h.entries...)
+	if len(h.entries) > h.limit {
+		h.entries = h.entries[:h.limit]
+	}
+}
+
+// setLimit 修改上限，立即丢弃超出新上限的旧记录
+func (h *clipboardHistory) setLimit(limit int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.limit = limit
+	if len(h.entries) > limit {
+		h.entries = h.entries[:limit]
+	}
+}
+
+// maxEntries 返回当前上限
+func (h *clipboardHistory) maxEntries() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.limit
+}
+
+func (h *clipboardHistory) list() []ClipboardEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]ClipboardEntry(nil), h.entries...)
+}
+
+func (h *clipboardHistory) get(id string) (ClipboardEntry, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, entry := range h.entries {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return ClipboardEntry{}, false
+}
+
+// search 不区分大小写地匹配文本记录，query 为空时返回全部
+func (h *clipboardHistory) search(query string) []ClipboardEntry {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return h.list()
+	}
+	var matched []ClipboardEntry
+	for _, entry := range h.list() {
+		if entry.Kind == ClipboardEntryText && strings.Contains(strings.ToLower(entry.Text), query) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+func (h *clipboardHistory) clear() {
+	h.mu.Lock()
+	h.entries = nil
+	h.mu.Unlock()
+}
+
+// setExclusions 设置用户的排除规则（正则表达式），同时清除已记录的命中条目
+func (h *clipboardHistory) setExclusions(patterns []string) error {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid exclusion pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.exclusions = compiled
+	kept := h.entries[:0]
+	for _, entry := range h.entries {
+		if entry.Kind == ClipboardEntryText && matchesAny(compiled, entry.Text) {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	h.entries = kept
+	return nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, text string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// pause 暂停记录，返回的函数恢复记录；可以嵌套调用
+func (w *clipboardWatcher) pause() func() {
+	w.mu.Lock()
+	w.paused++
+	w.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			w.mu.Lock()
+			w.paused--
+			w.resumeAt = time.Now().Add(clipboardWatchGrace)
+			w.mu.Unlock()
+		})
+	}
+}
+
+func (w *clipboardWatcher) ignoring() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.paused > 0 || time.Now().Before(w.resumeAt)
+}
+
+func (w *clipboardWatcher) running() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cancel != nil
+}
+
+// pauseClipboardHistory 我们自己改写剪贴板期间不记录剪贴板历史，返回恢复函数
+func (b *BaseService) pauseClipboardHistory() func() {
+	if b.app == nil || b.app.clipboardSvc == nil {
+		return func() {}
+	}
+	return b.app.clipboardSvc.watcher.pause()
+}
+
+// SetHistoryEnabled 开启或关闭剪贴板历史监听（默认关闭）；关闭时清空已记录的历史
+func (c *ClipboardService) SetHistoryEnabled(enabled bool) error {
+	c.watcher.mu.Lock()
+	defer c.watcher.mu.Unlock()
+	if !enabled {
+		if c.watcher.cancel != nil {
+			c.watcher.cancel()
+			c.watcher.cancel = nil
+		}
+		c.history.clear()
+		c.logSvc.Info("Clipboard history disabled")
+		return nil
+	}
+	if c.watcher.cancel != nil {
+		return nil
+	}
+	if err := initClipboard(); err != nil {
+		return fmt.Errorf("init clipboard: %w", err)
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.watcher.cancel = cancel
+	go c.watchClipboard(ctx, clipboard.Watch(ctx, clipboard.FmtText), clipboard.Watch(ctx, clipboard.FmtImage))
+	c.logSvc.Info("Clipboard history enabled, keeping up to %d entries", c.history.maxEntries())
+	return nil
+}
+
+func (c *ClipboardService) watchClipboard(ctx context.Context, texts, images <-chan []byte) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case data, ok := <-texts:
+			if !ok {
+				return
+			}
+			c.recordClipboard(ClipboardEntryText, data)
+		case data, ok := <-images:
+			if !ok {
+				return
+			}
+			c.recordClipboard(ClipboardEntryImage, data)
+		}
+	}
+}
+
+// recordClipboard 处理一次剪贴板变化，返回是否记录
+func (c *ClipboardService) recordClipboard(kind string, data []byte) bool {
+	if len(data) == 0 || c.watcher.ignoring() {
+		return false
+	}
+	if len(data) > currentLimits().ClipboardHistoryMaxBytes {
+		c.logSvc.Info("Skipping clipboard %s of %d bytes, too large for history", kind, len(data))
+		return false
+	}
+	if c.clipboardMarkedSensitive() {
+		c.logSvc.Info("Skipping clipboard %s marked as sensitive by the source app", kind)
+		return false
+	}
+	sum := sha256.Sum256(append([]byte(kind+":"), data...))
+	entry := ClipboardEntry{ID: hex.EncodeToString(sum[:8]), Kind: kind, CopiedAt: time.Now()}
+	if kind == ClipboardEntryText {
+		entry.Text = strings.TrimSpace(string(data))
+		if entry.Text == "" {
+			return false
+		}
+		if c.history.excluded(entry.Text) {
+			c.logSvc.Info("Skipping clipboard text matching exclusion rules")
+			return false
+		}
+	} else {
+		entry.Image = encodeDataURL("image/png", data)
+	}
+	c.history.add(entry)
+	return true
+}
+
+// clipboardMarkedSensitive 当前剪贴板是否被来源程序标记为敏感内容（不应进入剪贴板历史）
+func (c *ClipboardService) clipboardMarkedSensitive() bool {
+	if sensitive, err := clipboardMarkedSensitiveNative(); err == nil {
+		return sensitive
+	}
+	for _, t := range c.clipboardTypes() {
+		for _, marker := range sensitiveClipboardTypes {
+			if strings.EqualFold(strings.TrimSpace(t), marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clipboardTypes 列出剪贴板当前提供的类型（macOS 为 pasteboard 类型，Linux 为 MIME 类型/目标）
+func (c *ClipboardService) clipboardTypes() []string {
+	var command []string
+	switch {
+	case c.IsMacOS():
+		command = []string{"osascript", "-l", "JavaScript", "-e",
+			"ObjC.import('AppKit'); $.NSPasteboard.generalPasteboard.types.js.map(t => t.js).join('\\n')"}
+	case c.IsLinux() && c.IsWayland() && c.hasBinaries("wl-paste"):
+		command = []string{"wl-paste", "--list-types"}
+	case c.IsLinux() && c.hasBinaries("xclip"):
+		command = []string{"xclip", "-o", "-selection", "clipboard", "-t", "TARGETS"}
+	default:
+		return nil
+	}
+	output, err := c.runCommand(command[0], command[1:]...)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(strings.TrimSpace(string(output)), "\n")
+}
+
+// AskAboutEntry 把历史中的一条记录交给提示词，不需要重新选中；图片记录走 AskAboutImage
+func (c *ClipboardService) AskAboutEntry(requestID, entryID, providerID, prompt string, options ChatOptions) (ChatResponse, error) {
+	entry, ok := c.history.get(entryID)
+	if !ok {
+		return invalidRequestResponse(providerID, fmt.Errorf("clipboard entry %s not found", entryID)), nil
+	}
+	if providerID == "" {
+		providerID = PopAskProviderID
+	}
+	api := c.app.apiSvc
+	if entry.Kind == ClipboardEntryImage {
+		return api.AskAboutImage(requestID, providerID, prompt, entry.Image, "", options)
+	}
+	// 与前端 messageGenerator 的拼接方式一致
+	messages := []map[string]interface{}{{"role": "user", "content": prompt + entry.Text}}
+	return api.chatResponse(requestID, providerID, options, messages, "")
+}
+
+// 以下为剪贴板历史相关的前端绑定
+func (a *App) SetClipboardHistoryEnabled(enabled bool) error {
+	return a.clipboardSvc.SetHistoryEnabled(enabled)
+}
+
+func (a *App) ClipboardHistoryEnabled() bool {
+	return a.clipboardSvc.watcher.running()
+}
+
+func (a *App) ListClipboardHistory() []ClipboardEntry {
+	return a.clipboardSvc.history.list()
+}
+
+func (a *App) SearchClipboardHistory(query string) []ClipboardEntry {
+	return a.clipboardSvc.history.search(query)
+}
+
+func (a *App) ClearClipboardHistory() {
+	a.clipboardSvc.history.clear()
+}
+
+func (a *App) SetClipboardHistoryExclusions(patterns []string) error {
+	return a.clipboardSvc.history.setExclusions(patterns)
+}
+
+func (a *App) AskClipboardEntry(requestID, entryID, providerID, prompt string, options ChatOptions) (ChatResponse, error) {
+	return a.clipboardSvc.AskAboutEntry(requestID, entryID, providerID, prompt, options)
+}
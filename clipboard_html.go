@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Selection 选中的内容。来源程序（浏览器、Office 等）同时提供 HTML 时附带原始 HTML
+// 和转换后的 Markdown，表格、列表、链接和代码块的结构得以保留
+type Selection struct {
+	Text     string `json:"text"`
+	HTML     string `json:"html,omitempty"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// newSelection 由纯文本和 HTML 构造选区，HTML 转换失败时只保留纯文本
+func (c *ClipboardService) newSelection(text, source string) Selection {
+	selection := Selection{Text: text}
+	if strings.TrimSpace(source) == "" {
+		return selection
+	}
+	markdown, err := htmlToMarkdown(source)
+	if err != nil {
+		c.logSvc.Error("Failed to convert selection HTML to Markdown: %v", err)
+		return selection
+	}
+	selection.HTML = source
+	selection.Markdown = markdown
+	c.logSvc.Info("Converted selection HTML (%d bytes) to Markdown, length: %d", len(source), len(markdown))
+	return selection
+}
+
+// readSelectionHTML 读取剪贴板（primary 为 true 时读取 Linux PRIMARY 选区）中的 HTML，没有 HTML 时返回空字符串
+func (c *ClipboardService) readSelectionHTML(primary bool) (string, error) {
+	switch {
+	case c.IsWindows():
+		data, err := readClipboardHTML()
+		if err != nil || len(data) == 0 {
+			return "", err
+		}
+		return cfHTMLFragment(data), nil
+	case c.IsMacOS():
+		// 剪贴板没有 HTML 时 osascript 以非零状态退出
+		output, err := c.runCommand("osascript", "-e", "the clipboard as «class HTML»")
+		if err != nil {
+			return "", nil
+		}
+		data, err := decodeAppleScriptData(string(output))
+		if err != nil {
+			return "", err
+		}
+		return decodeClipboardHTML(data), nil
+	case c.IsLinux():
+		return c.readLinuxHTML(primary), nil
+	}
+	return "", nil
+}
+
+// readLinuxHTML 通过 wl-paste 或 xclip 读取 text/html 目标；xsel 不支持指定目标
+func (c *ClipboardService) readLinuxHTML(primary bool) string {
+	selection := "clipboard"
+	wlPaste := []string{"wl-paste", "--type", "text/html"}
+	if primary {
+		selection = "primary"
+		wlPaste = []string{"wl-paste", "--primary", "--type", "text/html"}
+	}
+	commands := [][]string{{"xclip", "-o", "-selection", selection, "-t", "text/html"}}
+	if c.IsWayland() {
+		commands = append([][]string{wlPaste}, commands...)
+	}
+	for _, command := range commands {
+		if !c.hasBinaries(command[0]) {
+			continue
+		}
+		// 来源没有提供 text/html 时两者都以非零状态退出
+		output, err := c.runCommand(command[0], command[1:]...)
+		if err != nil {
+			continue
+		}
+		if source := decodeClipboardHTML(output); strings.TrimSpace(source) != "" {
+			return source
+		}
+	}
+	return ""
+}
+
+// decodeClipboardHTML Firefox 在 X11 上以带 BOM 的 UTF-16LE 提供 text/html，其余来源为 UTF-8
+func decodeClipboardHTML(data []byte) string {
+	if len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE {
+		units := make([]uint16, 0, len(data)/2)
+		for i := 2; i+1 < len(data); i += 2 {
+			units = append(units, uint16(data[i])|uint16(data[i+1])<<8)
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	}
+	return strings.TrimRight(string(data), "\x00")
+}
+
+var cfHTMLOffset = regexp.MustCompile(`(?m)^(StartHTML|EndHTML|StartFragment|EndFragment):\s*(-?\d+)`)
+
+// cfHTMLFragment 取出 Windows CF_HTML 数据中的选中片段。CF_HTML 以描述字节偏移的头部开始：
+// Version:0.9 / StartHTML / EndHTML / StartFragment / EndFragment，片段前后还有 <!--StartFragment--> 注释
+func cfHTMLFragment(data []byte) string {
+	data = bytes.TrimRight(data, "\x00")
+	header := data
+	if i := bytes.IndexByte(data, '<'); i >= 0 {
+		header = data[:i]
+	}
+	offsets := map[string]int{}
+	for _, match := range cfHTMLOffset.FindAllSubmatch(header, -1) {
+		if n, err := strconv.Atoi(string(match[2])); err == nil {
+			offsets[string(match[1])] = n
+		}
+	}
+	valid := func(start, end int) bool {
+		return start > 0 && start <= end && end <= len(data)
+	}
+	if start, end := offsets["StartFragment"], offsets["EndFragment"]; valid(start, end) {
+		return string(data[start:end])
+	}
+	text := string(data)
+	const startMarker, endMarker = "<!--StartFragment-->", "<!--EndFragment-->"
+	if start := strings.Index(text, startMarker); start >= 0 {
+		text = text[start+len(startMarker):]
+		if end := strings.Index(text, endMarker); end >= 0 {
+			text = text[:end]
+		}
+		return text
+	}
+	if start, end := offsets["StartHTML"], offsets["EndHTML"]; valid(start, end) {
+		return string(data[start:end])
+	}
+	return text[len(header):]
+}
+
+// decodeAppleScriptData 解析 osascript 输出的 «data HTML3C6D657461...»，前 4 个字符为类型码，其后为十六进制数据
+func decodeAppleScriptData(output string) ([]byte, error) {
+	output = strings.TrimSpace(output)
+	const prefix = "«data "
+	if !strings.HasPrefix(output, prefix) || !strings.HasSuffix(output, "»") {
+		return nil, fmt.Errorf("unexpected osascript data: %.40q", output)
+	}
+	payload := strings.TrimSuffix(strings.TrimPrefix(output, prefix), "»")
+	if len(payload) < 4 {
+		return nil, fmt.Errorf("unexpected osascript data: %.40q", output)
+	}
+	data, err := hex.DecodeString(payload[4:])
+	if err != nil {
+		return nil, fmt.Errorf("decode osascript data: %w", err)
+	}
+	return data, nil
+}
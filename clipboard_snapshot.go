@@ -0,0 +1,203 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.design/x/clipboard"
+)
+
+// errNativeClipboardUnsupported 当前平台没有原生的多格式剪贴板快照
+var errNativeClipboardUnsupported = errors.New("native clipboard snapshot not supported on this platform")
+
+// clipboardFormat 原生剪贴板中的一种格式及其数据。Windows 上用格式 ID 区分；
+// macOS 上用 UTI 区分，Item 为所属的剪贴板条目（例如同时复制多个文件时每个文件一条）
+type clipboardFormat struct {
+	ID   uint32 `json:"-"`
+	Item int    `json:"item"`
+	Type string `json:"type"`
+	Data []byte `json:"data"`
+}
+
+// ClipboardSnapshot 剪贴板快照。Windows 和 macOS 上保存全部原生格式（文本、HTML、RTF、图片、文件列表等）。
+// Linux 上只保存文本和图片：xclip、xsel 和 wl-copy 每次只能提供一种类型，
+// 无法把 TARGETS 中的多种类型同时写回，因此不在 Linux 上保存其他格式
+type ClipboardSnapshot struct {
+	native   []clipboardFormat
+	isNative bool
+	text     []byte
+	image    []byte
+}
+
+var (
+	clipboardInitOnce sync.Once
+	clipboardInitErr  error
+)
+
+// initClipboard golang.design/x/clipboard 使用前必须初始化一次
+func initClipboard() error {
+	clipboardInitOnce.Do(func() {
+		clipboardInitErr = clipboard.Init()
+	})
+	return clipboardInitErr
+}
+
+// takeClipboardSnapshot 保存当前剪贴板的全部内容
+func takeClipboardSnapshot() (*ClipboardSnapshot, error) {
+	if formats, err := snapshotNativeClipboard(); err == nil {
+		return &ClipboardSnapshot{native: formats, isNative: true}, nil
+	} else if !errors.Is(err, errNativeClipboardUnsupported) {
+		return nil, err
+	}
+	if err := initClipboard(); err != nil {
+		return nil, fmt.Errorf("init clipboard: %w", err)
+	}
+	return &ClipboardSnapshot{text: clipboard.Read(clipboard.FmtText), image: clipboard.Read(clipboard.FmtImage)}, nil
+}
+
+// Formats 快照中保存的格式数量，用于日志
+func (s *ClipboardSnapshot) Formats() int {
+	if s.isNative {
+		return len(s.native)
+	}
+	count := 0
+	if len(s.text) > 0 {
+		count++
+	}
+	if len(s.image) > 0 {
+		count++
+	}
+	return count
+}
+
+// restore 把快照写回剪贴板。x/clipboard 每次写入都会替换整个剪贴板，
+// 因此 Linux 上只能恢复图片或文本其中之一，优先恢复图片
+func (s *ClipboardSnapshot) restore() error {
+	if s.isNative {
+		return restoreNativeClipboard(s.native)
+	}
+	if err := initClipboard(); err != nil {
+		return fmt.Errorf("init clipboard: %w", err)
+	}
+	if len(s.image) > 0 {
+		clipboard.Write(clipboard.FmtImage, s.image)
+		return nil
+	}
+	clipboard.Write(clipboard.FmtText, s.text)
+	return nil
+}
+
+// clipboardFingerprint 当前剪贴板文本和图片的摘要，用来判断剪贴板是否被用户改动过
+func clipboardFingerprint() string {
+	if err := initClipboard(); err != nil {
+		return ""
+	}
+	return fingerprintOf(clipboard.Read(clipboard.FmtText), clipboard.Read(clipboard.FmtImage))
+}
+
+// fingerprintOf 剪贴板内容为 text 和 image 时 clipboardFingerprint 的值
+func fingerprintOf(text, image []byte) string {
+	h := sha256.New()
+	h.Write(text)
+	h.Write([]byte{0})
+	h.Write(image)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// restoreClipboardSnapshot 剪贴板仍是 expected（我们自己写入或复制的内容）时才恢复快照，
+// 用户在此期间复制了别的内容则保留用户的剪贴板
+func (b *BaseService) restoreClipboardSnapshot(snapshot *ClipboardSnapshot, expected string) {
+	if snapshot == nil {
+		return
+	}
+	if current := clipboardFingerprint(); current != expected {
+		b.logSvc.Info("Clipboard changed since capture, skipping restore")
+		return
+	}
+	if err := snapshot.restore(); err != nil {
+		b.logSvc.Error("Failed to restore clipboard: %v", err)
+		return
+	}
+	b.logSvc.Info("Restored clipboard snapshot with %d formats", snapshot.Formats())
+}
+
+// pasteboardSnapshotScript 通过 JXA 调用 NSPasteboard，以 JSON 输出每个条目的全部类型，数据为 base64
+const pasteboardSnapshotScript = `ObjC.import("AppKit");
+function run() {
+  var items = $.NSPasteboard.generalPasteboard.pasteboardItems;
+  var out = [];
+  for (var i = 0; i < items.count; i++) {
+    var item = items.objectAtIndex(i);
+    var types = item.types;
+    for (var j = 0; j < types.count; j++) {
+      var type = types.objectAtIndex(j);
+      var data = item.dataForType(type);
+      if (data.isNil()) continue;
+      out.push({ item: i, type: type.js, data: data.base64EncodedStringWithOptions(0).js });
+    }
+  }
+  return JSON.stringify(out);
+}`
+
+// pasteboardRestoreScript 读取 argv[0] 中 pasteboardSnapshotScript 格式的 JSON，清空剪贴板后按条目写回
+const pasteboardRestoreScript = `ObjC.import("AppKit");
+function run(argv) {
+  var json = $.NSString.stringWithContentsOfFileEncodingError(argv[0], $.NSUTF8StringEncoding, null).js;
+  var items = [];
+  JSON.parse(json).forEach(function (f) {
+    if (!items[f.item]) items[f.item] = $.NSPasteboardItem.alloc.init;
+    var data = $.NSData.alloc.initWithBase64EncodedStringOptions(f.data, 0);
+    items[f.item].setDataForType(data, f.type);
+  });
+  var pasteboard = $.NSPasteboard.generalPasteboard;
+  pasteboard.clearContents;
+  return pasteboard.writeObjects($(items.filter(Boolean)));
+}`
+
+// snapshotPasteboard 通过 osascript 保存 macOS 剪贴板中的全部条目和类型
+func snapshotPasteboard(run func(name string, args ...string) ([]byte, error)) ([]clipboardFormat, error) {
+	output, err := run("osascript", "-l", "JavaScript", "-e", pasteboardSnapshotScript)
+	if err != nil {
+		return nil, fmt.Errorf("read pasteboard: %w", err)
+	}
+	var formats []clipboardFormat
+	if err := json.Unmarshal(output, &formats); err != nil {
+		return nil, fmt.Errorf("decode pasteboard: %w", err)
+	}
+	return formats, nil
+}
+
+// restorePasteboard 把 snapshotPasteboard 保存的内容写回 macOS 剪贴板。
+// 数据可能很大（图片），通过临时文件传给脚本，避免超过命令行参数长度限制
+func restorePasteboard(run func(name string, args ...string) ([]byte, error), formats []clipboardFormat) error {
+	data, err := json.Marshal(formats)
+	if err != nil {
+		return fmt.Errorf("encode pasteboard: %w", err)
+	}
+	file, err := os.CreateTemp("", "popask-pasteboard-*.json")
+	if err != nil {
+		return fmt.Errorf("create pasteboard file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write pasteboard file: %w", err)
+	}
+	output, err := run("osascript", "-l", "JavaScript", "-e", pasteboardRestoreScript, file.Name())
+	if err != nil {
+		return fmt.Errorf("write pasteboard: %w", err)
+	}
+	if result := strings.TrimSpace(string(output)); result != "true" {
+		return fmt.Errorf("write pasteboard: unexpected result %q", result)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build darwin
+
+package main
+
+// snapshotNativeClipboard 通过 NSPasteboard 保存全部条目和类型
+func snapshotNativeClipboard() ([]clipboardFormat, error) {
+	return snapshotPasteboard(newCommandRunner().runCommand)
+}
+
+func restoreNativeClipboard(formats []clipboardFormat) error {
+	return restorePasteboard(newCommandRunner().runCommand, formats)
+}
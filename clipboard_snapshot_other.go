@@ -0,0 +1,12 @@
+//go:build !windows && !darwin
+
+package main
+
+// snapshotNativeClipboard Linux 上没有原生快照，由 takeClipboardSnapshot 改用 x/clipboard 保存文本和图片
+func snapshotNativeClipboard() ([]clipboardFormat, error) {
+	return nil, errNativeClipboardUnsupported
+}
+
+func restoreNativeClipboard(formats []clipboardFormat) error {
+	return errNativeClipboardUnsupported
+}
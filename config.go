@@ -3,7 +3,7 @@ package main
 import "time"
 
 // AppConfig holds centralized defaults for window and HTTP client.
-// Values can be overridden via environment variables where applicable.
+// The HTTP, image and clipboard values are overwritten by ConfigService from config.toml and environment variables.
 var (
 	// Window defaults
 	DefaultWindowWidth   = 650
@@ -13,4 +13,19 @@ var (
 
 	// HTTP client timeout for API requests (OpenAI, pop-ask, etc.)
 	DefaultHTTPClientTimeout = 120 * time.Second
+
+	// Retry defaults for provider requests (429/502/503 etc.)
+	DefaultRetryMaxAttempts = 3
+	DefaultRetryBaseDelay   = 500 * time.Millisecond
+	DefaultRetryMaxDelay    = 8 * time.Second
+	// Retry-After longer than this is treated as a hard failure
+	DefaultRetryAfterMax = 30 * time.Second
+
+	// Images sent to vision models are downscaled/re-encoded above these limits
+	DefaultMaxImageDimension = 2048
+	DefaultMaxImageBytes     = 3 << 20
+
+	// Clipboard history keeps at most this many entries; larger copies are skipped
+	DefaultClipboardHistorySize     = 50
+	DefaultClipboardHistoryMaxBytes = 10 << 20
 )
@@ -0,0 +1,696 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fsnotify/fsnotify"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// ConfigPathEnv 覆盖配置文件路径，便于便携安装和测试
+	ConfigPathEnv = "POPASK_CONFIG"
+
+	configDirName  = "popask"
+	configFileName = "config.toml"
+	// configVersion 当前配置格式版本，修改字段含义或位置时递增并在 configMigrations 中追加迁移
+	configVersion = 1
+)
+
+// Duration 在配置文件中写成 "30s"、"2m" 这样的字符串
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// UserConfig 用户配置。每个值的优先级为 环境变量 > 配置文件 > 默认值，env 标签为对应的环境变量
+type UserConfig struct {
+	Version   int             `toml:"version" json:"version"`
+	Providers ProvidersConfig `toml:"providers" json:"providers"`
+	HTTP      HTTPConfig      `toml:"http" json:"http"`
+	Images    ImagesConfig    `toml:"images" json:"images"`
+	Clipboard ClipboardConfig `toml:"clipboard" json:"clipboard"`
+	UI        UIConfig        `toml:"ui" json:"ui"`
+	// Shortcuts 提示词标签到快捷键的绑定，空字符串表示不绑定；没有列出的提示词使用前端保存的快捷键
+	Shortcuts map[string]string `toml:"shortcuts,omitempty" json:"shortcuts,omitempty"`
+}
+
+// ProvidersConfig 内置提供方的地址和模型
+type ProvidersConfig struct {
+	// Fallback 提供方的尝试顺序，每项为 "provider" 或 "provider:model"，为空时按网络区域决定
+	Fallback []string `toml:"fallback" json:"fallback" env:"PROVIDER_FALLBACK"`
+	// Custom 自定义提供方，ProviderConfig 的 JSON 数组
+	Custom            string `toml:"custom" json:"custom" env:"POPASK_PROVIDERS"`
+	PopAskURL         string `toml:"popask_url" json:"popaskUrl" env:"SERVER_URL"`
+	AnthropicBaseURL  string `toml:"anthropic_base_url" json:"anthropicBaseUrl" env:"ANTHROPIC_BASE_URL"`
+	OllamaHost        string `toml:"ollama_host" json:"ollamaHost" env:"OLLAMA_HOST"`
+	OllamaModel       string `toml:"ollama_model" json:"ollamaModel" env:"OLLAMA_MODEL"`
+	OllamaVisionModel string `toml:"ollama_vision_model" json:"ollamaVisionModel" env:"OLLAMA_VISION_MODEL"`
+	LlamaCppURL       string `toml:"llamacpp_url" json:"llamacppUrl" env:"LLAMACPP_URL"`
+	LlamaCppModel     string `toml:"llamacpp_model" json:"llamacppModel" env:"LLAMACPP_MODEL"`
+	BianxieURL        string `toml:"bianxie_url" json:"bianxieUrl" env:"BIANXIE_URL"`
+	OpenHubURL        string `toml:"openhub_url" json:"openhubUrl" env:"OPENHUB_URL"`
+}
+
+// HTTPConfig 请求超时和重试
+type HTTPConfig struct {
+	Timeout          Duration `toml:"timeout" json:"timeout" env:"POPASK_HTTP_TIMEOUT"`
+	RetryMaxAttempts int      `toml:"retry_max_attempts" json:"retryMaxAttempts" env:"POPASK_RETRY_MAX_ATTEMPTS"`
+	RetryBaseDelay   Duration `toml:"retry_base_delay" json:"retryBaseDelay" env:"POPASK_RETRY_BASE_DELAY"`
+	RetryMaxDelay    Duration `toml:"retry_max_delay" json:"retryMaxDelay" env:"POPASK_RETRY_MAX_DELAY"`
+	RetryAfterMax    Duration `toml:"retry_after_max" json:"retryAfterMax" env:"POPASK_RETRY_AFTER_MAX"`
+}
+
+// ImagesConfig 发送给视觉模型的图片大小限制
+type ImagesConfig struct {
+	MaxDimension int `toml:"max_dimension" json:"maxDimension" env:"POPASK_MAX_IMAGE_DIMENSION"`
+	MaxBytes     int `toml:"max_bytes" json:"maxBytes" env:"POPASK_MAX_IMAGE_BYTES"`
+}
+
+// ClipboardConfig 剪贴板历史
+type ClipboardConfig struct {
+	HistorySize     int `toml:"history_size" json:"historySize" env:"POPASK_CLIPBOARD_HISTORY_SIZE"`
+	HistoryMaxBytes int `toml:"history_max_bytes" json:"historyMaxBytes" env:"POPASK_CLIPBOARD_HISTORY_MAX_BYTES"`
+}
+
+// UIConfig 前端设置；为空表示还没有保存过，前端第一次启动时写入 localStorage 中的值
+type UIConfig struct {
+	OCRLang           []string `toml:"ocr_lang,omitempty" json:"ocrLang,omitempty"`
+	ShowShortcutGuide *bool    `toml:"show_shortcut_guide,omitempty" json:"showShortcutGuide,omitempty"`
+	ShowPromptArea    *bool    `toml:"show_prompt_area,omitempty" json:"showPromptArea,omitempty"`
+}
+
+// ConfigState 返回给前端的配置：生效的配置、文件路径，以及被环境变量覆盖、修改文件不会生效的字段
+type ConfigState struct {
+	Config       UserConfig `json:"config"`
+	Path         string     `json:"path"`
+	EnvOverrides []string   `json:"envOverrides"`
+}
+
+// builtinConfig 默认配置，在 config.go 的包变量被配置覆盖之前取值
+var builtinConfig = defaultUserConfig()
+
+func defaultUserConfig() UserConfig {
+	return UserConfig{
+		Version: configVersion,
+		Providers: ProvidersConfig{
+			PopAskURL:        "https://beaptmqzdyznkhktjntq.functions.supabase.co",
+			AnthropicBaseURL: "https://api.anthropic.com",
+			OllamaHost:       "http://127.0.0.1:11434",
+			OllamaModel:      "llama3.2",
+			LlamaCppURL:      "http://127.0.0.1:8080",
+			LlamaCppModel:    "default",
+			BianxieURL:       "https://api.bianxie.ai",
+			OpenHubURL:       "https://api.openai-hub.com",
+		},
+		HTTP: HTTPConfig{
+			Timeout:          Duration{DefaultHTTPClientTimeout},
+			RetryMaxAttempts: DefaultRetryMaxAttempts,
+			RetryBaseDelay:   Duration{DefaultRetryBaseDelay},
+			RetryMaxDelay:    Duration{DefaultRetryMaxDelay},
+			RetryAfterMax:    Duration{DefaultRetryAfterMax},
+		},
+		Images: ImagesConfig{
+			MaxDimension: DefaultMaxImageDimension,
+			MaxBytes:     DefaultMaxImageBytes,
+		},
+		Clipboard: ClipboardConfig{
+			HistorySize:     DefaultClipboardHistorySize,
+			HistoryMaxBytes: DefaultClipboardHistoryMaxBytes,
+		},
+	}
+}
+
+// validate 校验配置，返回所有问题
+func (c UserConfig) validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+	for _, field := range []struct{ name, value string }{
+		{"providers.popask_url", c.Providers.PopAskURL},
+		{"providers.anthropic_base_url", c.Providers.AnthropicBaseURL},
+		// OLLAMA_HOST 与 ollama 一致，允许省略协议和端口，按提供方实际使用的地址校验
+		{"providers.ollama_host", ollamaHostURL(c.Providers.OllamaHost)},
+		{"providers.llamacpp_url", c.Providers.LlamaCppURL},
+		{"providers.bianxie_url", c.Providers.BianxieURL},
+		{"providers.openhub_url", c.Providers.OpenHubURL},
+	} {
+		u, err := url.Parse(field.value)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "%s: %q is not an http(s) URL", field.name, field.value)
+	}
+	for _, entry := range c.Providers.Fallback {
+		check(parseFallbackStep(entry).Provider != "", "providers.fallback: empty provider in %q", entry)
+	}
+	if c.Providers.Custom != "" {
+		var custom []ProviderConfig
+		err := json.Unmarshal([]byte(c.Providers.Custom), &custom)
+		check(err == nil, "providers.custom: %v", err)
+	}
+	check(c.HTTP.Timeout.Duration > 0, "http.timeout must be positive")
+	check(c.HTTP.RetryMaxAttempts >= 1 && c.HTTP.RetryMaxAttempts <= 10, "http.retry_max_attempts must be between 1 and 10")
+	check(c.HTTP.RetryBaseDelay.Duration > 0, "http.retry_base_delay must be positive")
+	check(c.HTTP.RetryMaxDelay.Duration >= c.HTTP.RetryBaseDelay.Duration, "http.retry_max_delay must not be less than retry_base_delay")
+	check(c.HTTP.RetryAfterMax.Duration >= 0, "http.retry_after_max must not be negative")
+	check(c.Images.MaxDimension >= 256, "images.max_dimension must be at least 256")
+	check(c.Images.MaxBytes >= 64<<10, "images.max_bytes must be at least 65536")
+	check(c.Clipboard.HistorySize >= 1 && c.Clipboard.HistorySize <= 1000, "clipboard.history_size must be between 1 and 1000")
+	check(c.Clipboard.HistoryMaxBytes > 0, "clipboard.history_max_bytes must be positive")
+	check(len(c.UI.OCRLang) <= 5, "ui.ocr_lang: at most 5 languages")
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// configLimitsMu 保护 apply 写入的包变量：配置文件变化后在监听的 goroutine 中写入，请求和剪贴板监听同时在读
+var configLimitsMu sync.RWMutex
+
+// configLimits apply 写入的包变量的快照
+type configLimits struct {
+	HTTPTimeout              time.Duration
+	RetryMaxAttempts         int
+	RetryBaseDelay           time.Duration
+	RetryMaxDelay            time.Duration
+	RetryAfterMax            time.Duration
+	MaxImageDimension        int
+	MaxImageBytes            int
+	ClipboardHistorySize     int
+	ClipboardHistoryMaxBytes int
+}
+
+// currentLimits 在读锁下读取当前的包变量，运行中读取这些值都应经过这里
+func currentLimits() configLimits {
+	configLimitsMu.RLock()
+	defer configLimitsMu.RUnlock()
+	return configLimits{
+		HTTPTimeout:              DefaultHTTPClientTimeout,
+		RetryMaxAttempts:         DefaultRetryMaxAttempts,
+		RetryBaseDelay:           DefaultRetryBaseDelay,
+		RetryMaxDelay:            DefaultRetryMaxDelay,
+		RetryAfterMax:            DefaultRetryAfterMax,
+		MaxImageDimension:        DefaultMaxImageDimension,
+		MaxImageBytes:            DefaultMaxImageBytes,
+		ClipboardHistorySize:     DefaultClipboardHistorySize,
+		ClipboardHistoryMaxBytes: DefaultClipboardHistoryMaxBytes,
+	}
+}
+
+// apply 把配置写入 config.go 中对应的包变量，之后创建的客户端和请求使用新的值
+func (c UserConfig) apply() {
+	configLimitsMu.Lock()
+	defer configLimitsMu.Unlock()
+	DefaultHTTPClientTimeout = c.HTTP.Timeout.Duration
+	DefaultRetryMaxAttempts = c.HTTP.RetryMaxAttempts
+	DefaultRetryBaseDelay = c.HTTP.RetryBaseDelay.Duration
+	DefaultRetryMaxDelay = c.HTTP.RetryMaxDelay.Duration
+	DefaultRetryAfterMax = c.HTTP.RetryAfterMax.Duration
+	DefaultMaxImageDimension = c.Images.MaxDimension
+	DefaultMaxImageBytes = c.Images.MaxBytes
+	DefaultClipboardHistorySize = c.Clipboard.HistorySize
+	DefaultClipboardHistoryMaxBytes = c.Clipboard.HistoryMaxBytes
+}
+
+// applyConfigEnv 用环境变量覆盖带 env 标签的字段，返回被覆盖字段的 JSON 路径；无法解析的值跳过并返回错误
+func applyConfigEnv(cfg *UserConfig) ([]string, error) {
+	var overrides []string
+	var errs []error
+	root := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Field(i)
+		if section.Kind() != reflect.Struct {
+			continue
+		}
+		sectionName := jsonFieldName(root.Type().Field(i))
+		for j := 0; j < section.NumField(); j++ {
+			field := section.Type().Field(j)
+			name := field.Tag.Get("env")
+			raw := os.Getenv(name)
+			if name == "" || raw == "" {
+				continue
+			}
+			if err := setConfigValue(section.Field(j), raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			overrides = append(overrides, sectionName+"."+jsonFieldName(field))
+		}
+	}
+	return overrides, errors.Join(errs...)
+}
+
+// configField 按 applyConfigEnv 返回的 JSON 路径（section.field）取出字段，找不到时返回零值
+func configField(cfg *UserConfig, path string) reflect.Value {
+	sectionName, fieldName, _ := strings.Cut(path, ".")
+	root := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		if jsonFieldName(root.Type().Field(i)) != sectionName {
+			continue
+		}
+		section := root.Field(i)
+		for j := 0; j < section.NumField(); j++ {
+			if jsonFieldName(section.Type().Field(j)) == fieldName {
+				return section.Field(j)
+			}
+		}
+	}
+	return reflect.Value{}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
+
+// setConfigValue 把环境变量的字符串写入字段；列表以逗号分隔
+func setConfigValue(value reflect.Value, raw string) error {
+	if unmarshaler, ok := value.Addr().Interface().(interface{ UnmarshalText([]byte) error }); ok {
+		return unmarshaler.UnmarshalText([]byte(raw))
+	}
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported config type %s", value.Type())
+	}
+	return nil
+}
+
+// configMigrations[i] 把版本 i 的配置文件升级到版本 i+1
+var configMigrations = []func(map[string]interface{}) error{
+	migrateConfigV0,
+}
+
+// migrateConfigV0 没有 version 的文件按 .env 的写法把环境变量名作为顶层键（如 OLLAMA_HOST = "..."），
+// 移到对应的分组下
+func migrateConfigV0(raw map[string]interface{}) error {
+	sections := reflect.TypeOf(UserConfig{})
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		if section.Type.Kind() != reflect.Struct {
+			continue
+		}
+		sectionKey, _, _ := strings.Cut(section.Tag.Get("toml"), ",")
+		for j := 0; j < section.Type.NumField(); j++ {
+			field := section.Type.Field(j)
+			env := field.Tag.Get("env")
+			value, ok := raw[env]
+			if env == "" || !ok {
+				continue
+			}
+			if s, isString := value.(string); isString && field.Type.Kind() == reflect.Slice {
+				value = strings.Split(s, ",")
+			}
+			table, _ := raw[sectionKey].(map[string]interface{})
+			if table == nil {
+				table = map[string]interface{}{}
+				raw[sectionKey] = table
+			}
+			key, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+			table[key] = value
+			delete(raw, env)
+		}
+	}
+	return nil
+}
+
+// migrateConfig 依次执行迁移，返回文件原来的版本
+func migrateConfig(raw map[string]interface{}) (int, error) {
+	version := 0
+	if v, ok := raw["version"].(int64); ok {
+		version = int(v)
+	}
+	if version > configVersion {
+		return version, fmt.Errorf("config version %d is newer than supported version %d", version, configVersion)
+	}
+	for v := version; v < configVersion; v++ {
+		if err := configMigrations[v](raw); err != nil {
+			return version, fmt.Errorf("migrate config from version %d: %w", v, err)
+		}
+	}
+	raw["version"] = int64(configVersion)
+	return version, nil
+}
+
+// decodeConfigFile 解析配置文件并迁移到当前版本，缺少的字段使用默认值；未知字段视为错误
+func decodeConfigFile(data []byte) (UserConfig, int, error) {
+	cfg := builtinConfig
+	var raw map[string]interface{}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return cfg, 0, err
+	}
+	version, err := migrateConfig(raw)
+	if err != nil {
+		return cfg, version, err
+	}
+	var migrated bytes.Buffer
+	if err := toml.NewEncoder(&migrated).Encode(raw); err != nil {
+		return cfg, version, err
+	}
+	meta, err := toml.Decode(migrated.String(), &cfg)
+	if err != nil {
+		return cfg, version, err
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return cfg, version, fmt.Errorf("unknown config keys: %s", strings.Join(keys, ", "))
+	}
+	return cfg, version, cfg.validate()
+}
+
+func encodeConfigFile(cfg UserConfig) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("# PopAsk configuration. Environment variables override the values in this file.\n\n")
+	if err := toml.NewEncoder(&b).Encode(cfg); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// mergePatch 按 JSON Merge Patch（RFC 7386）合并，patch 中的 null 删除对应字段
+func mergePatch(target, patch map[string]interface{}) {
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		if patchMap, ok := value.(map[string]interface{}); ok {
+			targetMap, _ := target[key].(map[string]interface{})
+			if targetMap == nil {
+				targetMap = map[string]interface{}{}
+			}
+			mergePatch(targetMap, patchMap)
+			target[key] = targetMap
+			continue
+		}
+		target[key] = value
+	}
+}
+
+// ConfigService 读取和保存用户配置文件
+type ConfigService struct {
+	BaseService
+	mu   sync.RWMutex
+	path string
+	// file 为配置文件中的值（缺少的字段为默认值），effective 为再叠加环境变量后的生效配置
+	file      UserConfig
+	effective UserConfig
+	overrides []string
+	// raw 最近一次读取或写入的文件内容，用于忽略自己写文件触发的变更，以及记录被拒绝修改的差异
+	raw []byte
+	// loadErr 配置文件无法使用时不允许保存，避免覆盖用户手写的内容
+	loadErr error
+	emit    func(ctx context.Context, eventName string, optionalData ...interface{})
+	watcher *fsnotify.Watcher
+	// notifyMu 串行执行 notify：文件监听的定时器和前端的 Patch 可能同时把新配置交给各服务
+	notifyMu sync.Mutex
+}
+
+// NewConfigService 创建新的配置服务并加载配置文件，文件不存在时写入默认配置
+func NewConfigService(ctx context.Context, app *App) *ConfigService {
+	service := &ConfigService{emit: runtime.EventsEmit}
+	service.SetContext(ctx)
+	service.SetApp(app)
+	if err := service.load(); err != nil {
+		service.logSvc.Error("Failed to load config, using defaults: %v", err)
+	}
+	return service
+}
+
+// userConfigDir 返回配置目录：Linux 为 ~/.config/popask，macOS 为 ~/Library/Application Support/popask，Windows 为 %AppData%\popask
+func userConfigDir() (string, error) {
+	if path := os.Getenv(ConfigPathEnv); path != "" {
+		return filepath.Dir(path), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configDirName), nil
+}
+
+// userConfigPath 返回方案的配置文件：默认方案为配置目录下的 config.toml，其他方案在 profiles/<方案名> 下
+func userConfigPath(profile string) (string, error) {
+	if path := os.Getenv(ConfigPathEnv); path != "" && profileDir("", profile) == "" {
+		return path, nil
+	}
+	dir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(profileDir(dir, profile), configFileName), nil
+}
+
+func (c *ConfigService) load() error {
+	path, err := userConfigPath(c.ActiveProfile())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.path = path
+	c.file = builtinConfig
+	c.loadErr = nil
+	defer c.refreshLocked()
+	if err != nil {
+		c.loadErr = fmt.Errorf("resolve config path: %w", err)
+		return c.loadErr
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		c.logSvc.Info("Config file not found, writing defaults to %s", path)
+		return c.saveLocked(c.file)
+	}
+	if err != nil {
+		c.loadErr = fmt.Errorf("read %s: %w", path, err)
+		return c.loadErr
+	}
+	if err := c.useFileLocked(data); err != nil {
+		c.loadErr = err
+		return err
+	}
+	c.logSvc.Info("Config loaded from %s", path)
+	return nil
+}
+
+// useFileLocked 解析文件内容作为配置文件中的值，解析失败时不修改当前配置；旧版本的文件迁移后写回
+func (c *ConfigService) useFileLocked(data []byte) error {
+	cfg, version, err := decodeConfigFile(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.path, err)
+	}
+	c.file = cfg
+	c.raw = data
+	if version != configVersion {
+		// 保留迁移前的文件
+		backup := fmt.Sprintf("%s.v%d.bak", c.path, version)
+		if err := os.WriteFile(backup, data, 0o644); err != nil {
+			return fmt.Errorf("back up config: %w", err)
+		}
+		c.logSvc.Info("Migrated config from version %d to %d, previous file saved as %s", version, configVersion, backup)
+		return c.saveLocked(cfg)
+	}
+	return nil
+}
+
+// refreshLocked 叠加环境变量并应用到包变量
+func (c *ConfigService) refreshLocked() {
+	env := c.file
+	env.UI.OCRLang = append([]string(nil), c.file.UI.OCRLang...)
+	overrides, err := applyConfigEnv(&env)
+	if err != nil {
+		c.logSvc.Error("Ignoring invalid config environment variables: %v", err)
+	}
+	// 逐个叠加环境变量，只丢弃会使配置无效的那一项，其余覆盖照常生效
+	effective := c.file
+	effective.UI.OCRLang = append([]string(nil), c.file.UI.OCRLang...)
+	kept := make([]string, 0, len(overrides))
+	for _, path := range overrides {
+		candidate := effective
+		field := configField(&candidate, path)
+		if !field.IsValid() {
+			continue
+		}
+		field.Set(configField(&env, path))
+		if err := candidate.validate(); err != nil {
+			c.logSvc.Error("Ignoring environment override of %s: %v", path, err)
+			continue
+		}
+		effective = candidate
+		kept = append(kept, path)
+	}
+	c.effective = effective
+	c.overrides = kept
+	effective.apply()
+}
+
+// saveLocked 先写临时文件再改名，保证文件要么是旧内容要么是完整的新内容
+func (c *ConfigService) saveLocked(cfg UserConfig) error {
+	cfg.Version = configVersion
+	data, err := encodeConfigFile(cfg)
+	if err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if err := c.EnsureDirectory(filepath.Dir(c.path)); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), configFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	c.file = cfg
+	c.raw = data
+	return nil
+}
+
+// Get 返回生效的配置
+func (c *ConfigService) Get() UserConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.effective
+}
+
+// State 返回生效的配置、文件路径和被环境变量覆盖的字段
+func (c *ConfigService) State() ConfigState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return ConfigState{
+		Config:       c.effective,
+		Path:         c.path,
+		EnvOverrides: append([]string{}, c.overrides...),
+	}
+}
+
+// Patch 把 JSON Merge Patch 合并到配置文件中的值，校验通过后原子地写回文件并生效
+func (c *ConfigService) Patch(patch map[string]interface{}) (ConfigState, error) {
+	c.mu.Lock()
+	if c.loadErr != nil {
+		err := c.loadErr
+		c.mu.Unlock()
+		return c.State(), fmt.Errorf("config file cannot be updated until it is fixed: %w", err)
+	}
+	next, err := patchedConfig(c.file, patch)
+	if err == nil && reflect.DeepEqual(next, c.file) {
+		c.mu.Unlock()
+		return c.State(), nil
+	}
+	if err == nil {
+		err = c.saveLocked(next)
+	}
+	prev := c.effective
+	if err == nil {
+		c.refreshLocked()
+	}
+	current := c.effective
+	c.mu.Unlock()
+	if err != nil {
+		return c.State(), err
+	}
+	c.logSvc.Info("Config updated")
+	c.notify(prev, current)
+	return c.State(), nil
+}
+
+func patchedConfig(current UserConfig, patch map[string]interface{}) (UserConfig, error) {
+	data, err := json.Marshal(current)
+	if err != nil {
+		return current, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return current, err
+	}
+	delete(patch, "version")
+	mergePatch(doc, patch)
+	if data, err = json.Marshal(doc); err != nil {
+		return current, err
+	}
+	// 被 patch 删除的字段恢复为默认值
+	next := builtinConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&next); err != nil {
+		return current, fmt.Errorf("invalid config patch: %w", err)
+	}
+	return next, next.validate()
+}
+
+// UserConfig 返回生效的用户配置；没有配置服务时（例如测试中）使用默认值加环境变量
+func (b *BaseService) UserConfig() UserConfig {
+	if app := b.GetApp(); app != nil {
+		if config := app.config(); config != nil {
+			return config.Get()
+		}
+	}
+	cfg := builtinConfig
+	applyConfigEnv(&cfg)
+	return cfg
+}
+
+// 以下为配置相关的前端绑定
+func (a *App) GetConfig() ConfigState {
+	return a.config().State()
+}
+
+func (a *App) PatchConfig(patch map[string]interface{}) (ConfigState, error) {
+	return a.config().Patch(patch)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearConfigEnv 清除所有配置相关的环境变量，避免测试受运行环境影响
+func clearConfigEnv(t *testing.T) {
+	sections := reflect.TypeOf(UserConfig{})
+	for i := 0; i < sections.NumField(); i++ {
+		if section := sections.Field(i).Type; section.Kind() == reflect.Struct {
+			for j := 0; j < section.NumField(); j++ {
+				if env := section.Field(j).Tag.Get("env"); env != "" {
+					t.Setenv(env, "")
+				}
+			}
+		}
+	}
+}
+
+// newTestConfigService 使用临时配置文件，测试结束后恢复被 apply 修改的包变量
+func newTestConfigService(t *testing.T, content string) (*ConfigService, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), configFileName)
+	t.Setenv(ConfigPathEnv, path)
+	t.Cleanup(builtinConfig.apply)
+	if content != "" {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := NewConfigService(context.Background(), NewApp())
+	c.emit = func(context.Context, string, ...interface{}) {}
+	return c, path
+}
+
+func TestConfigService_defaultsWritten(t *testing.T) {
+	clearConfigEnv(t)
+	c, path := newTestConfigService(t, "")
+	if got := c.Get(); !reflect.DeepEqual(got, builtinConfig) {
+		t.Errorf("Get() = %+v, want defaults", got)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("default config not written: %v", err)
+	}
+	if cfg, version, err := decodeConfigFile(data); err != nil || version != configVersion || !reflect.DeepEqual(cfg, builtinConfig) {
+		t.Errorf("written config = %+v, version %d, %v", cfg, version, err)
+	}
+}
+
+func TestConfigService_precedence(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("OLLAMA_MODEL", "qwen2.5")
+	t.Setenv("PROVIDER_FALLBACK", "ollama, openhub:gpt-4o-mini")
+	c, _ := newTestConfigService(t, `
+version = 1
+
+[providers]
+ollama_model = "llama3.1"
+ollama_host = "http://10.0.0.2:11434"
+
+[http]
+timeout = "45s"
+`)
+	got := c.Get()
+	if got.Providers.OllamaModel != "qwen2.5" {
+		t.Errorf("env should override file: ollama_model = %q", got.Providers.OllamaModel)
+	}
+	if got.Providers.OllamaHost != "http://10.0.0.2:11434" {
+		t.Errorf("file should override default: ollama_host = %q", got.Providers.OllamaHost)
+	}
+	if got.Providers.BianxieURL != builtinConfig.Providers.BianxieURL {
+		t.Errorf("missing key should use default: bianxie_url = %q", got.Providers.BianxieURL)
+	}
+	if want := []string{"ollama", "openhub:gpt-4o-mini"}; !reflect.DeepEqual(got.Providers.Fallback, want) {
+		t.Errorf("fallback = %q, want %q", got.Providers.Fallback, want)
+	}
+	if DefaultHTTPClientTimeout != 45*time.Second {
+		t.Errorf("DefaultHTTPClientTimeout = %v, want 45s", DefaultHTTPClientTimeout)
+	}
+	if want := []string{"providers.fallback", "providers.ollamaModel"}; !reflect.DeepEqual(c.State().EnvOverrides, want) {
+		t.Errorf("EnvOverrides = %v, want %v", c.State().EnvOverrides, want)
+	}
+}
+
+func TestConfigService_invalidEnvOverrideDropped(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("OLLAMA_HOST", "127.0.0.1:11434")
+	t.Setenv("OLLAMA_MODEL", "qwen2.5")
+	t.Setenv("POPASK_RETRY_MAX_ATTEMPTS", "50")
+	c, _ := newTestConfigService(t, "")
+	got := c.Get()
+	if got.Providers.OllamaHost != "127.0.0.1:11434" || got.Providers.OllamaModel != "qwen2.5" {
+		t.Errorf("valid overrides dropped: ollama_host = %q, ollama_model = %q", got.Providers.OllamaHost, got.Providers.OllamaModel)
+	}
+	if got.HTTP.RetryMaxAttempts != builtinConfig.HTTP.RetryMaxAttempts {
+		t.Errorf("invalid override applied: retry_max_attempts = %d", got.HTTP.RetryMaxAttempts)
+	}
+	if want := []string{"providers.ollamaHost", "providers.ollamaModel"}; !reflect.DeepEqual(c.State().EnvOverrides, want) {
+		t.Errorf("EnvOverrides = %v, want %v", c.State().EnvOverrides, want)
+	}
+}
+
+func TestConfigService_migratesUnversionedFile(t *testing.T) {
+	clearConfigEnv(t)
+	legacy := "OLLAMA_HOST = \"http://192.168.1.5:11434\"\nPROVIDER_FALLBACK = \"bianxie,popask\"\n"
+	c, path := newTestConfigService(t, legacy)
+	got := c.Get()
+	if got.Providers.OllamaHost != "http://192.168.1.5:11434" || !reflect.DeepEqual(got.Providers.Fallback, []string{"bianxie", "popask"}) {
+		t.Errorf("migrated providers = %+v", got.Providers)
+	}
+	if backup, err := os.ReadFile(path + ".v0.bak"); err != nil || string(backup) != legacy {
+		t.Errorf("backup = %q, %v", backup, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "version = 1") || strings.Contains(string(data), "OLLAMA_HOST") {
+		t.Errorf("migrated file not rewritten:\n%s", data)
+	}
+}
+
+func TestConfigService_rejectsInvalidFile(t *testing.T) {
+	clearConfigEnv(t)
+	for name, content := range map[string]string{
+		"unknown key":   "version = 1\n[http]\ntimeout = \"10s\"\nproxy = \"socks5://x\"\n",
+		"invalid value": "version = 1\n[providers]\nollama_host = \"not a url\"\n",
+		"newer version": "version = 99\n",
+		"syntax":        "version = \n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, path := newTestConfigService(t, content)
+			if got := c.Get(); !reflect.DeepEqual(got, builtinConfig) {
+				t.Errorf("Get() = %+v, want defaults", got)
+			}
+			if _, err := c.Patch(map[string]interface{}{"images": map[string]interface{}{"maxBytes": 1 << 20}}); err == nil {
+				t.Error("Patch() should refuse to overwrite a broken config file")
+			}
+			if data, _ := os.ReadFile(path); string(data) != content {
+				t.Errorf("config file modified:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestConfigService_Patch(t *testing.T) {
+	clearConfigEnv(t)
+	c, path := newTestConfigService(t, "")
+	app := c.GetApp()
+	app.clipboardSvc = NewClipboardService(context.Background(), app)
+	state, err := c.Patch(map[string]interface{}{
+		"clipboard": map[string]interface{}{"historySize": 20},
+		"ui":        map[string]interface{}{"ocrLang": []interface{}{"eng", "chi_sim"}, "showPromptArea": false},
+	})
+	if err != nil {
+		t.Fatalf("Patch() error: %v", err)
+	}
+	if limit := app.clipboardSvc.history.maxEntries(); state.Config.Clipboard.HistorySize != 20 || limit != 20 {
+		t.Errorf("history size = %d, clipboard history cap = %d", state.Config.Clipboard.HistorySize, limit)
+	}
+	if ui := state.Config.UI; !reflect.DeepEqual(ui.OCRLang, []string{"eng", "chi_sim"}) || ui.ShowPromptArea == nil || *ui.ShowPromptArea {
+		t.Errorf("ui = %+v", ui)
+	}
+	if cfg, _, err := decodeConfigFile(mustReadFile(t, path)); err != nil || cfg.Clipboard.HistorySize != 20 {
+		t.Errorf("patch not persisted: %+v, %v", cfg.Clipboard, err)
+	}
+
+	// 校验失败或字段未知时不修改文件
+	before := mustReadFile(t, path)
+	for _, patch := range []map[string]interface{}{
+		{"clipboard": map[string]interface{}{"historySize": 0}},
+		{"http": map[string]interface{}{"timeout": "soon"}},
+		{"unknown": true},
+	} {
+		if _, err := c.Patch(patch); err == nil {
+			t.Errorf("Patch(%v) should fail", patch)
+		}
+	}
+	if after := mustReadFile(t, path); string(after) != string(before) || c.Get().Clipboard.HistorySize != 20 {
+		t.Errorf("failed patch changed config:\n%s", after)
+	}
+
+	// null 恢复默认值
+	state, err = c.Patch(map[string]interface{}{"clipboard": map[string]interface{}{"historySize": nil}})
+	if err != nil || state.Config.Clipboard.HistorySize != builtinConfig.Clipboard.HistorySize {
+		t.Errorf("Patch(null) = %d, %v", state.Config.Clipboard.HistorySize, err)
+	}
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
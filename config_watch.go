@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ConfigChangedEvent 配置文件被修改并生效后推送，数据为 ConfigState
+const ConfigChangedEvent = "config:changed"
+
+// configReloadDelay 编辑器保存时往往连续产生多个事件，最后一个事件之后等待这么久再重新加载
+const configReloadDelay = 200 * time.Millisecond
+
+// Watch 监听配置文件，手动编辑或同步工具修改后立即生效。
+// 监听的是所在目录：很多编辑器保存时先写临时文件再改名替换，直接监听文件会丢失后续修改
+func (c *ConfigService) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create config watcher: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(c.path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("watch config dir: %w", err)
+	}
+	c.mu.Lock()
+	c.watcher = watcher
+	c.mu.Unlock()
+	go c.watchLoop(watcher)
+	c.logSvc.Info("Watching %s for changes", c.path)
+	return nil
+}
+
+func (c *ConfigService) watchLoop(watcher *fsnotify.Watcher) {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(c.path) || !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			if timer == nil {
+				timer = time.AfterFunc(configReloadDelay, c.reload)
+			} else {
+				timer.Reset(configReloadDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			c.logSvc.Error("Config watcher error: %v", err)
+		}
+	}
+}
+
+// Close 停止监听配置文件
+func (c *ConfigService) Close() error {
+	c.mu.Lock()
+	watcher := c.watcher
+	c.watcher = nil
+	c.mu.Unlock()
+	if watcher == nil {
+		return nil
+	}
+	return watcher.Close()
+}
+
+// reload 重新读取配置文件。内容无效时保留当前配置，并在日志中记录与上一次有效内容的差异
+func (c *ConfigService) reload() {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			c.logSvc.Error("Failed to read config %s: %v", c.path, err)
+		}
+		return
+	}
+
+	c.mu.Lock()
+	if bytes.Equal(data, c.raw) {
+		c.mu.Unlock()
+		return
+	}
+	previous := c.raw
+	prev := c.effective
+	if err := c.useFileLocked(data); err != nil {
+		c.mu.Unlock()
+		c.logSvc.Error("Rejected config change, keeping the previous config: %v\n%s", err, lineDiff(string(previous), string(data)))
+		return
+	}
+	c.loadErr = nil
+	c.refreshLocked()
+	next := c.effective
+	c.mu.Unlock()
+
+	c.logSvc.Info("Config reloaded from %s", c.path)
+	c.notify(prev, next)
+}
+
+// notify 把新配置交给各服务并通知前端
+func (c *ConfigService) notify(prev, next UserConfig) {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	if app := c.GetApp(); app != nil {
+		app.applyConfig(prev, next)
+	}
+	if c.emit != nil {
+		c.emit(c.GetContext(), ConfigChangedEvent, c.State())
+	}
+}
+
+// lineDiff 返回按行比较的差异，删除的行以 "-" 开头，新增的行以 "+" 开头，未改变的行省略
+func lineDiff(before, after string) string {
+	a := strings.Split(strings.TrimSuffix(before, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(after, "\n"), "\n")
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&out, "-%s\n", a[i])
+			i++
+		default:
+			fmt.Fprintf(&out, "+%s\n", b[j])
+			j++
+		}
+	}
+	return out.String()
+}
+
+// applyConfig 配置变化后更新已创建的服务；HTTP、图片和剪贴板的限制已经由 refreshLocked 写入包变量，
+// 这里再交给创建时读取过这些限制的 HTTP 客户端和剪贴板历史
+func (a *App) applyConfig(prev, next UserConfig) {
+	if a.apiSvc != nil && prev.HTTP.Timeout != next.HTTP.Timeout {
+		a.apiSvc.setHTTPTimeout(next.HTTP.Timeout.Duration)
+	}
+	if a.clipboardSvc != nil && prev.Clipboard.HistorySize != next.Clipboard.HistorySize {
+		a.clipboardSvc.history.setLimit(next.Clipboard.HistorySize)
+	}
+	if a.apiSvc != nil && !reflect.DeepEqual(prev.Providers, next.Providers) {
+		a.apiSvc.applyProviderConfig(prev.Providers, next.Providers)
+	}
+	if a.shortcutSvc != nil && !maps.Equal(prev.Shortcuts, next.Shortcuts) {
+		a.shortcutSvc.reloadBindings()
+	}
+}
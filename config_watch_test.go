@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigService_watchReloadsProviders(t *testing.T) {
+	clearConfigEnv(t)
+	path := filepath.Join(t.TempDir(), configFileName)
+	t.Setenv(ConfigPathEnv, path)
+	t.Cleanup(builtinConfig.apply)
+
+	app := NewApp()
+	app.configSvc = NewConfigService(context.Background(), app)
+	changed := make(chan ConfigState, 4)
+	app.configSvc.emit = func(ctx context.Context, name string, data ...interface{}) {
+		if name == ConfigChangedEvent {
+			changed <- data[0].(ConfigState)
+		}
+	}
+	app.apiSvc = NewAPIService(context.Background(), app)
+	if err := app.apiSvc.providers.Register(ProviderConfig{ID: "runtime", BaseURL: "http://127.0.0.1:9000"}); err != nil {
+		t.Fatal(err)
+	}
+	app.clipboardSvc = NewClipboardService(context.Background(), app)
+	for i := 0; i < 10; i++ {
+		app.clipboardSvc.history.add(ClipboardEntry{ID: fmt.Sprint(i), Kind: ClipboardEntryText, Text: fmt.Sprint(i)})
+	}
+	if err := app.configSvc.Watch(); err != nil {
+		t.Fatalf("Watch() error: %v", err)
+	}
+	defer app.configSvc.Close()
+
+	waitChange := func() ConfigState {
+		t.Helper()
+		select {
+		case state := <-changed:
+			return state
+		case <-time.After(5 * time.Second):
+			t.Fatal("no config:changed event after editing the file")
+			return ConfigState{}
+		}
+	}
+
+	edited := "version = 1\n\n[providers]\nollama_host = \"http://10.0.0.9:11434\"\nfallback = [\"ollama\"]\n"
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if state := waitChange(); state.Config.Providers.OllamaHost != "http://10.0.0.9:11434" {
+		t.Errorf("config:changed state = %+v", state.Config.Providers)
+	}
+	provider, err := app.apiSvc.providers.Get(OllamaProviderID)
+	if err != nil || provider.Config().BaseURL != "http://10.0.0.9:11434" {
+		t.Errorf("ollama provider not swapped: %+v, %v", provider, err)
+	}
+	if _, err := app.apiSvc.providers.Get("runtime"); err != nil {
+		t.Errorf("runtime provider dropped on reload: %v", err)
+	}
+	if steps := app.apiSvc.FallbackChain(); len(steps) != 1 || steps[0].Provider != OllamaProviderID {
+		t.Errorf("FallbackChain() = %v", steps)
+	}
+
+	// 无效的修改被拒绝，保留之前的配置
+	if err := os.WriteFile(path, []byte(strings.Replace(edited, "http://10.0.0.9:11434", "not a url", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(3 * configReloadDelay)
+	select {
+	case state := <-changed:
+		t.Errorf("invalid edit applied: %+v", state.Config.Providers)
+	default:
+	}
+	if got := app.configSvc.Get().Providers.OllamaHost; got != "http://10.0.0.9:11434" {
+		t.Errorf("OllamaHost after invalid edit = %q", got)
+	}
+
+	// 修正后再次生效；PatchConfig 写文件不会重复触发
+	if err := os.WriteFile(path, []byte(edited+"\n[clipboard]\nhistory_size = 7\n\n[http]\ntimeout = \"45s\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if state := waitChange(); state.Config.Clipboard.HistorySize != 7 {
+		t.Errorf("history size after fix = %d", state.Config.Clipboard.HistorySize)
+	}
+	// 创建时读取过限制的剪贴板历史和 HTTP 客户端也要更新
+	if limit, entries := app.clipboardSvc.history.maxEntries(), app.clipboardSvc.history.list(); limit != 7 || len(entries) != 7 || entries[0].ID != "9" {
+		t.Errorf("clipboard history cap = %d, %d entries (newest %+v), want the 7 newest", limit, len(entries), entries[0])
+	}
+	client, streamClient := app.apiSvc.httpClients()
+	if client.Timeout != 45*time.Second || streamClient.Transport.(*http.Transport).ResponseHeaderTimeout != 45*time.Second {
+		t.Errorf("HTTP timeouts after reload = %v, %v, want 45s", client.Timeout, streamClient.Transport.(*http.Transport).ResponseHeaderTimeout)
+	}
+	if _, err := app.configSvc.Patch(map[string]interface{}{"images": map[string]interface{}{"maxDimension": 1024}}); err != nil {
+		t.Fatalf("Patch() error: %v", err)
+	}
+	waitChange()
+	time.Sleep(3 * configReloadDelay)
+	if len(changed) != 0 {
+		t.Errorf("own write triggered %d extra reloads", len(changed))
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	before := "version = 1\n[http]\ntimeout = \"30s\"\n"
+	after := "version = 1\n[http]\ntimeout = \"soon\"\nproxy = true\n"
+	want := "-timeout = \"30s\"\n+timeout = \"soon\"\n+proxy = true\n"
+	if got := lineDiff(before, after); got != want {
+		t.Errorf("lineDiff() =\n%s\nwant\n%s", got, want)
+	}
+	if got := lineDiff(before, before); got != "" {
+		t.Errorf("lineDiff() of equal text = %q", got)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrorCategory 前端据此区分错误类型并给出对应提示
+type ErrorCategory string
+
+const (
+	ErrorCategoryAuth           ErrorCategory = "auth"
+	ErrorCategoryQuota          ErrorCategory = "quota"
+	ErrorCategoryRateLimit      ErrorCategory = "rate_limit"
+	ErrorCategoryContextLength  ErrorCategory = "context_length"
+	ErrorCategoryInvalidRequest ErrorCategory = "invalid_request"
+	ErrorCategoryNetwork        ErrorCategory = "network"
+	ErrorCategoryTimeout        ErrorCategory = "timeout"
+	ErrorCategoryCancelled      ErrorCategory = "cancelled"
+	ErrorCategoryServer         ErrorCategory = "server"
+	// ErrorCategoryVaultLocked 密钥保存在 vault 中但 vault 未解锁或尚未设置，前端据此弹出解锁提示
+	ErrorCategoryVaultLocked ErrorCategory = "vault_locked"
+	ErrorCategoryUnknown     ErrorCategory = "unknown"
+)
+
+// APIError 结构化的对话错误，随 ChatResponse.Error 和 chat:error 事件返回给前端
+type APIError struct {
+	Category ErrorCategory `json:"category"`
+	Provider string        `json:"provider,omitempty"`
+	// ProviderCode 提供方自己的错误码，例如 invalid_api_key、overloaded_error
+	ProviderCode string `json:"providerCode,omitempty"`
+	// Status HTTP 状态码，非 HTTP 错误时为 0
+	Status    int    `json:"status,omitempty"`
+	Retryable bool   `json:"retryable"`
+	Message   string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// errorParser 由提供方实现，从错误响应体中取出错误码和错误信息
+type errorParser interface {
+	parseError(body []byte) (code, message string)
+}
+
+// parseError OpenAI 兼容接口：{"error":{"message":"...","type":"...","code":"..."}}
+func (p *openAIProvider) parseError(body []byte) (string, string) {
+	var resp struct {
+		Error struct {
+			Message string      `json:"message"`
+			Type    string      `json:"type"`
+			Code    interface{} `json:"code"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return "", ""
+	}
+	code, _ := resp.Error.Code.(string)
+	if code == "" {
+		code = resp.Error.Type
+	}
+	return code, resp.Error.Message
+}
+
+// parseError pop-ask：{"code":500,"data":null,"message":"..."}，message 多为 OpenAI 的原始错误
+func (p *popAskProvider) parseError(body []byte) (string, string) {
+	var resp struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return "", ""
+	}
+	return "", resp.Message
+}
+
+// parseError Anthropic：{"type":"error","error":{"type":"...","message":"..."}}
+func (p *anthropicProvider) parseError(body []byte) (string, string) {
+	var resp struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return "", ""
+	}
+	return resp.Error.Type, resp.Error.Message
+}
+
+// parseError Ollama：{"error":"..."}
+func (p *ollamaProvider) parseError(body []byte) (string, string) {
+	var resp struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return "", ""
+	}
+	return "", resp.Error
+}
+
+// classifyError 把任意错误转换为 APIError
+func (api *APIService) classifyError(providerID string, err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	result := &APIError{Category: ErrorCategoryUnknown, Provider: providerID, Message: err.Error()}
+
+	var statusErr *HTTPStatusError
+	var anthropicErr *AnthropicAPIError
+	var netErr net.Error
+	switch {
+	case isCancelled(err):
+		result.Category = ErrorCategoryCancelled
+	case errors.Is(err, ErrVaultLocked):
+		result.Category = ErrorCategoryVaultLocked
+		result.Status = http.StatusLocked
+	case errors.As(err, &statusErr):
+		result.Status = statusErr.StatusCode
+		if provider, getErr := api.providers.Get(providerID); getErr == nil {
+			if parser, ok := provider.(errorParser); ok {
+				result.ProviderCode, result.Message = parser.parseError(statusErr.Body)
+			}
+		}
+		if result.Message == "" {
+			result.Message = strings.TrimSpace(string(statusErr.Body))
+		}
+		if result.Message == "" {
+			result.Message = http.StatusText(statusErr.StatusCode)
+		}
+		result.Category = categoryFor(statusErr.StatusCode, result.ProviderCode, result.Message)
+	case errors.As(err, &anthropicErr):
+		result.Status = anthropicErr.StatusCode
+		result.ProviderCode = anthropicErr.Type
+		result.Message = anthropicErr.Message
+		result.Category = categoryFor(anthropicErr.StatusCode, anthropicErr.Type, anthropicErr.Message)
+	case errors.Is(err, context.DeadlineExceeded):
+		result.Category = ErrorCategoryTimeout
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			result.Category = ErrorCategoryTimeout
+		} else {
+			result.Category = ErrorCategoryNetwork
+		}
+	}
+	result.Retryable = isRetryableCategory(result.Category, result.Status)
+	return result
+}
+
+// categoryFor 先按提供方错误码归类，再按 HTTP 状态码兜底
+func categoryFor(status int, code, message string) ErrorCategory {
+	lowerMessage := strings.ToLower(message)
+	switch code {
+	case "invalid_api_key", "authentication_error", "permission_error":
+		return ErrorCategoryAuth
+	case "insufficient_quota", "billing_error":
+		return ErrorCategoryQuota
+	case "rate_limit_exceeded", "rate_limit_error":
+		return ErrorCategoryRateLimit
+	case "context_length_exceeded", "string_above_max_length":
+		return ErrorCategoryContextLength
+	case "overloaded_error", "api_error":
+		return ErrorCategoryServer
+	}
+	if strings.Contains(lowerMessage, "context length") || strings.Contains(lowerMessage, "context window") ||
+		strings.Contains(lowerMessage, "prompt is too long") || strings.Contains(lowerMessage, "too many tokens") {
+		return ErrorCategoryContextLength
+	}
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorCategoryAuth
+	case status == http.StatusPaymentRequired:
+		return ErrorCategoryQuota
+	case status == http.StatusTooManyRequests:
+		if strings.Contains(lowerMessage, "quota") {
+			return ErrorCategoryQuota
+		}
+		return ErrorCategoryRateLimit
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return ErrorCategoryTimeout
+	case status >= 500:
+		return ErrorCategoryServer
+	case status >= 400:
+		return ErrorCategoryInvalidRequest
+	}
+	return ErrorCategoryUnknown
+}
+
+func isRetryableCategory(category ErrorCategory, status int) bool {
+	switch category {
+	case ErrorCategoryRateLimit, ErrorCategoryNetwork, ErrorCategoryTimeout:
+		return true
+	case ErrorCategoryServer:
+		return status == 0 || DefaultRetryPolicy().retryableStatus(status)
+	}
+	return false
+}
+
+// invalidRequestResponse 请求参数错误（消息 JSON 无法解析、采样参数越界等）时返回 400
+func invalidRequestResponse(providerID string, err error) ChatResponse {
+	return ChatResponse{Code: 400, Data: err.Error(), Provider: providerID, Error: &APIError{
+		Category: ErrorCategoryInvalidRequest, Provider: providerID, Message: err.Error(),
+	}}
+}
+
+// errorResponse 把错误包装成带结构化错误的 ChatResponse；vault 锁定时 code 固定为 423，前端据此提示解锁
+func (api *APIService) errorResponse(providerID string, code int, err error) ChatResponse {
+	apiErr := api.classifyError(providerID, err)
+	if apiErr.Category == ErrorCategoryVaultLocked {
+		code = http.StatusLocked
+	}
+	return ChatResponse{Code: code, Data: apiErr.Message, Provider: apiErr.Provider, Error: apiErr}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// FallbackEnv 以逗号分隔的 fallback 顺序，例如 "popask,bianxie,openhub:gpt-4o-mini"
+const FallbackEnv = "PROVIDER_FALLBACK"
+
+// ChatFallbackEvent 切换到下一个提供方时推送，前端可以提示"正在尝试 xxx"
+const ChatFallbackEvent = "chat:fallback"
+
+// FallbackStep fallback 链中的一项；Model 为空时使用提供方默认模型
+type FallbackStep struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+}
+
+// parseFallbackStep 解析 "provider" 或 "provider:model"，只按第一个冒号切分，兼容 llama3.2:3b 这类模型名
+func parseFallbackStep(entry string) FallbackStep {
+	providerID, model, _ := strings.Cut(strings.TrimSpace(entry), ":")
+	return FallbackStep{Provider: strings.TrimSpace(providerID), Model: strings.TrimSpace(model)}
+}
+
+// options 采样参数沿用本次请求的设置，模型由 fallback 项决定：不同提供方的模型名通常互不通用
+func (s FallbackStep) options(opts ChatOptions) ChatOptions {
+	opts.Model = s.Model
+	return opts
+}
+
+// FallbackChain 有序的提供方列表，前一个因可重试错误或网络错误失败时依次尝试下一个
+type FallbackChain struct {
+	mu    sync.RWMutex
+	steps []FallbackStep
+	// detectOnce 未配置时只检测一次网络区域来决定默认顺序
+	detectOnce sync.Once
+	detected   []FallbackStep
+}
+
+// NewFallbackChain 使用配置中的顺序（providers.fallback 或 PROVIDER_FALLBACK），为空时使用按区域决定的默认顺序
+func NewFallbackChain(entries []string) *FallbackChain {
+	chain := &FallbackChain{}
+	for _, entry := range entries {
+		if step := parseFallbackStep(entry); step.Provider != "" {
+			chain.steps = append(chain.steps, step)
+		}
+	}
+	return chain
+}
+
+// defaultFallbackSteps 国内优先使用可直连的 Bianxie、OpenHub，其余地区优先 pop-ask
+func defaultFallbackSteps(inChina bool) []FallbackStep {
+	if inChina {
+		return []FallbackStep{{Provider: BianxieProviderID}, {Provider: OpenHubProviderID}, {Provider: PopAskProviderID}}
+	}
+	return []FallbackStep{{Provider: PopAskProviderID}, {Provider: BianxieProviderID}, {Provider: OpenHubProviderID}}
+}
+
+// FallbackChain 返回当前生效的 fallback 顺序
+func (api *APIService) FallbackChain() []FallbackStep {
+	chain := api.fallback
+	chain.mu.RLock()
+	steps := append([]FallbackStep(nil), chain.steps...)
+	chain.mu.RUnlock()
+	if len(steps) > 0 {
+		return steps
+	}
+	chain.detectOnce.Do(func() {
+		chain.detected = defaultFallbackSteps(api.IsUserInChina())
+		api.logSvc.Info("Default fallback chain: %v", chain.detected)
+	})
+	return append([]FallbackStep(nil), chain.detected...)
+}
+
+// SetFallbackChain 设置 fallback 顺序，entries 为 "provider" 或 "provider:model"；传空列表恢复默认顺序
+func (api *APIService) SetFallbackChain(entries []string) error {
+	steps := make([]FallbackStep, 0, len(entries))
+	for _, entry := range entries {
+		step := parseFallbackStep(entry)
+		if _, err := api.providers.Get(step.Provider); err != nil {
+			return err
+		}
+		steps = append(steps, step)
+	}
+	api.fallback.mu.Lock()
+	api.fallback.steps = steps
+	api.fallback.mu.Unlock()
+	api.logSvc.Info("Fallback chain set to %v", steps)
+	return nil
+}
+
+// fallbackCandidates 过滤掉未注册或缺少 key 的提供方，避免每次都先撞一次鉴权失败
+func (api *APIService) fallbackCandidates() []FallbackStep {
+	var candidates []FallbackStep
+	for _, step := range api.FallbackChain() {
+		provider, err := api.providers.Get(step.Provider)
+		if err != nil {
+			api.logSvc.Error("Skipping fallback provider: %v", err)
+			continue
+		}
+		cfg := provider.Config()
+		// vault 锁定时仍然尝试，让请求报告 vault_locked
+		if cfg.AuthScheme != AuthSchemeNone && !hasAPIKey(api, cfg) {
+			api.logSvc.Info("Skipping fallback provider %s: no API key", step.Provider)
+			continue
+		}
+		candidates = append(candidates, step)
+	}
+	return candidates
+}
+
+// shouldFallback 只有可重试错误（限流、过载、超时）和网络错误才切换提供方；
+// 鉴权、额度、上下文过长等错误换一家也多半失败，直接返回给用户
+func shouldFallback(apiErr *APIError) bool {
+	switch apiErr.Category {
+	case ErrorCategoryCancelled:
+		return false
+	case ErrorCategoryNetwork, ErrorCategoryTimeout:
+		return true
+	}
+	return apiErr.Retryable
+}
+
+// chatFallback 按 fallback 顺序依次调用，返回内容和实际回答的一项（模型为空时填入提供方默认模型）；
+// 失败时返回的一项只有最后失败的提供方。
+// stream 为 true 时走流式接口，已经推送过增量的提供方失败后不再切换，避免前端内容重复
+func (api *APIService) chatFallback(ctx context.Context, requestID string, opts ChatOptions, messages []map[string]interface{}, stream bool, onDelta func(string)) (string, FallbackStep, error) {
+	candidates := api.fallbackCandidates()
+	if len(candidates) == 0 {
+		return "", FallbackStep{}, &APIError{Category: ErrorCategoryAuth, Message: "no provider in fallback chain is configured"}
+	}
+	var lastErr *APIError
+	for i, step := range candidates {
+		if i > 0 {
+			api.logSvc.Info("Falling back from %s to %s, requestId: %s", candidates[i-1].Provider, step.Provider, requestID)
+			api.emit(api.ctx, ChatFallbackEvent, map[string]interface{}{
+				"requestId": requestID,
+				"from":      candidates[i-1].Provider,
+				"to":        step.Provider,
+				"errorInfo": lastErr,
+			})
+		}
+		var content string
+		var err error
+		emitted := false
+		if stream {
+			content, err = api.ChatStream(ctx, step.Provider, step.options(opts), messages, "", func(delta string) {
+				emitted = true
+				onDelta(delta)
+			})
+		} else {
+			content, err = api.Chat(ctx, step.Provider, step.options(opts), messages, "")
+		}
+		if err = cancelledError(ctx, requestID, err); err == nil {
+			if provider, err := api.providers.Get(step.Provider); err == nil {
+				step.Model = modelOrDefault(provider.Config(), step.Model)
+			}
+			return content, step, nil
+		}
+		lastErr = api.classifyError(step.Provider, err)
+		if emitted || !shouldFallback(lastErr) {
+			break
+		}
+	}
+	return "", FallbackStep{Provider: lastErr.Provider}, lastErr
+}
+
+// ChatWithFallback 按 fallback 顺序对话，ChatResponse.Provider/Model 为实际回答的提供方和模型
+func (api *APIService) ChatWithFallback(requestID, messages string, options ChatOptions) (ChatResponse, error) {
+	parsedMessages, err := parseMessages(messages)
+	if err == nil {
+		err = options.Validate()
+	}
+	if err != nil {
+		api.logSvc.Error("ChatWithFallback invalid request: %v", err)
+		return invalidRequestResponse("", err), nil
+	}
+	ctx, requestID, done := api.beginRequest(requestID, "fallback")
+	defer done()
+	content, step, err := api.chatFallback(ctx, requestID, options, parsedMessages, false, nil)
+	if err != nil {
+		apiErr := api.classifyError(step.Provider, err)
+		code := 500
+		if apiErr.Category == ErrorCategoryCancelled {
+			code = ChatCodeCancelled
+		}
+		return api.errorResponse(step.Provider, code, apiErr), nil
+	}
+	api.logSvc.Info("ChatWithFallback answered by %s (%s)", step.Provider, step.Model)
+	return ChatResponse{Code: 200, Data: content, Provider: step.Provider, Model: step.Model}, nil
+}
+
+// StreamWithFallback 流式版本，chat:done 的 provider 为实际回答的提供方
+func (api *APIService) StreamWithFallback(requestID, messages string, options ChatOptions) (string, error) {
+	parsedMessages, err := parseMessages(messages)
+	if err != nil {
+		return "", err
+	}
+	if err := options.Validate(); err != nil {
+		return "", err
+	}
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	return api.runStream(requestID, "fallback", func(ctx context.Context, onDelta func(string)) (string, string, error) {
+		content, step, err := api.chatFallback(ctx, requestID, options, parsedMessages, true, onDelta)
+		return content, step.Provider, err
+	}), nil
+}
+
+// 以下为 fallback 相关的前端绑定
+func (a *App) GetFallbackChain() []FallbackStep {
+	return a.apiSvc.FallbackChain()
+}
+
+func (a *App) SetFallbackChain(entries []string) error {
+	if err := a.apiSvc.SetFallbackChain(entries); err != nil {
+		return fmt.Errorf("set fallback chain: %w", err)
+	}
+	return nil
+}
+
+func (a *App) ChatWithFallback(requestID, messages string, options ChatOptions) (ChatResponse, error) {
+	return a.apiSvc.ChatWithFallback(requestID, messages, options)
+}
+
+func (a *App) StreamWithFallback(requestID, messages string, options ChatOptions) (string, error) {
+	return a.apiSvc.StreamWithFallback(requestID, messages, options)
+}
@@ -2,13 +2,23 @@ import { Layout, Spin, Tabs } from "antd";
 import { useAppStore } from "./store";
 import { Suspense, useCallback, useEffect, useMemo, useState } from "react";
 import { IsUserInChina } from "../wailsjs/go/main/App";
-import { syncShortcutListToBackend } from "./utils";
+import {
+  importHistoryToBackend,
+  listenSelectionReplaced,
+  migrateOpenAIKeyToVault,
+  syncOCRLangToBackend,
+  syncActiveProfile,
+  syncDailyUsageToBackend,
+  syncShortcutListToBackend,
+  syncUIConfig,
+} from "./utils";
 
 import ChatComp from "./components/ChatComp";
 import PromptComp from "./components/PromptComp";
 import ChatHistoryComp from "./components/ChatHistoryComp";
 import SettingsComp from "./components/SettingsComp";
 import ShortcutGuideComp from "./components/ShortcutGuideComp";
+import VaultComp from "./components/VaultComp";
 import "./app.css";
 import styles from "./App.module.css";
 
@@ -66,6 +76,7 @@ const App = () => {
         label: "Chat History",
         children: (
           <ChatHistoryComp
+            activeKey={activeKey}
             setActiveKey={setActiveKey}
             setChatMessages={setChatMessages}
           />
@@ -80,8 +91,17 @@ const App = () => {
     [activeKey, chatMessages, isMac, setActiveKey, setChatMessages],
   );
   useEffect(() => {
-    const { promptList, systemShortcuts } = useAppStore.getState();
-    syncShortcutListToBackend(promptList, systemShortcuts);
+    syncActiveProfile().then((ready) => {
+      if (!ready) return;
+      const { promptList, systemShortcuts, OCRLang } = useAppStore.getState();
+      syncShortcutListToBackend(promptList, systemShortcuts);
+      syncOCRLangToBackend(OCRLang);
+      syncDailyUsageToBackend();
+      listenSelectionReplaced();
+      importHistoryToBackend();
+      migrateOpenAIKeyToVault();
+      syncUIConfig();
+    });
   }, []);
 
   return (
@@ -106,6 +126,8 @@ const App = () => {
           onClose={handleCloseShortcutGuide}
           onNeverShow={handleNeverShowShortcutGuide}
         />
+
+        <VaultComp />
       </Suspense>
     </Layout>
   );
@@ -1,13 +1,11 @@
 import { useState, useCallback } from "react";
-import { ChatAPI } from "../../../../wailsjs/go/main/App";
+import { Chat, ChatAPI } from "../../../../wailsjs/go/main/App";
 import { useAppStore } from "../../../store";
-import { historyGenerator } from "../../../utils";
+import { recordAskHistory } from "../../../utils";
 
 export function useAskChat({
   promptList,
   selectedPrompt,
-  historyList,
-  setHistoryList,
   messageApi,
 }) {
   const setRecentPrompts = useAppStore((s) => s.setRecentPrompts);
@@ -15,17 +13,25 @@ export function useAskChat({
   const [isAskLoading, setIsAskLoading] = useState(false);
 
   const handleChat = useCallback(
-    async (message) => {
+    async (message, { provider = "", model = "" } = {}) => {
       setChatResponse(null);
       setIsAskLoading(true);
-      const response = await ChatAPI(message);
+      // a prompt bound to a provider (set on its shortcut) overrides the default
+      const response = provider
+        ? await Chat(
+            "",
+            provider,
+            model,
+            JSON.stringify([{ role: "user", content: message }]),
+          )
+        : await ChatAPI(message);
       setIsAskLoading(false);
       if (response.code === 200) {
         setChatResponse(response.data);
-        setHistoryList([
-          historyGenerator(message, response.data),
-          ...historyList,
-        ]);
+        recordAskHistory(message, response.data, {
+          prompt: selectedPrompt,
+          response,
+        });
         const prompt = promptList.find((p) => p.value === selectedPrompt);
         if (prompt) {
           setRecentPrompts((prev) => {
@@ -45,8 +51,6 @@ export function useAskChat({
     [
       promptList,
       selectedPrompt,
-      historyList,
-      setHistoryList,
       setRecentPrompts,
       messageApi,
     ]
@@ -23,12 +23,15 @@ export function useAskSelectionHandler({
       try {
         const {
           text: selectionText,
+          markdown,
           prompt,
           autoAsking,
           isOCR,
           isOpenWindow,
+          provider,
+          model,
         } = selectionData;
-        let text = selectionText ?? "";
+        let text = markdown || (selectionText ?? "");
         WindowShow();
         setActiveKey("ask");
         setIsLoading(true);
@@ -51,7 +54,10 @@ export function useAskSelectionHandler({
         setSelection(messageGenerator(effectivePrompt, text));
         if (setChatResponse) setChatResponse(null);
         if (autoAsking) {
-          handleChat(messageGenerator(effectivePrompt, text));
+          handleChat(messageGenerator(effectivePrompt, text), {
+            provider,
+            model,
+          });
         }
       } catch (error) {
         messageApi.open({
@@ -39,8 +39,6 @@ const AskComp = ({
   setPromptList,
   systemShortcuts,
   syncShortcutList,
-  historyList,
-  setHistoryList,
 }) => {
   const [messageApi, contextHolder] = message.useMessage();
   const [selection, setSelection] = useState("");
@@ -63,8 +61,6 @@ const AskComp = ({
     useAskChat({
       promptList,
       selectedPrompt,
-      historyList,
-      setHistoryList,
       messageApi,
     });
 
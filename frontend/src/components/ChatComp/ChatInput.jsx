@@ -15,8 +15,6 @@ export default function ChatInput({
   isAskLoading,
   stopRequest,
   newChatHandler,
-  chatMessages,
-  chatHistoryList,
   setShowPromptArea,
   showPromptArea,
 }) {
@@ -37,14 +35,14 @@ export default function ChatInput({
                 const isCmdOrCtrl = isMac ? e.metaKey : e.ctrlKey;
                 if (isCmdOrCtrl && e.shiftKey) {
                   e.preventDefault();
-                  newChatHandler(chatMessages, chatHistoryList);
+                  newChatHandler();
                   setTimeout(() => askRef.current?.click(), 200);
                 } else if (isCmdOrCtrl) {
                   e.preventDefault();
                   askRef.current?.click();
                 } else if (e.shiftKey) {
                   e.preventDefault();
-                  newChatHandler(chatMessages, chatHistoryList);
+                  newChatHandler();
                   setTimeout(() => askRef.current?.click(), 200);
                 }
               }}
@@ -1,11 +1,17 @@
 import { useState, useRef, useEffect, useCallback } from "react";
-import { CustomOpenAIAPI, OpenAIAPI } from "../../../../wailsjs/go/main/App";
+import {
+  Chat,
+  CustomOpenAIAPI,
+  OpenAIAPI,
+} from "../../../../wailsjs/go/main/App";
 import { useAppStore } from "../../../store";
 import {
   userMessageGenerator,
   assistantMessageGenerator,
   checkDailyUsageLimit,
   incrementDailyUsageCount,
+  isVaultLockedError,
+  recordChatHistory,
 } from "../../../utils";
 import { DEFAULT_DAILY_LIMIT } from "../../../constant";
 
@@ -65,11 +71,11 @@ function buildChatMessages(currentMessages, messages, options) {
 export function useChatMessages(chatMessages, setChatMessages, messageApi) {
   const promptList = useAppStore((s) => s.promptList);
   const selectedPrompt = useAppStore((s) => s.selectedPrompt);
-  const openAIKey = useAppStore((s) => s.openAIKey);
+  const hasOpenAIKey = useAppStore((s) => s.hasOpenAIKey);
   const setRecentPrompts = useAppStore((s) => s.setRecentPrompts);
 
   const chatMessagesRef = useRef(chatMessages);
-  const openAIKeyRef = useRef(openAIKey);
+  const hasOpenAIKeyRef = useRef(hasOpenAIKey);
   const selectedPromptRef = useRef(selectedPrompt);
   const isRequestCancelledRef = useRef(false);
 
@@ -79,8 +85,8 @@ export function useChatMessages(chatMessages, setChatMessages, messageApi) {
     chatMessagesRef.current = chatMessages;
   }, [chatMessages]);
   useEffect(() => {
-    openAIKeyRef.current = openAIKey;
-  }, [openAIKey]);
+    hasOpenAIKeyRef.current = hasOpenAIKey;
+  }, [hasOpenAIKey]);
   useEffect(() => {
     selectedPromptRef.current = selectedPrompt;
   }, [selectedPrompt]);
@@ -98,6 +104,8 @@ export function useChatMessages(chatMessages, setChatMessages, messageApi) {
         isEdit = false,
         isRegenerate = false,
         messageIndex = null,
+        provider = "",
+        model = "",
       } = typeof options === "object" && options !== null
         ? options
         : { isNewChat: !!options };
@@ -122,16 +130,21 @@ export function useChatMessages(chatMessages, setChatMessages, messageApi) {
       );
       setChatMessages(newChatMessages);
       setIsAskLoading(true);
+      const prompt = selectedPromptRef.current ?? "";
+      // the question is saved while waiting so it stays in history even if the request fails
+      const saving = recordChatHistory(newChatMessages, { prompt });
 
       try {
         const params = newChatMessages.map((m) => ({
           role: m.type,
           content: m.content,
         }));
-        const key = openAIKeyRef.current?.trim() ?? "";
-        const response =
-          key !== ""
-            ? await CustomOpenAIAPI(JSON.stringify(params), key)
+        // a prompt bound to a provider (set on its shortcut) overrides the default;
+        // an empty key tells the backend to use the key saved in the vault
+        const response = provider
+          ? await Chat("", provider, model, JSON.stringify(params))
+          : hasOpenAIKeyRef.current
+            ? await CustomOpenAIAPI(JSON.stringify(params), "")
             : await OpenAIAPI(JSON.stringify(params));
 
         if (isRequestCancelledRef.current) return;
@@ -140,15 +153,17 @@ export function useChatMessages(chatMessages, setChatMessages, messageApi) {
         if (response.code === 200) {
           const newCount = incrementDailyUsageCount();
           const content = normalizeResponseData(response.data);
-          setChatMessages((prev) => [...prev, assistantMessageGenerator(content)]);
-
-          const prompt = promptList.find(
-            (p) => p.value === selectedPromptRef.current,
+          const answer = assistantMessageGenerator(content);
+          setChatMessages((prev) => [...prev, answer]);
+          saving.then(() =>
+            recordChatHistory([...newChatMessages, answer], { prompt, response }),
           );
-          if (prompt) {
+
+          const recentPrompt = promptList.find((p) => p.value === prompt);
+          if (recentPrompt) {
             setRecentPrompts((prev) => {
-              const filtered = prev.filter((p) => p.label !== prompt.label);
-              return [prompt, ...filtered].slice(0, 12);
+              const filtered = prev.filter((p) => p.label !== recentPrompt.label);
+              return [recentPrompt, ...filtered].slice(0, 12);
             });
           }
           const remaining = DEFAULT_DAILY_LIMIT - newCount;
@@ -159,6 +174,9 @@ export function useChatMessages(chatMessages, setChatMessages, messageApi) {
             });
           }
         } else {
+          if (isVaultLockedError(response)) {
+            useAppStore.getState().setShowVaultPrompt(true);
+          }
           messageApi.open({
             type: "error",
             content: normalizeResponseData(response.data),
@@ -1,46 +1,31 @@
 import { useCallback } from "react";
+import { useAppStore } from "../../../store";
+
+const EMPTY_CHAT_SESSION = { id: "", messageIds: [] };
 
 export function useChatSession({
-  chatMessages,
   setChatMessages,
-  setChatHistoryList,
   isAskLoading,
   messageApi,
 }) {
-  const saveChatHistory = useCallback(
-    (messages, historyList) => {
-      if (isAskLoading || messages.length === 0) return;
-      if (
-        historyList.length > 0 &&
-        historyList.some(
-          (h) =>
-            h.length === messages.length &&
-            h.every((m, i) => m.content === messages[i]?.content),
-        )
-      ) {
-        setChatMessages([]);
-        return;
-      }
-      setChatHistoryList([messages, ...historyList]);
-      setChatMessages([]);
-    },
-    [isAskLoading, setChatMessages, setChatHistoryList],
-  );
+  const setChatSession = useAppStore((s) => s.setChatSession);
+
+  // Messages are written to the history store as they are sent and answered,
+  // so starting a new chat only detaches the page from the current session.
+  const newChatHandler = useCallback(() => {
+    if (isAskLoading) return;
+    setChatMessages([]);
+    setChatSession(EMPTY_CHAT_SESSION);
+  }, [isAskLoading, setChatMessages, setChatSession]);
 
-  const newChatHandler = useCallback(
-    (messages, historyList) => {
-      if (isAskLoading) return;
-      setChatMessages([]);
-      saveChatHistory(messages, historyList);
-    },
-    [isAskLoading, setChatMessages, saveChatHistory],
-  );
+  const saveChatHistory = newChatHandler;
 
   const clearChat = useCallback(() => {
     if (isAskLoading) return;
     setChatMessages([]);
+    setChatSession(EMPTY_CHAT_SESSION);
     messageApi.open({ type: "success", content: "Chat cleared" });
-  }, [isAskLoading, setChatMessages, messageApi]);
+  }, [isAskLoading, setChatMessages, setChatSession, messageApi]);
 
   return { saveChatHistory, newChatHandler, clearChat };
 }
@@ -12,7 +12,6 @@ export function useChatShortcuts(
     editingMessageId,
     isAskLoading,
     chatMessages,
-    chatHistoryList,
     showPromptArea,
   }
 ) {
@@ -22,7 +21,7 @@ export function useChatShortcuts(
 
       if (isCmdOrCtrl && e.key === "n") {
         e.preventDefault();
-        newChatHandler(chatMessages, chatHistoryList);
+        newChatHandler();
       }
       if (isCmdOrCtrl && e.key === "k") {
         e.preventDefault();
@@ -31,7 +30,7 @@ export function useChatShortcuts(
       if (isCmdOrCtrl && e.key === "s") {
         e.preventDefault();
         if (chatMessages.length > 0) {
-          saveChatHistory(chatMessages, chatHistoryList);
+          saveChatHistory();
         }
       }
       if (e.key === "Escape") {
@@ -60,7 +59,6 @@ export function useChatShortcuts(
     editingMessageId,
     isAskLoading,
     chatMessages,
-    chatHistoryList,
     showPromptArea,
   ]);
 }
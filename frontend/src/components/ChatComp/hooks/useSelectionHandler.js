@@ -53,8 +53,6 @@ export function useSelectionHandler({
   selectedPrompt,
   setSelectedPrompt,
   newChatHandler,
-  chatMessages,
-  chatHistoryList,
   handleChatWithEdit,
   setSelection,
   setIsLoading,
@@ -63,8 +61,15 @@ export function useSelectionHandler({
   const onSelectionHandler = useCallback(
     async (selectionData) => {
       try {
-        const { shortcut, prompt, autoAsking, isOCR, isOpenWindow } =
-          selectionData ?? {};
+        const {
+          shortcut,
+          prompt,
+          autoAsking,
+          isOCR,
+          isOpenWindow,
+          provider,
+          model,
+        } = selectionData ?? {};
         const isOpenWindowOnly =
           shortcut === "Open Window" ||
           (isOpenWindow && !isOCR && !autoAsking);
@@ -78,7 +83,8 @@ export function useSelectionHandler({
           return;
         }
 
-        let text = selectionData?.text ?? "";
+        // prefer the Markdown converted from HTML so tables and lists keep their structure
+        let text = selectionData?.markdown || (selectionData?.text ?? "");
         if (isOCR) {
           text = await runOCRFlow(text, messageApi, setIsLoading);
         }
@@ -89,13 +95,18 @@ export function useSelectionHandler({
         }
 
         const effectivePrompt = isOpenWindow || isOCR ? selectedPrompt : prompt;
-        newChatHandler(chatMessages, chatHistoryList);
+        newChatHandler();
         setSelectedPrompt(effectivePrompt);
         const formattedMessage = messageGenerator(effectivePrompt, text);
         setSelection(formattedMessage);
         if (autoAsking) {
           setSelection("");
-          await handleChatWithEdit(formattedMessage, true);
+          // provider/model come from the prompt that owns the shortcut
+          await handleChatWithEdit(formattedMessage, {
+            isNewChat: true,
+            provider,
+            model,
+          });
         }
       } catch (error) {
         messageApi.open({
@@ -114,8 +125,6 @@ export function useSelectionHandler({
       selectedPrompt,
       setSelectedPrompt,
       newChatHandler,
-      chatMessages,
-      chatHistoryList,
       handleChatWithEdit,
       setSelection,
       setIsLoading,
@@ -55,8 +55,6 @@ const ChatComp = ({
   const promptList = useAppStore((s) => s.promptList);
   const setPromptList = useAppStore((s) => s.setPromptList);
   const systemShortcuts = useAppStore((s) => s.systemShortcuts);
-  const chatHistoryList = useAppStore((s) => s.chatHistoryList);
-  const setChatHistoryList = useAppStore((s) => s.setChatHistoryList);
   const selectedPrompt = useAppStore((s) => s.selectedPrompt);
   const setSelectedPrompt = useAppStore((s) => s.setSelectedPrompt);
   const isMac = useAppStore((s) => s.platform.isMac);
@@ -79,9 +77,7 @@ const ChatComp = ({
   );
 
   const { saveChatHistory, newChatHandler, clearChat } = useChatSession({
-    chatMessages,
     setChatMessages,
-    setChatHistoryList,
     isAskLoading,
     messageApi,
   });
@@ -128,8 +124,6 @@ const ChatComp = ({
     selectedPrompt,
     setSelectedPrompt,
     newChatHandler,
-    chatMessages,
-    chatHistoryList,
     handleChatWithEdit,
     setSelection,
     setIsLoading,
@@ -153,7 +147,6 @@ const ChatComp = ({
     editingMessageId,
     isAskLoading,
     chatMessages,
-    chatHistoryList,
     showPromptArea,
   });
 
@@ -180,9 +173,7 @@ const ChatComp = ({
                           type="text"
                           size="small"
                           icon={<PlusOutlined />}
-                          onClick={() =>
-                            newChatHandler(chatMessages, chatHistoryList)
-                          }
+                          onClick={newChatHandler}
                         />
                       </Tooltip>
                     )}
@@ -192,9 +183,7 @@ const ChatComp = ({
                           type="text"
                           size="small"
                           icon={<SaveOutlined />}
-                          onClick={() =>
-                            saveChatHistory(chatMessages, chatHistoryList)
-                          }
+                          onClick={saveChatHistory}
                         />
                       </Tooltip>
                     )}
@@ -300,8 +289,6 @@ const ChatComp = ({
             isAskLoading={isAskLoading}
             stopRequest={stopRequest}
             newChatHandler={newChatHandler}
-            chatMessages={chatMessages}
-            chatHistoryList={chatHistoryList}
             setShowPromptArea={setShowPromptArea}
             showPromptArea={showPromptArea}
           />
@@ -19,68 +19,83 @@ import {
   ClockCircleOutlined,
   SearchOutlined,
 } from "@ant-design/icons";
-import { useState, useMemo } from "react";
+import { useState, useMemo, useEffect, useCallback } from "react";
 import dayjs from "dayjs";
 import relativeTime from "dayjs/plugin/relativeTime";
 import { MarkDownComp } from "../MarkDownComp";
 import { useAppStore } from "../../store";
+import { chatMessagesFromHistory } from "../../utils";
+import { useHistorySessionSearch } from "../../hooks/useHistorySessionSearch";
+import {
+  DeleteHistorySession,
+  ListHistoryConversations,
+} from "../../../wailsjs/go/main/App";
 import styles from "./index.module.css";
 dayjs.extend(relativeTime);
 
 const { Text, Title } = Typography;
 const { Panel } = Collapse;
 
-const ChatHistoryComp = ({ setActiveKey, setChatMessages }) => {
-  const chatHistoryList = useAppStore((s) => s.chatHistoryList);
-  const setChatHistoryList = useAppStore((s) => s.setChatHistoryList);
+const ChatHistoryComp = ({ activeKey, setActiveKey, setChatMessages }) => {
+  const setChatSession = useAppStore((s) => s.setChatSession);
+  // [{ session, messages }] from the Go history store, most recent first
+  const [conversations, setConversations] = useState([]);
   const [expandedKeys, setExpandedKeys] = useState([]);
   const [searchText, setSearchText] = useState("");
 
+  const loadHistory = useCallback(async () => {
+    try {
+      const list = await ListHistoryConversations("chat");
+      setConversations(
+        (list ?? []).map(({ session, messages }) => ({
+          session,
+          messages: chatMessagesFromHistory(messages),
+        })),
+      );
+    } catch (e) {
+      console.error("load chat history:", e);
+    }
+  }, []);
+
+  // chats are saved from the Chat tab, so reload whenever this tab is shown
+  useEffect(() => {
+    if (activeKey === "chatHistory") loadHistory();
+  }, [activeKey, loadHistory]);
+
+  const chatHistoryList = useMemo(
+    () => conversations.map((c) => c.messages),
+    [conversations],
+  );
+
   const handleHistoryClick = (historyIndex) => {
-    const chatMessages = chatHistoryList[historyIndex];
-    setChatMessages(chatMessages);
+    const { session, messages } = conversations[historyIndex];
+    setChatMessages(messages);
+    // keep appending to this session when the chat continues
+    setChatSession({ id: session.id, messageIds: messages.map((m) => m.id) });
     setActiveKey("chat");
   };
 
-  const handleDeleteHistory = (historyIndex, e) => {
+  const handleDeleteHistory = async (historyIndex, e) => {
     e.stopPropagation();
-    const newHistoryList = chatHistoryList.filter(
-      (_, index) => index !== historyIndex
-    );
-    setChatHistoryList(newHistoryList);
+    try {
+      await DeleteHistorySession(conversations[historyIndex].session.id);
+    } catch (err) {
+      console.error("delete chat history:", err);
+    }
+    loadHistory();
   };
 
-  // Filter chat history based on search text
+  // Full-text search runs in the Go history store; hits come back best first
+  const matchedSessionIds = useHistorySessionSearch("chat", searchText);
   const filteredChatHistory = useMemo(() => {
-    if (!searchText.trim()) {
+    if (matchedSessionIds == null) {
       return chatHistoryList;
     }
-
-    const searchLower = searchText.toLowerCase();
-    return chatHistoryList.filter((history, index) => {
-      // Search in chat number
-      if (`chat #${index + 1}`.toLowerCase().includes(searchLower)) {
-        return true;
-      }
-
-      // Search in all messages
-      return history.some((message) => {
-        // Search in message content
-        if (message.content.toLowerCase().includes(searchLower)) {
-          return true;
-        }
-        // Search in timestamp
-        if (message.timestamp.toLowerCase().includes(searchLower)) {
-          return true;
-        }
-        // Search in message type
-        if (message.type.toLowerCase().includes(searchLower)) {
-          return true;
-        }
-        return false;
-      });
-    });
-  }, [chatHistoryList, searchText]);
+    return matchedSessionIds
+      .map((id) => conversations.findIndex((c) => c.session.id === id))
+      .filter((index) => index !== -1)
+      .map((index) => chatHistoryList[index]);
+  }, [chatHistoryList, conversations, matchedSessionIds]);
 
   const renderMessage = (message) => {
     const isUser = message.type === "user";
@@ -104,7 +119,7 @@ const ChatHistoryComp = ({ setActiveKey, setChatMessages }) => {
               <Text
                 className={`${styles.msgTimestampText} ${isUser ? styles.msgTimestampUser : styles.msgTimestampAssistant}`}
               >
-                {message.timestamp}
+                {dayjs(message.timestamp).format("YYYY-MM-DD HH:mm:ss")}
               </Text>
             </div>
             {isUser ? (
@@ -138,6 +153,7 @@ const ChatHistoryComp = ({ setActiveKey, setChatMessages }) => {
     const assistantMessages = history.filter((msg) => msg.type === "assistant");
     const firstUserMessage = userMessages[0];
     const lastMessage = history[history.length - 1];
+    const { session } = conversations[index];
 
     const formatTime = (timestamp) => {
       return dayjs(timestamp).fromNow();
@@ -161,6 +177,16 @@ const ChatHistoryComp = ({ setActiveKey, setChatMessages }) => {
               <Tag size="small" color="blue">
                 {history.length} messages
               </Tag>
+              {session.provider && (
+                <Tag size="small">
+                  {session.model
+                    ? `${session.provider} · ${session.model}`
+                    : session.provider}
+                </Tag>
+              )}
+              {session.usage?.totalTokens > 0 && (
+                <Tag size="small">{session.usage.totalTokens} tokens</Tag>
+              )}
             </div>
 
             <div className={styles.historyCardTime}>
@@ -28,6 +28,11 @@ function HistoryItem({ item, index, onDelete, onCopy }) {
           <Text type="secondary" className={styles.historyItemTimestamp}>
             {item.timestamp}
           </Text>
+          {item.provider && (
+            <Text type="secondary" className={styles.historyItemTimestamp}>
+              {item.model ? `${item.provider} · ${item.model}` : item.provider}
+            </Text>
+          )}
         </div>
         <Button
           type="text"
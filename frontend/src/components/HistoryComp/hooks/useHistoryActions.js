@@ -1,29 +1,44 @@
 import { useCallback } from "react";
+import {
+  ClearHistorySessions,
+  DeleteHistorySession,
+} from "../../../../wailsjs/go/main/App";
 
 export function useHistoryActions({
   historyList,
-  setHistoryList,
+  reloadHistory,
   messageApi,
 }) {
   const handleDeleteHistory = useCallback(
-    (index) => {
-      const newHistoryList = (historyList ?? []).filter((_, i) => i !== index);
-      setHistoryList(newHistoryList);
-      messageApi.open({
-        type: "success",
-        content: "History item deleted",
-      });
+    async (index) => {
+      const item = (historyList ?? [])[index];
+      if (!item) return;
+      try {
+        await DeleteHistorySession(item.id);
+        messageApi.open({
+          type: "success",
+          content: "History item deleted",
+        });
+      } catch (e) {
+        messageApi.open({ type: "error", content: String(e) });
+      }
+      reloadHistory();
     },
-    [historyList, setHistoryList, messageApi]
+    [historyList, reloadHistory, messageApi]
   );
 
-  const handleClearAll = useCallback(() => {
-    setHistoryList([]);
-    messageApi.open({
-      type: "success",
-      content: "All history cleared",
-    });
-  }, [setHistoryList, messageApi]);
+  const handleClearAll = useCallback(async () => {
+    try {
+      await ClearHistorySessions("ask");
+      messageApi.open({
+        type: "success",
+        content: "All history cleared",
+      });
+    } catch (e) {
+      messageApi.open({ type: "error", content: String(e) });
+    }
+    reloadHistory();
+  }, [reloadHistory, messageApi]);
 
   const handleCopyToClipboard = useCallback(
     async (text) => {
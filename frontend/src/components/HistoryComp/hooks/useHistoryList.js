@@ -0,0 +1,36 @@
+import { useState, useEffect, useCallback } from "react";
+import { ListHistoryConversations } from "../../../../wailsjs/go/main/App";
+
+// Ask sessions hold one question and one answer; flatten them into the
+// { message, response } items the list renders.
+function askItem({ session, messages }) {
+  const question = messages?.find((m) => m.role === "user");
+  const answer = messages?.find((m) => m.role === "assistant");
+  return {
+    id: session.id,
+    message: question?.content ?? "",
+    response: answer?.content ?? "",
+    timestamp: new Date(session.createdAt).toLocaleString(),
+    provider: session.provider,
+    model: session.model,
+  };
+}
+
+export function useHistoryList() {
+  const [historyList, setHistoryList] = useState([]);
+
+  const reloadHistory = useCallback(async () => {
+    try {
+      const conversations = await ListHistoryConversations("ask");
+      setHistoryList((conversations ?? []).map(askItem));
+    } catch (e) {
+      console.error("load ask history:", e);
+    }
+  }, []);
+
+  useEffect(() => {
+    reloadHistory();
+  }, [reloadHistory]);
+
+  return { historyList, reloadHistory };
+}
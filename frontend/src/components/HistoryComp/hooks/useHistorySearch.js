@@ -1,17 +1,15 @@
 import { useState, useMemo } from "react";
+import { useHistorySessionSearch } from "../../../hooks/useHistorySessionSearch";
 
 export function useHistorySearch(historyList) {
   const [searchKeyword, setSearchKeyword] = useState("");
+  const sessionIds = useHistorySessionSearch("ask", searchKeyword);
 
   const filteredHistory = useMemo(() => {
-    if (!searchKeyword.trim()) return historyList ?? [];
-    const keyword = searchKeyword.toLowerCase();
-    return (historyList ?? []).filter(
-      (item) =>
-        item.message?.toLowerCase().includes(keyword) ||
-        item.response?.toLowerCase().includes(keyword)
-    );
-  }, [historyList, searchKeyword]);
+    if (sessionIds == null) return historyList ?? [];
+    const byId = new Map((historyList ?? []).map((item) => [item.id, item]));
+    return sessionIds.map((id) => byId.get(id)).filter(Boolean);
+  }, [historyList, sessionIds]);
 
   return { searchKeyword, setSearchKeyword, filteredHistory };
 }
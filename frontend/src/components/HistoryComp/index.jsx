@@ -12,14 +12,16 @@ import {
 import { ClearOutlined } from "@ant-design/icons";
 import { useHistorySearch } from "./hooks/useHistorySearch";
 import { useHistoryActions } from "./hooks/useHistoryActions";
+import { useHistoryList } from "./hooks/useHistoryList";
 import HistoryItem from "./HistoryItem";
 import styles from "./index.module.css";
 
 const { Title } = Typography;
 const { Search } = Input;
 
-function HistoryComp({ historyList, setHistoryList }) {
+function HistoryComp() {
   const [messageApi, contextHolder] = message.useMessage();
+  const { historyList, reloadHistory } = useHistoryList();
 
   const { searchKeyword, setSearchKeyword, filteredHistory } =
     useHistorySearch(historyList);
@@ -27,7 +29,7 @@ function HistoryComp({ historyList, setHistoryList }) {
   const { handleDeleteHistory, handleClearAll, handleCopyToClipboard } =
     useHistoryActions({
       historyList,
-      setHistoryList,
+      reloadHistory,
       messageApi,
     });
 
@@ -108,10 +110,10 @@ function HistoryComp({ historyList, setHistoryList }) {
           />
         ) : (
           <div className={styles.historyCompList}>
-            {filteredHistory.map((item, i) => {
+            {filteredHistory.map((item) => {
               const realIndex = historyList.indexOf(item);
               return (
-                <div key={`${realIndex}-${i}-${item?.timestamp}`}>
+                <div key={item.id}>
                   <HistoryItem
                     item={item}
                     index={realIndex}
@@ -8,6 +8,7 @@ import {
   Button,
   message,
   Modal,
+  Checkbox,
 } from "antd";
 const { Option } = Select;
 const { TextArea } = Input;
@@ -18,6 +19,7 @@ import {
   DeleteOutlined,
 } from "@ant-design/icons";
 import React, { useEffect, useState } from "react";
+import { ListProviders } from "../../../../wailsjs/go/main/App";
 import { VALIDATION_MSGS } from "../../../constant";
 import { formatShortcutDisplay } from "../../../utils";
 import styles from "./index.module.css";
@@ -39,7 +41,11 @@ function ShortcutComp({
     label: "",
     value: "",
     shortcut: "",
+    replaceSelection: false,
+    provider: "",
+    model: "",
   });
+  const [providers, setProviders] = useState([]);
   const shortcut = localPrompt?.shortcut ?? "";
   const lastPlusIndex = shortcut.lastIndexOf("+");
   const shortcutModifier =
@@ -100,6 +106,9 @@ function ShortcutComp({
       label: localPrompt?.label ?? "",
       value: localPrompt?.value ?? "",
       shortcut: localPrompt?.shortcut ?? "",
+      replaceSelection: !!localPrompt?.replaceSelection,
+      provider: localPrompt?.provider ?? "",
+      model: localPrompt?.model ?? "",
     });
     setIsEditing(true);
   };
@@ -117,6 +126,9 @@ function ShortcutComp({
               label: editSnapshot.label,
               value: editSnapshot.value,
               shortcut: editSnapshot.shortcut,
+              replaceSelection: editSnapshot.replaceSelection,
+              provider: editSnapshot.provider,
+              model: editSnapshot.model,
             }
           : prompt,
       ),
@@ -156,11 +168,21 @@ function ShortcutComp({
         label: localPrompt?.label ?? "",
         value: localPrompt?.value ?? "",
         shortcut: localPrompt?.shortcut ?? "",
+        replaceSelection: !!localPrompt?.replaceSelection,
+        provider: localPrompt?.provider ?? "",
+        model: localPrompt?.model ?? "",
       });
       onEditModeConsumed?.();
     }
   }, [initialEditMode]);
 
+  useEffect(() => {
+    if (!isEditing) return;
+    ListProviders()
+      .then((list) => setProviders(list ?? []))
+      .catch(() => setProviders([]));
+  }, [isEditing]);
+
   const selectBefore = (
     <Select
       value={shortcutModifier}
@@ -202,6 +224,34 @@ function ShortcutComp({
                 autoSize={{ minRows: 2, maxRows: 6 }}
                 className={styles.shortcutCompTextarea}
               />
+              <Checkbox
+                checked={!!localPrompt?.replaceSelection}
+                onChange={(e) =>
+                  updatePrompt("replaceSelection", e.target.checked)
+                }
+                title="Paste the answer over the selected text instead of opening the window"
+              >
+                Replace selection with answer
+              </Checkbox>
+              <Space.Compact className={styles.shortcutCompProviderRow}>
+                <Select
+                  allowClear
+                  value={localPrompt?.provider || undefined}
+                  onChange={(value) => updatePrompt("provider", value ?? "")}
+                  placeholder="Default provider"
+                  className={styles.shortcutCompProviderSelect}
+                  options={providers.map((p) => ({
+                    value: p.id,
+                    label: p.name || p.id,
+                  }))}
+                />
+                <Input
+                  value={localPrompt?.model ?? ""}
+                  onChange={(e) => updatePrompt("model", e.target.value)}
+                  placeholder="Default model"
+                  disabled={!localPrompt?.provider}
+                />
+              </Space.Compact>
               <Space>
                 <Button
                   type="primary"
@@ -262,6 +312,18 @@ function ShortcutComp({
                 {formatShortcutDisplay(`${shortcutModifier}+${shortcutKey}`)}
               </Tag>
             )}
+            {localPrompt?.replaceSelection && (
+              <Tag color="green" className={styles.shortcutCompTag}>
+                Replace
+              </Tag>
+            )}
+            {localPrompt?.provider && (
+              <Tag className={styles.shortcutCompTag}>
+                {localPrompt.model
+                  ? `${localPrompt.provider}:${localPrompt.model}`
+                  : localPrompt.provider}
+              </Tag>
+            )}
           </div>
 
           {isEditing && (
@@ -2,10 +2,23 @@ import { useState, useEffect, useCallback } from "react";
 import { message } from "antd";
 import { useAppStore } from "../../../store";
 import {
+  refreshVaultStatus,
+  syncOCRLangToBackend,
   syncShortcutListToBackend,
   validateShortcut,
 } from "../../../utils";
-import { DEFAULT_OCR_LANG, DEFAULT_PROMPT_LIST } from "../../../constant";
+import {
+  DEFAULT_OCR_LANG,
+  DEFAULT_PROMPT_LIST,
+  OPENAI_SECRET_NAME,
+} from "../../../constant";
+import {
+  DeleteSecret,
+  GetActiveProfile,
+  ListProfiles,
+  SetSecret,
+  SwitchProfile,
+} from "../../../../wailsjs/go/main/App";
 
 export function useSettingsForm(activeKey) {
   const promptList = useAppStore((s) => s.promptList);
@@ -14,8 +27,10 @@ export function useSettingsForm(activeKey) {
   const setSystemShortcuts = useAppStore((s) => s.setSystemShortcuts);
   const OCRLang = useAppStore((s) => s.OCRLang);
   const setOCRLang = useAppStore((s) => s.setOCRLang);
-  const openAIKey = useAppStore((s) => s.openAIKey);
-  const setOpenAIKey = useAppStore((s) => s.setOpenAIKey);
+  const hasOpenAIKey = useAppStore((s) => s.hasOpenAIKey);
+  const setHasOpenAIKey = useAppStore((s) => s.setHasOpenAIKey);
+  const vaultStatus = useAppStore((s) => s.vaultStatus);
+  const setShowVaultPrompt = useAppStore((s) => s.setShowVaultPrompt);
 
   const [messageApi, contextHolder] = message.useMessage();
   const [localOCRLang, setLocalOCRLang] = useState(DEFAULT_OCR_LANG);
@@ -23,6 +38,8 @@ export function useSettingsForm(activeKey) {
   const [localPromptList, setLocalPromptList] = useState(DEFAULT_PROMPT_LIST);
   const [localSystemShortcuts, setLocalSystemShortcuts] = useState([]);
   const [newlyAddedPromptId, setNewlyAddedPromptId] = useState(null);
+  const [profiles, setProfiles] = useState([]);
+  const [activeProfile, setActiveProfile] = useState("");
 
   useEffect(() => {
     setLocalPromptList(promptList);
@@ -36,14 +53,26 @@ export function useSettingsForm(activeKey) {
     setLocalOCRLang(OCRLang);
   }, [OCRLang]);
 
+  useEffect(() => {
+    if (activeKey !== "settings") return;
+    Promise.all([ListProfiles(), GetActiveProfile()])
+      .then(([list, active]) => {
+        setProfiles(list ?? []);
+        setActiveProfile(active);
+      })
+      .catch((e) => console.error("load profiles:", e));
+    refreshVaultStatus();
+  }, [activeKey]);
+
   useEffect(() => {
     if (activeKey === "settings") {
       setLocalOCRLang(OCRLang);
-      setLocalOpenAIKey(openAIKey ?? "");
+      // the saved key is never sent back to the frontend; the input only takes a new one
+      setLocalOpenAIKey("");
       setLocalPromptList(promptList);
       setLocalSystemShortcuts(systemShortcuts);
     }
-  }, [activeKey, OCRLang, openAIKey, promptList, systemShortcuts]);
+  }, [activeKey, OCRLang, promptList, systemShortcuts]);
 
   const onChangeOCRHandler = useCallback(
     (value) => {
@@ -66,7 +95,24 @@ export function useSettingsForm(activeKey) {
       return;
     }
     setOCRLang(localOCRLang);
-    setOpenAIKey(localOpenAIKey);
+    syncOCRLangToBackend(localOCRLang);
+    const newOpenAIKey = localOpenAIKey.trim();
+    if (newOpenAIKey !== "") {
+      SetSecret(OPENAI_SECRET_NAME, newOpenAIKey)
+        .then(() => {
+          setHasOpenAIKey(true);
+          setLocalOpenAIKey("");
+        })
+        .catch(async (e) => {
+          // a locked vault (or one without a keyring) needs the passphrase first
+          const status = await refreshVaultStatus();
+          if (status?.locked) setShowVaultPrompt(true);
+          messageApi.open({
+            type: "error",
+            content: `Failed to save API key: ${e}`,
+          });
+        });
+    }
     setPromptList(localPromptList);
     setSystemShortcuts(localSystemShortcuts);
     syncShortcutListToBackend(localPromptList, localSystemShortcuts);
@@ -80,12 +126,42 @@ export function useSettingsForm(activeKey) {
     localPromptList,
     localSystemShortcuts,
     setOCRLang,
-    setOpenAIKey,
+    setHasOpenAIKey,
+    setShowVaultPrompt,
     setPromptList,
     setSystemShortcuts,
     messageApi,
   ]);
 
+  const handleRemoveOpenAIKey = useCallback(() => {
+    DeleteSecret(OPENAI_SECRET_NAME)
+      .then(() => {
+        setHasOpenAIKey(false);
+        messageApi.open({ type: "success", content: "API key removed" });
+      })
+      .catch((e) =>
+        messageApi.open({
+          type: "error",
+          content: `Failed to remove API key: ${e}`,
+        })
+      );
+  }, [setHasOpenAIKey, messageApi]);
+
+  // The backend emits profile:changed on success and the window reloads with the new profile
+  const handleSwitchProfile = useCallback(
+    (name) => {
+      const profile = name?.trim();
+      if (!profile || profile === activeProfile) return;
+      SwitchProfile(profile).catch((e) =>
+        messageApi.open({
+          type: "error",
+          content: `Failed to switch profile: ${e}`,
+        })
+      );
+    },
+    [activeProfile, messageApi]
+  );
+
   const handleDragEnd = useCallback(
     (result) => {
       if (!result.destination) return;
@@ -126,6 +202,10 @@ export function useSettingsForm(activeKey) {
     setLocalOCRLang,
     localOpenAIKey,
     setLocalOpenAIKey,
+    hasOpenAIKey,
+    handleRemoveOpenAIKey,
+    vaultLocked: !!vaultStatus?.locked,
+    handleUnlockVault: () => setShowVaultPrompt(true),
     localPromptList,
     setLocalPromptList,
     localSystemShortcuts,
@@ -136,5 +216,8 @@ export function useSettingsForm(activeKey) {
     handleSave,
     handleDragEnd,
     addCustomPrompt,
+    profiles,
+    activeProfile,
+    handleSwitchProfile,
   };
 }
@@ -9,6 +9,7 @@ import {
   Space,
   Typography,
   Input,
+  Tag,
 } from "antd";
 import { OCR_LANGUAGE_OPTIONS } from "../../constant";
 import { InfoCircleOutlined, SaveOutlined, PlusOutlined } from "@ant-design/icons";
@@ -28,6 +29,10 @@ function SettingsComp({ activeKey, isMac = false }) {
     setLocalOCRLang,
     localOpenAIKey,
     setLocalOpenAIKey,
+    hasOpenAIKey,
+    handleRemoveOpenAIKey,
+    vaultLocked,
+    handleUnlockVault,
     localPromptList,
     setLocalPromptList,
     localSystemShortcuts,
@@ -38,6 +43,9 @@ function SettingsComp({ activeKey, isMac = false }) {
     handleSave,
     handleDragEnd,
     addCustomPrompt,
+    profiles,
+    activeProfile,
+    handleSwitchProfile,
   } = useSettingsForm(activeKey);
 
   return (
@@ -45,6 +53,39 @@ function SettingsComp({ activeKey, isMac = false }) {
       {contextHolder}
 
       <div className={styles.settingsCompScroll}>
+        {/* Profile */}
+        <Card
+          title={
+            <Space>
+              <Title level={4} className={styles.settingsCompCardTitle}>
+                Profile
+              </Title>
+              <Tooltip
+                title="Each profile has its own API keys, providers, prompts, shortcuts and history"
+                placement="top"
+              >
+                <InfoCircleOutlined className={styles.settingsCompInfoIcon} />
+              </Tooltip>
+            </Space>
+          }
+          size="small"
+        >
+          <Space direction="vertical" className={styles.settingsCompSpaceFull}>
+            <Select
+              className={styles.settingsCompSelectFull}
+              options={profiles.map((name) => ({ label: name, value: name }))}
+              value={activeProfile || undefined}
+              onChange={handleSwitchProfile}
+            />
+            <Input.Search
+              placeholder="New profile name, e.g. work"
+              enterButton="Create & switch"
+              onSearch={handleSwitchProfile}
+              maxLength={32}
+            />
+          </Space>
+        </Card>
+
         {/* OpenAI API Key */}
         <Card
           title={
@@ -52,22 +93,37 @@ function SettingsComp({ activeKey, isMac = false }) {
               <Title level={4} className={styles.settingsCompCardTitle}>
                 OpenAI API Key
               </Title>
-              <Tooltip title="Your key is stored locally and never sent to our servers" placement="top">
+              <Tooltip title="Your key is encrypted on this device and never sent to our servers" placement="top">
                 <InfoCircleOutlined className={styles.settingsCompInfoIcon} />
               </Tooltip>
+              {vaultLocked && <Tag color="warning">Vault locked</Tag>}
             </Space>
           }
+          extra={
+            vaultLocked && (
+              <Button type="link" size="small" onClick={handleUnlockVault}>
+                Unlock
+              </Button>
+            )
+          }
           size="small"
         >
           <Input.Password
-            placeholder="sk-..."
+            placeholder={hasOpenAIKey ? "Key saved. Enter a new key to replace it" : "sk-..."}
             value={localOpenAIKey}
             onChange={(e) => setLocalOpenAIKey(e.target.value)}
             allowClear
           />
           <Text type="secondary" className={styles.settingsCompHint}>
-            Optional. Leave empty to use the default service.
+            {hasOpenAIKey
+              ? "Using your saved key."
+              : "Optional. Leave empty to use the default service."}
           </Text>
+          {hasOpenAIKey && (
+            <Button type="link" size="small" danger onClick={handleRemoveOpenAIKey}>
+              Remove saved key
+            </Button>
+          )}
         </Card>
 
         {/* OCR Settings */}
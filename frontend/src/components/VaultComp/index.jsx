@@ -0,0 +1,110 @@
+import React, { useCallback, useEffect, useState } from "react";
+import { Alert, Input, Modal, Space, Typography } from "antd";
+import { LockOutlined } from "@ant-design/icons";
+import { SetVaultPassphrase, UnlockVault } from "../../../wailsjs/go/main/App";
+import { useAppStore } from "../../store";
+import { migrateOpenAIKeyToVault, refreshVaultStatus } from "../../utils";
+import styles from "./index.module.css";
+
+const { Text } = Typography;
+
+// Prompts for the vault passphrase: unlocks a passphrase-protected vault, or creates
+// the vault with a passphrase when the system keyring is unavailable.
+const VaultComp = () => {
+  const open = useAppStore((s) => s.showVaultPrompt);
+  const setShowVaultPrompt = useAppStore((s) => s.setShowVaultPrompt);
+  const vaultStatus = useAppStore((s) => s.vaultStatus);
+
+  const [passphrase, setPassphrase] = useState("");
+  const [confirm, setConfirm] = useState("");
+  const [error, setError] = useState("");
+  const [loading, setLoading] = useState(false);
+
+  const isSetup = !vaultStatus?.initialized;
+  const canUnlock = vaultStatus?.keySource === "passphrase";
+
+  useEffect(() => {
+    if (!open) return;
+    setPassphrase("");
+    setConfirm("");
+    setError("");
+    refreshVaultStatus();
+  }, [open]);
+
+  const handleClose = useCallback(() => setShowVaultPrompt(false), [setShowVaultPrompt]);
+
+  const handleSubmit = useCallback(async () => {
+    if (passphrase === "") {
+      setError("Enter a passphrase");
+      return;
+    }
+    if (isSetup && passphrase !== confirm) {
+      setError("Passphrases do not match");
+      return;
+    }
+    setLoading(true);
+    try {
+      if (isSetup) {
+        await SetVaultPassphrase(passphrase);
+        // the legacy key could not be moved in while there was no vault
+        await migrateOpenAIKeyToVault();
+      } else {
+        await UnlockVault(passphrase);
+        await refreshVaultStatus();
+      }
+      setShowVaultPrompt(false);
+    } catch (e) {
+      setError(String(e?.message ?? e));
+    } finally {
+      setLoading(false);
+    }
+  }, [passphrase, confirm, isSetup, setShowVaultPrompt]);
+
+  return (
+    <Modal
+      title={
+        <Space>
+          <LockOutlined className={styles.vaultIcon} />
+          <span>{isSetup ? "Set a vault passphrase" : "Vault locked"}</span>
+        </Space>
+      }
+      open={open}
+      onCancel={handleClose}
+      onOk={handleSubmit}
+      okText={isSetup ? "Set passphrase" : "Unlock"}
+      okButtonProps={{ disabled: !isSetup && !canUnlock }}
+      confirmLoading={loading}
+      destroyOnClose
+    >
+      <Space direction="vertical" className={styles.vaultBody}>
+        <Text type="secondary">
+          {isSetup
+            ? "The system keyring is unavailable. Choose a passphrase to encrypt your API keys on this device."
+            : canUnlock
+              ? "Your API keys are encrypted with a passphrase. Enter it to use them."
+              : "The vault key could not be read from the system keyring. Unlock your keyring and restart the app."}
+        </Text>
+        {(isSetup || canUnlock) && (
+          <Input.Password
+            placeholder="Passphrase"
+            value={passphrase}
+            onChange={(e) => setPassphrase(e.target.value)}
+            onPressEnter={isSetup ? undefined : handleSubmit}
+            autoFocus
+          />
+        )}
+        {isSetup && (
+          <Input.Password
+            placeholder="Confirm passphrase"
+            value={confirm}
+            onChange={(e) => setConfirm(e.target.value)}
+            onPressEnter={handleSubmit}
+          />
+        )}
+        {error && <Alert type="error" message={error} showIcon />}
+      </Space>
+    </Modal>
+  );
+};
+
+export default VaultComp;
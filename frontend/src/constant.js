@@ -165,6 +165,12 @@ export const DEFAULT_OCR_LANG = []; // ["eng", "chi_sim"]
 export const DEFAULT_PROMPT = "帮我翻译成中文:\n";
 export const OCR_LANG_KEY = "orcLang";
 export const OPENAI_API_KEY_KEY = "openai_api_key";
+// Vault secret name for the user's OpenAI key (matches the backend provider ID)
+export const OPENAI_SECRET_NAME = "openai";
+
+// Mirror of the backend's active profile, read synchronously when the store loads
+export const ACTIVE_PROFILE_KEY = "activeProfile";
+export const DEFAULT_PROFILE = "default";
 export const PROMPT_LIST_KEY = "promptList";
 export const DEFAULT_PROMPT_LIST = [];
 export const SELECTED_PROMPT_KEY = "selectedPrompt";
@@ -174,10 +180,10 @@ export const DEFAULT_SHORTCUT_LIST = [
   { label: "OCR", value: "OCR", shortcut: "" },
 ];
 
+// Ask and chat history used to live under these keys; they are only read
+// for the one-time import into the Go history store.
 export const HISTORY_LIST_KEY = "historyList";
-export const DEFAULT_HISTORY_LIST = [];
 export const CHAT_HISTORY_LIST_KEY = "chatHistoryList";
-export const DEFAULT_CHAT_HISTORY_LIST = [];
 
 
 
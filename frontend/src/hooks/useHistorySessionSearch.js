@@ -0,0 +1,34 @@
+import { useState, useEffect } from "react";
+import { SearchHistory } from "../../wailsjs/go/main/App";
+
+// Hits are messages; this many covers the sessions a person scrolls through.
+const SEARCH_LIMIT = 200;
+
+// Full-text search over the Go history store. Returns the ids of the matching
+// sessions, best match first, or null while the keyword is empty.
+export function useHistorySessionSearch(kind, searchKeyword) {
+  const [sessionIds, setSessionIds] = useState(null);
+
+  useEffect(() => {
+    const query = searchKeyword.trim();
+    if (!query) {
+      setSessionIds(null);
+      return;
+    }
+    let cancelled = false;
+    SearchHistory({ query, kind, limit: SEARCH_LIMIT })
+      .then((result) => {
+        if (cancelled) return;
+        setSessionIds([...new Set((result?.hits ?? []).map((hit) => hit.sessionId))]);
+      })
+      .catch((e) => {
+        console.error("search history:", e);
+        if (!cancelled) setSessionIds([]);
+      });
+    return () => {
+      cancelled = true;
+    };
+  }, [kind, searchKeyword]);
+
+  return sessionIds;
+}
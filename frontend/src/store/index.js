@@ -4,22 +4,19 @@ import { immer } from "zustand/middleware/immer";
 import {
   PROMPT_LIST_KEY,
   SYSTEM_SHORTCUT_KEY,
-  HISTORY_LIST_KEY,
-  CHAT_HISTORY_LIST_KEY,
   SELECTED_PROMPT_KEY,
   OCR_LANG_KEY,
-  OPENAI_API_KEY_KEY,
   RECENT_PROMPTS_KEY,
   IS_SHOW_PROMPT_AREA_KEY,
   IS_OPEN_RECENT_PROMPTS_KEY,
   DEFAULT_PROMPT_OPTIONS,
   DEFAULT_PROMPT_OPTIONS_VALUE,
   DEFAULT_SHORTCUT_LIST,
-  DEFAULT_HISTORY_LIST,
-  DEFAULT_CHAT_HISTORY_LIST,
   DEFAULT_OCR_LANG,
   IS_SHOW_PROMPT_AREA_VALUE,
   IS_OPEN_RECENT_PROMPTS_VALUE,
+  ACTIVE_PROFILE_KEY,
+  DEFAULT_PROFILE,
 } from "../constant";
 
 const SHOW_SHORTCUT_GUIDE_KEY = "showShortcutGuide";
@@ -27,17 +24,22 @@ const SHOW_SHORTCUT_GUIDE_KEY = "showShortcutGuide";
 const STORAGE_KEYS = {
   promptList: PROMPT_LIST_KEY,
   systemShortcuts: SYSTEM_SHORTCUT_KEY,
-  historyList: HISTORY_LIST_KEY,
-  chatHistoryList: CHAT_HISTORY_LIST_KEY,
   selectedPrompt: SELECTED_PROMPT_KEY,
   showShortcutGuide: SHOW_SHORTCUT_GUIDE_KEY,
   OCRLang: OCR_LANG_KEY,
-  openAIKey: OPENAI_API_KEY_KEY,
   recentPrompts: RECENT_PROMPTS_KEY,
   showPromptArea: IS_SHOW_PROMPT_AREA_KEY,
   recentPromptsActiveKey: IS_OPEN_RECENT_PROMPTS_KEY,
 };
 
+// Each profile keeps its own copy of the persisted fields; the default profile
+// uses the unprefixed keys written before profiles existed.
+export const profileStorageKey = (key) => {
+  const profile =
+    typeof window === "undefined" ? null : window.localStorage.getItem(ACTIVE_PROFILE_KEY);
+  return profile && profile !== DEFAULT_PROFILE ? `profile:${profile}:${key}` : key;
+};
+
 const DEFAULT_PLATFORM = {
   isMac: false,
   isUserInChina: false,
@@ -47,12 +49,17 @@ const DEFAULT_PLATFORM = {
 const DEFAULT_STATE = {
   promptList: DEFAULT_PROMPT_OPTIONS,
   systemShortcuts: DEFAULT_SHORTCUT_LIST,
-  historyList: DEFAULT_HISTORY_LIST,
-  chatHistoryList: DEFAULT_CHAT_HISTORY_LIST,
   selectedPrompt: DEFAULT_PROMPT_OPTIONS_VALUE,
   showShortcutGuide: true,
   OCRLang: DEFAULT_OCR_LANG,
-  openAIKey: "",
+  // whether the backend vault holds an OpenAI key (runtime only, the key itself stays in Go)
+  hasOpenAIKey: false,
+  // backend vault status from GetVaultStatus and whether the unlock prompt is open (runtime only)
+  vaultStatus: null,
+  showVaultPrompt: false,
+  // history session the chat page appends to and the ids of the messages already
+  // saved there (runtime only, the history itself lives in the Go store)
+  chatSession: { id: "", messageIds: [] },
   recentPrompts: [],
   showPromptArea: IS_SHOW_PROMPT_AREA_VALUE,
   recentPromptsActiveKey: IS_OPEN_RECENT_PROMPTS_VALUE,
@@ -66,7 +73,7 @@ const multiKeyStorage = {
     const state = {};
     for (const [stateKey, storageKey] of Object.entries(STORAGE_KEYS)) {
       try {
-        const raw = window.localStorage.getItem(storageKey);
+        const raw = window.localStorage.getItem(profileStorageKey(storageKey));
         state[stateKey] = raw != null ? JSON.parse(raw) : DEFAULT_STATE[stateKey];
       } catch {
         state[stateKey] = DEFAULT_STATE[stateKey];
@@ -81,7 +88,7 @@ const multiKeyStorage = {
     for (const [stateKey, storageKey] of Object.entries(STORAGE_KEYS)) {
       if (stateKey in state) {
         try {
-          window.localStorage.setItem(storageKey, JSON.stringify(state[stateKey]));
+          window.localStorage.setItem(profileStorageKey(storageKey), JSON.stringify(state[stateKey]));
         } catch (e) {
           console.error(`store persist ${stateKey}:`, e);
         }
@@ -90,7 +97,7 @@ const multiKeyStorage = {
   },
   removeItem: () => {
     if (typeof window === "undefined") return;
-    Object.values(STORAGE_KEYS).forEach((key) => window.localStorage.removeItem(key));
+    Object.values(STORAGE_KEYS).forEach((key) => window.localStorage.removeItem(profileStorageKey(key)));
   },
 };
 
@@ -109,12 +116,13 @@ export const useAppStore = create(
 
         setPromptList: createSetter("promptList"),
         setSystemShortcuts: createSetter("systemShortcuts"),
-        setHistoryList: createSetter("historyList"),
-        setChatHistoryList: createSetter("chatHistoryList"),
         setSelectedPrompt: createSetter("selectedPrompt"),
         setShowShortcutGuide: createSetter("showShortcutGuide"),
         setOCRLang: createSetter("OCRLang"),
-        setOpenAIKey: createSetter("openAIKey"),
+        setHasOpenAIKey: createSetter("hasOpenAIKey"),
+        setVaultStatus: createSetter("vaultStatus"),
+        setShowVaultPrompt: createSetter("showVaultPrompt"),
+        setChatSession: createSetter("chatSession"),
         setRecentPrompts: createSetter("recentPrompts"),
         setShowPromptArea: createSetter("showPromptArea"),
         setRecentPromptsActiveKey: createSetter("recentPromptsActiveKey"),
@@ -132,12 +140,9 @@ export const useAppStore = create(
       partialize: (state) => ({
         promptList: state.promptList,
         systemShortcuts: state.systemShortcuts,
-        historyList: state.historyList,
-        chatHistoryList: state.chatHistoryList,
         selectedPrompt: state.selectedPrompt,
         showShortcutGuide: state.showShortcutGuide,
         OCRLang: state.OCRLang,
-        openAIKey: state.openAIKey,
         recentPrompts: state.recentPrompts,
         showPromptArea: state.showPromptArea,
         recentPromptsActiveKey: state.recentPromptsActiveKey,
@@ -1,7 +1,28 @@
-import { IsMac, GetUniqueHardwareID } from "../wailsjs/go/main/App";
-import { EventsEmit } from "../wailsjs/runtime/runtime";
-import { useAppStore } from "./store";
-import { DEFAULT_PROMPT_OPTIONS, DEFAULT_SHORTCUT_LIST } from "./constant";
+import {
+    AddHistoryMessage,
+    CreateHistorySession,
+    IsMac,
+    GetUniqueHardwareID,
+    GetActiveProfile,
+    GetConfig,
+    GetVaultStatus,
+    HasSecret,
+    PatchConfig,
+    SetSecret,
+} from "../wailsjs/go/main/App";
+import { EventsEmit, EventsOff, EventsOn } from "../wailsjs/runtime/runtime";
+import { profileStorageKey, useAppStore } from "./store";
+import {
+    CHAT_HISTORY_LIST_KEY,
+    DEFAULT_PROMPT_OPTIONS,
+    DEFAULT_SHORTCUT_LIST,
+    HISTORY_LIST_KEY,
+    OPENAI_API_KEY_KEY,
+    OPENAI_SECRET_NAME,
+    ACTIVE_PROFILE_KEY,
+    DEFAULT_PROFILE,
+    DEFAULT_DAILY_LIMIT,
+} from "./constant";
 
 export const initEnv = async () => {
     return {
@@ -58,14 +79,69 @@ export const getLocalStorage = (key, defaultValue) => {
     }
 };
 
-export const historyGenerator = (message, response) => {
-    return {
-        message: message,
-        response: response,
-        timestamp: new Date().toLocaleString(),
-    };
+// Builds a HistoryMessage for the Go history store. The answering provider,
+// model and token usage come from the ChatResponse when there is one.
+const historyMessage = (role, content, { prompt = "", response, timestamp } = {}) => ({
+    role,
+    content,
+    prompt: role === "user" ? prompt : "",
+    provider: response?.provider ?? "",
+    model: response?.model ?? "",
+    usage: response?.usage,
+    createdAt: typeof timestamp === "number" ? new Date(timestamp).toISOString() : undefined,
+});
+
+// Saves one Ask exchange as its own history session.
+export const recordAskHistory = async (message, answer, { prompt = "", response } = {}) => {
+    try {
+        const session = await CreateHistorySession({ kind: "ask", prompt });
+        await AddHistoryMessage(session.id, historyMessage("user", message, { prompt }));
+        await AddHistoryMessage(session.id, historyMessage("assistant", answer, { response }));
+    } catch (e) {
+        console.error("record ask history:", e);
+    }
 };
 
+// Appends the chat messages not yet saved to the current history session.
+// When the saved messages are no longer a prefix of the chat (an edit or a
+// regenerate cut it short) the branch goes into a new session, so the earlier
+// one stays intact. `response` belongs to the last message when it is an answer.
+export const recordChatHistory = async (messages, { prompt = "", response } = {}) => {
+    const { chatSession, setChatSession } = useAppStore.getState();
+    let { id, messageIds } = chatSession;
+    try {
+        if (!id || !messageIds.every((messageId, i) => messages[i]?.id === messageId)) {
+            id = (await CreateHistorySession({ kind: "chat", prompt })).id;
+            messageIds = [];
+        }
+        for (let i = messageIds.length; i < messages.length; i++) {
+            const m = messages[i];
+            await AddHistoryMessage(
+                id,
+                historyMessage(m.type, m.content, {
+                    prompt,
+                    response: i === messages.length - 1 ? response : undefined,
+                    timestamp: m.timestamp,
+                }),
+            );
+            messageIds = [...messageIds, m.id];
+        }
+    } catch (e) {
+        console.error("record chat history:", e);
+    } finally {
+        setChatSession({ id, messageIds });
+    }
+};
+
+// Converts history messages back into chat page messages.
+export const chatMessagesFromHistory = (messages) =>
+    (messages ?? []).map((m) => ({
+        id: m.id,
+        type: m.role,
+        content: m.content,
+        timestamp: new Date(m.createdAt).getTime(),
+    }));
+
 
 
 export const userMessageGenerator = (message) => {
@@ -114,6 +190,7 @@ export const incrementDailyUsageCount = () => {
         const currentCount = getDailyUsageCount();
         const newCount = currentCount + 1;
         localStorage.setItem('dailyUsageCount', newCount.toString());
+        syncDailyUsageToBackend();
         return newCount;
     } catch (error) {
         console.error('Error incrementing daily usage count:', error);
@@ -131,6 +208,19 @@ export const checkDailyUsageLimit = (limit = 5) => {
     };
 };
 
+// Shortcuts that replace the selection ask from Go without the window, so the
+// backend needs the remaining count to apply the same daily limit.
+export const syncDailyUsageToBackend = (limit = DEFAULT_DAILY_LIMIT) => {
+    EventsEmit("syncDailyUsage", checkDailyUsageLimit(limit).remaining);
+};
+
+// Counts answers pasted over the selection by the backend like any other answer.
+export const listenSelectionReplaced = () => {
+    EventsOn("selection:replaced", () => {
+        incrementDailyUsageCount();
+    });
+};
+
 export const sleep = (ms) => {
     return new Promise((resolve) => setTimeout(resolve, ms));
 };
@@ -139,6 +229,193 @@ export const syncShortcutListToBackend = (promptList, systemShortcuts) => {
     EventsEmit("syncShortcutList", JSON.stringify([...(promptList ?? []), ...(systemShortcuts ?? [])]));
 };
 
+export const syncOCRLangToBackend = (OCRLang) => {
+    EventsEmit("syncOCRLang", JSON.stringify(OCRLang ?? []));
+};
+
+// One-time import of the history older versions kept in localStorage into the
+// Go history store; new messages go straight to the store, and the backend
+// ignores this once the import has been recorded.
+export const importHistoryToBackend = () => {
+    EventsEmit(
+        "importLocalStorageHistory",
+        localStorage.getItem(profileStorageKey(HISTORY_LIST_KEY)) ?? "[]",
+        localStorage.getItem(profileStorageKey(CHAT_HISTORY_LIST_KEY)) ?? "[]",
+    );
+};
+
+// Moves a key saved by older versions from localStorage into the backend vault,
+// then records whether the vault holds an OpenAI key. The key is never read back.
+export const migrateOpenAIKeyToVault = async () => {
+    const { setHasOpenAIKey } = useAppStore.getState();
+    try {
+        const raw = localStorage.getItem(OPENAI_API_KEY_KEY);
+        const legacyKey = raw ? JSON.parse(raw) : "";
+        if (typeof legacyKey === "string" && legacyKey.trim() !== "") {
+            await SetSecret(OPENAI_SECRET_NAME, legacyKey.trim());
+        }
+        localStorage.removeItem(OPENAI_API_KEY_KEY);
+        setHasOpenAIKey(await HasSecret(OPENAI_SECRET_NAME));
+    } catch (e) {
+        console.error("migrate OpenAI key:", e);
+    }
+    const status = await refreshVaultStatus();
+    // a locked vault, or one that could not be created without a keyring, needs the
+    // user's passphrase before saved keys can be used or the legacy key moved in
+    if (status?.locked && (status.secrets?.length > 0 || localStorage.getItem(OPENAI_API_KEY_KEY))) {
+        useAppStore.getState().setShowVaultPrompt(true);
+    }
+};
+
+// Reads the vault status into the store so the UI can show whether it is locked.
+export const refreshVaultStatus = async () => {
+    try {
+        const status = await GetVaultStatus();
+        useAppStore.getState().setVaultStatus(status);
+        return status;
+    } catch (e) {
+        console.error("get vault status:", e);
+        return null;
+    }
+};
+
+export const isVaultLockedError = (response) => response?.error?.category === "vault_locked";
+
+// Store fields kept in the backend config file (ui section) so they survive
+// reinstalls and can be edited by hand; localStorage stays as a cache.
+const UI_CONFIG_FIELDS = {
+    OCRLang: "ocrLang",
+    showShortcutGuide: "showShortcutGuide",
+    showPromptArea: "showPromptArea",
+};
+
+const CONFIG_CHANGED_EVENT = "config:changed";
+
+const sameValue = (a, b) => JSON.stringify(a) === JSON.stringify(b);
+
+// Label -> shortcut map stored in the config's [shortcuts] table.
+const shortcutBindings = ({ promptList, systemShortcuts }) =>
+    Object.fromEntries(
+        [...(promptList ?? []), ...(systemShortcuts ?? [])].map((item) => [item.label, item.shortcut ?? ""]),
+    );
+
+const withBindings = (list, bindings) =>
+    (list ?? []).map((item) =>
+        item.label in bindings && bindings[item.label] !== item.shortcut
+            ? { ...item, shortcut: bindings[item.label] }
+            : item,
+    );
+
+// Copies config values into the store, touching only fields that differ so the
+// store subscription does not write the same values straight back.
+const applyConfigToStore = (config) => {
+    const ui = config?.ui ?? {};
+    const state = useAppStore.getState();
+    const next = {};
+    for (const [stateKey, configKey] of Object.entries(UI_CONFIG_FIELDS)) {
+        if (ui[configKey] != null && !sameValue(ui[configKey], state[stateKey])) {
+            next[stateKey] = ui[configKey];
+        }
+    }
+    const bindings = config?.shortcuts ?? {};
+    for (const key of ["promptList", "systemShortcuts"]) {
+        const list = withBindings(state[key], bindings);
+        if (!sameValue(list, state[key])) next[key] = list;
+    }
+    if (Object.keys(next).length === 0) return;
+    useAppStore.setState(next);
+    if (next.OCRLang) syncOCRLangToBackend(next.OCRLang);
+};
+
+// Store changes expressed as a merge patch for PatchConfig; removed prompts drop their binding.
+const configPatchFromStore = (state, prev) => {
+    const patch = {};
+    const ui = {};
+    for (const [stateKey, configKey] of Object.entries(UI_CONFIG_FIELDS)) {
+        if (!sameValue(state[stateKey], prev[stateKey])) ui[configKey] = state[stateKey];
+    }
+    if (Object.keys(ui).length > 0) patch.ui = ui;
+    if (state.promptList !== prev.promptList || state.systemShortcuts !== prev.systemShortcuts) {
+        const before = shortcutBindings(prev);
+        const after = shortcutBindings(state);
+        const shortcuts = {};
+        for (const label of Object.keys(before)) {
+            if (!(label in after)) shortcuts[label] = null;
+        }
+        for (const [label, shortcut] of Object.entries(after)) {
+            if (before[label] !== shortcut) shortcuts[label] = shortcut;
+        }
+        if (Object.keys(shortcuts).length > 0) patch.shortcuts = shortcuts;
+    }
+    return patch;
+};
+
+let unsubscribeUIConfig = null;
+
+// Loads the ui settings and shortcut bindings from the config file into the store,
+// seeds the file with values it does not have yet, patches the file whenever they
+// change, and follows edits made to the file while the app is running.
+export const syncUIConfig = async () => {
+    if (unsubscribeUIConfig) return;
+    try {
+        const { config } = await GetConfig();
+        const ui = config?.ui ?? {};
+        const state = useAppStore.getState();
+        const missing = {};
+        for (const [stateKey, configKey] of Object.entries(UI_CONFIG_FIELDS)) {
+            if (ui[configKey] == null) missing[configKey] = state[stateKey];
+        }
+        const missingShortcuts = Object.fromEntries(
+            Object.entries(shortcutBindings(state)).filter(([label]) => !(label in (config?.shortcuts ?? {}))),
+        );
+        applyConfigToStore(config);
+        const patch = {};
+        if (Object.keys(missing).length > 0) patch.ui = missing;
+        if (Object.keys(missingShortcuts).length > 0) patch.shortcuts = missingShortcuts;
+        if (Object.keys(patch).length > 0) await PatchConfig(patch);
+    } catch (e) {
+        console.error("load ui config:", e);
+    }
+    if (unsubscribeUIConfig) return;
+    const unsubscribeStore = useAppStore.subscribe((state, prev) => {
+        const patch = configPatchFromStore(state, prev);
+        if (Object.keys(patch).length > 0) {
+            PatchConfig(patch).catch((e) => console.error("save ui config:", e));
+        }
+    });
+    EventsOn(CONFIG_CHANGED_EVENT, (state) => applyConfigToStore(state?.config));
+    unsubscribeUIConfig = () => {
+        unsubscribeStore();
+        EventsOff(CONFIG_CHANGED_EVENT);
+    };
+};
+
+const PROFILE_CHANGED_EVENT = "profile:changed";
+
+// Points the store at the given profile's localStorage keys and reloads the window,
+// which re-syncs prompts, shortcuts and settings of that profile to the backend.
+const reloadWithProfile = (profile) => {
+    localStorage.setItem(ACTIVE_PROFILE_KEY, profile || DEFAULT_PROFILE);
+    window.location.reload();
+};
+
+// Makes sure the store was loaded for the backend's active profile (it may have been
+// switched from the command line or another window) and follows later switches.
+// Resolves to false when the window is about to reload; callers should skip syncing then.
+export const syncActiveProfile = async () => {
+    EventsOn(PROFILE_CHANGED_EVENT, reloadWithProfile);
+    try {
+        const active = await GetActiveProfile();
+        if (active !== (localStorage.getItem(ACTIVE_PROFILE_KEY) || DEFAULT_PROFILE)) {
+            reloadWithProfile(active);
+            return false;
+        }
+    } catch (e) {
+        console.error("load active profile:", e);
+    }
+    return true;
+};
+
 export const resetShortcut = () => {
     const { setSystemShortcuts, setPromptList } = useAppStore.getState();
     setSystemShortcuts(DEFAULT_SHORTCUT_LIST);
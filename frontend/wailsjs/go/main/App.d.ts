@@ -7,18 +7,66 @@ export function AIBianxieAPI(arg1:string):Promise<main.ChatResponse>;
 
 export function AIOpenHubAPI(arg1:string):Promise<main.ChatResponse>;
 
+export function AddHistoryMessage(arg1:string,arg2:main.HistoryMessage):Promise<main.HistoryMessage>;
+
+export function AskAboutImage(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:main.ChatOptions):Promise<main.ChatResponse>;
+
+export function AskAboutImageStream(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:main.ChatOptions):Promise<string>;
+
+export function AskClipboardEntry(arg1:string,arg2:string,arg3:string,arg4:string,arg5:main.ChatOptions):Promise<main.ChatResponse>;
+
 export function CanAccessGoogle():Promise<boolean>;
 
+export function CanReachProvider(arg1:string):Promise<boolean>;
+
+export function CancelRequest(arg1:string):Promise<boolean>;
+
+export function Chat(arg1:string,arg2:string,arg3:string,arg4:string):Promise<main.ChatResponse>;
+
 export function ChatAPI(arg1:string):Promise<main.ChatResponse>;
 
+export function ChatStream(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
+export function ChatStreamWithOptions(arg1:string,arg2:string,arg3:string,arg4:main.ChatOptions):Promise<string>;
+
+export function ChatWithFallback(arg1:string,arg2:string,arg3:main.ChatOptions):Promise<main.ChatResponse>;
+
+export function ChatWithOptions(arg1:string,arg2:string,arg3:string,arg4:main.ChatOptions):Promise<main.ChatResponse>;
+
+export function ClearClipboardHistory():Promise<void>;
+
+export function ClearHistorySessions(arg1:string):Promise<void>;
+
+export function ClipboardHistoryEnabled():Promise<boolean>;
+
+export function CreateHistorySession(arg1:main.HistorySession):Promise<main.HistorySession>;
+
 export function CreateScreenshot(arg1:context.Context):Promise<string>;
 
+export function CreateScreenshotLinux(arg1:context.Context):Promise<string>;
+
 export function CreateScreenshotMac(arg1:context.Context):Promise<string>;
 
 export function CreateScreenshotWindows(arg1:context.Context):Promise<string>;
 
 export function CustomOpenAIAPI(arg1:string,arg2:string):Promise<main.ChatResponse>;
 
+export function DeleteHistorySession(arg1:string):Promise<void>;
+
+export function DeleteSecret(arg1:string):Promise<void>;
+
+export function ExportHistory(arg1:main.HistoryExportOptions):Promise<Array<string>>;
+
+export function GetActiveProfile():Promise<string>;
+
+export function GetConfig():Promise<main.ConfigState>;
+
+export function GetFallbackChain():Promise<Array<main.FallbackStep>>;
+
+export function GetHistoryMessages(arg1:string):Promise<Array<main.HistoryMessage>>;
+
+export function GetHistorySession(arg1:string):Promise<main.HistorySession>;
+
 export function GetMousePosition():Promise<any>;
 
 export function GetPromptsCSV():Promise<string>;
@@ -27,20 +75,86 @@ export function GetSelection(arg1:context.Context):Promise<string>;
 
 export function GetUniqueHardwareID():Promise<string>;
 
+export function GetVaultStatus():Promise<main.VaultStatus>;
+
 export function Greet(arg1:string):Promise<string>;
 
+export function HasSecret(arg1:string):Promise<boolean>;
+
+export function ImportHistory(arg1:string):Promise<number>;
+
+export function ImportLocalStorageHistory(arg1:string,arg2:string):Promise<number>;
+
 export function IsMac():Promise<boolean>;
 
 export function IsUserInChina():Promise<boolean>;
 
+export function ListClipboardHistory():Promise<Array<main.ClipboardEntry>>;
+
+export function ListHistoryConversations(arg1:string):Promise<Array<main.HistoryConversation>>;
+
+export function ListHistorySessions(arg1:string):Promise<Array<main.HistorySession>>;
+
+export function ListModels(arg1:string):Promise<Array<string>>;
+
+export function ListProfiles():Promise<Array<string>>;
+
+export function ListProviders():Promise<Array<main.ProviderInfo>>;
+
 export function LoadPromptsCSV():Promise<Array<main.Prompt>>;
 
 export function LoadPromptsJSON():Promise<Array<main.PromptCategory>>;
 
+export function LockVault():Promise<void>;
+
 export function OpenAIAPI(arg1:string):Promise<main.ChatResponse>;
 
+export function PatchConfig(arg1:Record<string, any>):Promise<main.ConfigState>;
+
+export function RecognizeImage(arg1:string):Promise<main.OCRResult>;
+
+export function RecognizeScreenshot():Promise<main.OCRResult>;
+
 export function RegisterKeyboardShortcut(arg1:context.Context):Promise<void>;
 
+export function RegisterProvider(arg1:string):Promise<void>;
+
+export function ReplaceSelection(arg1:string):Promise<void>;
+
+export function SearchClipboardHistory(arg1:string):Promise<Array<main.ClipboardEntry>>;
+
+export function SearchHistory(arg1:main.HistorySearchQuery):Promise<main.HistorySearchResult>;
+
+export function SetClipboardHistoryEnabled(arg1:boolean):Promise<void>;
+
+export function SetClipboardHistoryExclusions(arg1:Array<string>):Promise<void>;
+
+export function SetFallbackChain(arg1:Array<string>):Promise<void>;
+
+export function SetHistoryEncryption(arg1:boolean):Promise<void>;
+
+export function SetOCRLanguages(arg1:Array<string>):Promise<void>;
+
+export function SetSecret(arg1:string,arg2:string):Promise<void>;
+
 export function SetShortcutList(arg1:string):Promise<void>;
 
+export function SetVaultPassphrase(arg1:string):Promise<void>;
+
 export function ShowPopWindow():Promise<void>;
+
+export function StreamAIBianxieAPI(arg1:string,arg2:string):Promise<string>;
+
+export function StreamAIOpenHubAPI(arg1:string,arg2:string):Promise<string>;
+
+export function StreamChatAPI(arg1:string,arg2:string):Promise<string>;
+
+export function StreamCustomOpenAIAPI(arg1:string,arg2:string,arg3:string):Promise<string>;
+
+export function StreamWithFallback(arg1:string,arg2:string,arg3:main.ChatOptions):Promise<string>;
+
+export function SwitchProfile(arg1:string):Promise<void>;
+
+export function UnlockVault(arg1:string):Promise<void>;
+
+export function UpdateHistorySession(arg1:main.HistorySession):Promise<main.HistorySession>;
@@ -10,18 +10,82 @@ export function AIOpenHubAPI(arg1) {
   return window['go']['main']['App']['AIOpenHubAPI'](arg1);
 }
 
+export function AddHistoryMessage(arg1, arg2) {
+  return window['go']['main']['App']['AddHistoryMessage'](arg1, arg2);
+}
+
+export function AskAboutImage(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['AskAboutImage'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function AskAboutImageStream(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['main']['App']['AskAboutImageStream'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function AskClipboardEntry(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['AskClipboardEntry'](arg1, arg2, arg3, arg4, arg5);
+}
+
 export function CanAccessGoogle() {
   return window['go']['main']['App']['CanAccessGoogle']();
 }
 
+export function CanReachProvider(arg1) {
+  return window['go']['main']['App']['CanReachProvider'](arg1);
+}
+
+export function CancelRequest(arg1) {
+  return window['go']['main']['App']['CancelRequest'](arg1);
+}
+
+export function Chat(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['Chat'](arg1, arg2, arg3, arg4);
+}
+
 export function ChatAPI(arg1) {
   return window['go']['main']['App']['ChatAPI'](arg1);
 }
 
+export function ChatStream(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ChatStream'](arg1, arg2, arg3, arg4);
+}
+
+export function ChatStreamWithOptions(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ChatStreamWithOptions'](arg1, arg2, arg3, arg4);
+}
+
+export function ChatWithFallback(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ChatWithFallback'](arg1, arg2, arg3);
+}
+
+export function ChatWithOptions(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ChatWithOptions'](arg1, arg2, arg3, arg4);
+}
+
+export function ClearClipboardHistory() {
+  return window['go']['main']['App']['ClearClipboardHistory']();
+}
+
+export function ClearHistorySessions(arg1) {
+  return window['go']['main']['App']['ClearHistorySessions'](arg1);
+}
+
+export function ClipboardHistoryEnabled() {
+  return window['go']['main']['App']['ClipboardHistoryEnabled']();
+}
+
+export function CreateHistorySession(arg1) {
+  return window['go']['main']['App']['CreateHistorySession'](arg1);
+}
+
 export function CreateScreenshot(arg1) {
   return window['go']['main']['App']['CreateScreenshot'](arg1);
 }
 
+export function CreateScreenshotLinux(arg1) {
+  return window['go']['main']['App']['CreateScreenshotLinux'](arg1);
+}
+
 export function CreateScreenshotMac(arg1) {
   return window['go']['main']['App']['CreateScreenshotMac'](arg1);
 }
@@ -34,6 +98,38 @@ export function CustomOpenAIAPI(arg1, arg2) {
   return window['go']['main']['App']['CustomOpenAIAPI'](arg1, arg2);
 }
 
+export function DeleteHistorySession(arg1) {
+  return window['go']['main']['App']['DeleteHistorySession'](arg1);
+}
+
+export function DeleteSecret(arg1) {
+  return window['go']['main']['App']['DeleteSecret'](arg1);
+}
+
+export function ExportHistory(arg1) {
+  return window['go']['main']['App']['ExportHistory'](arg1);
+}
+
+export function GetActiveProfile() {
+  return window['go']['main']['App']['GetActiveProfile']();
+}
+
+export function GetConfig() {
+  return window['go']['main']['App']['GetConfig']();
+}
+
+export function GetFallbackChain() {
+  return window['go']['main']['App']['GetFallbackChain']();
+}
+
+export function GetHistoryMessages(arg1) {
+  return window['go']['main']['App']['GetHistoryMessages'](arg1);
+}
+
+export function GetHistorySession(arg1) {
+  return window['go']['main']['App']['GetHistorySession'](arg1);
+}
+
 export function GetMousePosition() {
   return window['go']['main']['App']['GetMousePosition']();
 }
@@ -50,10 +146,26 @@ export function GetUniqueHardwareID() {
   return window['go']['main']['App']['GetUniqueHardwareID']();
 }
 
+export function GetVaultStatus() {
+  return window['go']['main']['App']['GetVaultStatus']();
+}
+
 export function Greet(arg1) {
   return window['go']['main']['App']['Greet'](arg1);
 }
 
+export function HasSecret(arg1) {
+  return window['go']['main']['App']['HasSecret'](arg1);
+}
+
+export function ImportHistory(arg1) {
+  return window['go']['main']['App']['ImportHistory'](arg1);
+}
+
+export function ImportLocalStorageHistory(arg1, arg2) {
+  return window['go']['main']['App']['ImportLocalStorageHistory'](arg1, arg2);
+}
+
 export function IsMac() {
   return window['go']['main']['App']['IsMac']();
 }
@@ -62,6 +174,30 @@ export function IsUserInChina() {
   return window['go']['main']['App']['IsUserInChina']();
 }
 
+export function ListClipboardHistory() {
+  return window['go']['main']['App']['ListClipboardHistory']();
+}
+
+export function ListHistoryConversations(arg1) {
+  return window['go']['main']['App']['ListHistoryConversations'](arg1);
+}
+
+export function ListHistorySessions(arg1) {
+  return window['go']['main']['App']['ListHistorySessions'](arg1);
+}
+
+export function ListModels(arg1) {
+  return window['go']['main']['App']['ListModels'](arg1);
+}
+
+export function ListProfiles() {
+  return window['go']['main']['App']['ListProfiles']();
+}
+
+export function ListProviders() {
+  return window['go']['main']['App']['ListProviders']();
+}
+
 export function LoadPromptsCSV() {
   return window['go']['main']['App']['LoadPromptsCSV']();
 }
@@ -70,18 +206,110 @@ export function LoadPromptsJSON() {
   return window['go']['main']['App']['LoadPromptsJSON']();
 }
 
+export function LockVault() {
+  return window['go']['main']['App']['LockVault']();
+}
+
 export function OpenAIAPI(arg1) {
   return window['go']['main']['App']['OpenAIAPI'](arg1);
 }
 
+export function PatchConfig(arg1) {
+  return window['go']['main']['App']['PatchConfig'](arg1);
+}
+
+export function RecognizeImage(arg1) {
+  return window['go']['main']['App']['RecognizeImage'](arg1);
+}
+
+export function RecognizeScreenshot() {
+  return window['go']['main']['App']['RecognizeScreenshot']();
+}
+
 export function RegisterKeyboardShortcut(arg1) {
   return window['go']['main']['App']['RegisterKeyboardShortcut'](arg1);
 }
 
+export function RegisterProvider(arg1) {
+  return window['go']['main']['App']['RegisterProvider'](arg1);
+}
+
+export function ReplaceSelection(arg1) {
+  return window['go']['main']['App']['ReplaceSelection'](arg1);
+}
+
+export function SearchClipboardHistory(arg1) {
+  return window['go']['main']['App']['SearchClipboardHistory'](arg1);
+}
+
+export function SearchHistory(arg1) {
+  return window['go']['main']['App']['SearchHistory'](arg1);
+}
+
+export function SetClipboardHistoryEnabled(arg1) {
+  return window['go']['main']['App']['SetClipboardHistoryEnabled'](arg1);
+}
+
+export function SetClipboardHistoryExclusions(arg1) {
+  return window['go']['main']['App']['SetClipboardHistoryExclusions'](arg1);
+}
+
+export function SetFallbackChain(arg1) {
+  return window['go']['main']['App']['SetFallbackChain'](arg1);
+}
+
+export function SetHistoryEncryption(arg1) {
+  return window['go']['main']['App']['SetHistoryEncryption'](arg1);
+}
+
+export function SetOCRLanguages(arg1) {
+  return window['go']['main']['App']['SetOCRLanguages'](arg1);
+}
+
+export function SetSecret(arg1, arg2) {
+  return window['go']['main']['App']['SetSecret'](arg1, arg2);
+}
+
 export function SetShortcutList(arg1) {
   return window['go']['main']['App']['SetShortcutList'](arg1);
 }
 
+export function SetVaultPassphrase(arg1) {
+  return window['go']['main']['App']['SetVaultPassphrase'](arg1);
+}
+
 export function ShowPopWindow() {
   return window['go']['main']['App']['ShowPopWindow']();
 }
+
+export function StreamAIBianxieAPI(arg1, arg2) {
+  return window['go']['main']['App']['StreamAIBianxieAPI'](arg1, arg2);
+}
+
+export function StreamAIOpenHubAPI(arg1, arg2) {
+  return window['go']['main']['App']['StreamAIOpenHubAPI'](arg1, arg2);
+}
+
+export function StreamChatAPI(arg1, arg2) {
+  return window['go']['main']['App']['StreamChatAPI'](arg1, arg2);
+}
+
+export function StreamCustomOpenAIAPI(arg1, arg2, arg3) {
+  return window['go']['main']['App']['StreamCustomOpenAIAPI'](arg1, arg2, arg3);
+}
+
+export function StreamWithFallback(arg1, arg2, arg3) {
+  return window['go']['main']['App']['StreamWithFallback'](arg1, arg2, arg3);
+}
+
+export function SwitchProfile(arg1) {
+  return window['go']['main']['App']['SwitchProfile'](arg1);
+}
+
+export function UnlockVault(arg1) {
+  return window['go']['main']['App']['UnlockVault'](arg1);
+}
+
+export function UpdateHistorySession(arg1) {
+  return window['go']['main']['App']['UpdateHistorySession'](arg1);
+}
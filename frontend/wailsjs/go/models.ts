@@ -1,8 +1,72 @@
 export namespace main {
 	
+	export class APIError {
+	    category: string;
+	    provider?: string;
+	    providerCode?: string;
+	    status?: number;
+	    retryable: boolean;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new APIError(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.category = source["category"];
+	        this.provider = source["provider"];
+	        this.providerCode = source["providerCode"];
+	        this.status = source["status"];
+	        this.retryable = source["retryable"];
+	        this.message = source["message"];
+	    }
+	}
+	export class ChatOptions {
+	    model?: string;
+	    temperature?: number;
+	    topP?: number;
+	    maxTokens?: number;
+	    stop?: string[];
+	    seed?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChatOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.model = source["model"];
+	        this.temperature = source["temperature"];
+	        this.topP = source["topP"];
+	        this.maxTokens = source["maxTokens"];
+	        this.stop = source["stop"];
+	        this.seed = source["seed"];
+	    }
+	}
+	export class TokenUsage {
+	    promptTokens: number;
+	    completionTokens: number;
+	    totalTokens: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TokenUsage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.promptTokens = source["promptTokens"];
+	        this.completionTokens = source["completionTokens"];
+	        this.totalTokens = source["totalTokens"];
+	    }
+	}
 	export class ChatResponse {
 	    code: number;
 	    data: any;
+	    provider?: string;
+	    error?: APIError;
+	    model?: string;
+	    usage?: TokenUsage;
 	
 	    static createFrom(source: any = {}) {
 	        return new ChatResponse(source);
@@ -12,8 +76,661 @@ export namespace main {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.code = source["code"];
 	        this.data = source["data"];
+	        this.provider = source["provider"];
+	        this.error = this.convertValues(source["error"], APIError);
+	        this.model = source["model"];
+	        this.usage = this.convertValues(source["usage"], TokenUsage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ClipboardConfig {
+	    historySize: number;
+	    historyMaxBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClipboardConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.historySize = source["historySize"];
+	        this.historyMaxBytes = source["historyMaxBytes"];
+	    }
+	}
+	export class ClipboardEntry {
+	    id: string;
+	    kind: string;
+	    text?: string;
+	    image?: string;
+	    // Go type: time
+	    copiedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClipboardEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.text = source["text"];
+	        this.image = source["image"];
+	        this.copiedAt = this.convertValues(source["copiedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UIConfig {
+	    ocrLang?: string[];
+	    showShortcutGuide?: boolean;
+	    showPromptArea?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new UIConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ocrLang = source["ocrLang"];
+	        this.showShortcutGuide = source["showShortcutGuide"];
+	        this.showPromptArea = source["showPromptArea"];
+	    }
+	}
+	export class ImagesConfig {
+	    maxDimension: number;
+	    maxBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImagesConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.maxDimension = source["maxDimension"];
+	        this.maxBytes = source["maxBytes"];
 	    }
 	}
+	export class Duration {
+	    Duration: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Duration(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Duration = source["Duration"];
+	    }
+	}
+	export class HTTPConfig {
+	    timeout: Duration;
+	    retryMaxAttempts: number;
+	    retryBaseDelay: Duration;
+	    retryMaxDelay: Duration;
+	    retryAfterMax: Duration;
+	
+	    static createFrom(source: any = {}) {
+	        return new HTTPConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.timeout = this.convertValues(source["timeout"], Duration);
+	        this.retryMaxAttempts = source["retryMaxAttempts"];
+	        this.retryBaseDelay = this.convertValues(source["retryBaseDelay"], Duration);
+	        this.retryMaxDelay = this.convertValues(source["retryMaxDelay"], Duration);
+	        this.retryAfterMax = this.convertValues(source["retryAfterMax"], Duration);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProvidersConfig {
+	    fallback: string[];
+	    custom: string;
+	    popaskUrl: string;
+	    anthropicBaseUrl: string;
+	    ollamaHost: string;
+	    ollamaModel: string;
+	    ollamaVisionModel: string;
+	    llamacppUrl: string;
+	    llamacppModel: string;
+	    bianxieUrl: string;
+	    openhubUrl: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProvidersConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fallback = source["fallback"];
+	        this.custom = source["custom"];
+	        this.popaskUrl = source["popaskUrl"];
+	        this.anthropicBaseUrl = source["anthropicBaseUrl"];
+	        this.ollamaHost = source["ollamaHost"];
+	        this.ollamaModel = source["ollamaModel"];
+	        this.ollamaVisionModel = source["ollamaVisionModel"];
+	        this.llamacppUrl = source["llamacppUrl"];
+	        this.llamacppModel = source["llamacppModel"];
+	        this.bianxieUrl = source["bianxieUrl"];
+	        this.openhubUrl = source["openhubUrl"];
+	    }
+	}
+	export class UserConfig {
+	    version: number;
+	    providers: ProvidersConfig;
+	    http: HTTPConfig;
+	    images: ImagesConfig;
+	    clipboard: ClipboardConfig;
+	    ui: UIConfig;
+	    shortcuts?: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new UserConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.providers = this.convertValues(source["providers"], ProvidersConfig);
+	        this.http = this.convertValues(source["http"], HTTPConfig);
+	        this.images = this.convertValues(source["images"], ImagesConfig);
+	        this.clipboard = this.convertValues(source["clipboard"], ClipboardConfig);
+	        this.ui = this.convertValues(source["ui"], UIConfig);
+	        this.shortcuts = source["shortcuts"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ConfigState {
+	    config: UserConfig;
+	    path: string;
+	    envOverrides: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ConfigState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.config = this.convertValues(source["config"], UserConfig);
+	        this.path = source["path"];
+	        this.envOverrides = source["envOverrides"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class FallbackStep {
+	    provider: string;
+	    model?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FallbackStep(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	    }
+	}
+	
+	export class HistoryMessage {
+	    id: string;
+	    sessionId: string;
+	    role: string;
+	    content: string;
+	    prompt?: string;
+	    provider?: string;
+	    model?: string;
+	    usage: TokenUsage;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryMessage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.sessionId = source["sessionId"];
+	        this.role = source["role"];
+	        this.content = source["content"];
+	        this.prompt = source["prompt"];
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.usage = this.convertValues(source["usage"], TokenUsage);
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistorySession {
+	    id: string;
+	    kind: string;
+	    title: string;
+	    prompt?: string;
+	    provider?: string;
+	    model?: string;
+	    messageCount: number;
+	    usage: TokenUsage;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    updatedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistorySession(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.title = source["title"];
+	        this.prompt = source["prompt"];
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.messageCount = source["messageCount"];
+	        this.usage = this.convertValues(source["usage"], TokenUsage);
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.updatedAt = this.convertValues(source["updatedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistoryConversation {
+	    session: HistorySession;
+	    messages: HistoryMessage[];
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryConversation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.session = this.convertValues(source["session"], HistorySession);
+	        this.messages = this.convertValues(source["messages"], HistoryMessage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistoryExportOptions {
+	    format: string;
+	    dir: string;
+	    sessionIds?: string[];
+	    // Go type: time
+	    from?: any;
+	    // Go type: time
+	    to?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryExportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.format = source["format"];
+	        this.dir = source["dir"];
+	        this.sessionIds = source["sessionIds"];
+	        this.from = this.convertValues(source["from"], null);
+	        this.to = this.convertValues(source["to"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class SnippetPart {
+	    text: string;
+	    match?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SnippetPart(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.match = source["match"];
+	    }
+	}
+	export class HistorySearchHit {
+	    sessionId: string;
+	    sessionTitle: string;
+	    kind: string;
+	    messageId: string;
+	    role: string;
+	    prompt?: string;
+	    provider?: string;
+	    model?: string;
+	    score: number;
+	    snippet: SnippetPart[];
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistorySearchHit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sessionId = source["sessionId"];
+	        this.sessionTitle = source["sessionTitle"];
+	        this.kind = source["kind"];
+	        this.messageId = source["messageId"];
+	        this.role = source["role"];
+	        this.prompt = source["prompt"];
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.score = source["score"];
+	        this.snippet = this.convertValues(source["snippet"], SnippetPart);
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistorySearchQuery {
+	    query: string;
+	    kind?: string;
+	    prompt?: string;
+	    provider?: string;
+	    // Go type: time
+	    from?: any;
+	    // Go type: time
+	    to?: any;
+	    offset?: number;
+	    limit?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistorySearchQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.query = source["query"];
+	        this.kind = source["kind"];
+	        this.prompt = source["prompt"];
+	        this.provider = source["provider"];
+	        this.from = this.convertValues(source["from"], null);
+	        this.to = this.convertValues(source["to"], null);
+	        this.offset = source["offset"];
+	        this.limit = source["limit"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistorySearchResult {
+	    total: number;
+	    hits: HistorySearchHit[];
+	
+	    static createFrom(source: any = {}) {
+	        return new HistorySearchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.total = source["total"];
+	        this.hits = this.convertValues(source["hits"], HistorySearchHit);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class OCRWord {
+	    text: string;
+	    confidence: number;
+	    x: number;
+	    y: number;
+	    width: number;
+	    height: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OCRWord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.confidence = source["confidence"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	}
+	export class OCRResult {
+	    text: string;
+	    words: OCRWord[];
+	    languages: string[];
+	    confidence: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OCRResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.words = this.convertValues(source["words"], OCRWord);
+	        this.languages = source["languages"];
+	        this.confidence = source["confidence"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
 	export class Prompt {
 	    act: string;
 	    prompt: string;
@@ -62,6 +779,93 @@ export namespace main {
 		    return a;
 		}
 	}
+	export class ProviderCapabilities {
+	    streaming: boolean;
+	    vision: boolean;
+	    local: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProviderCapabilities(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.streaming = source["streaming"];
+	        this.vision = source["vision"];
+	        this.local = source["local"];
+	    }
+	}
+	export class ProviderInfo {
+	    id: string;
+	    name: string;
+	    kind: string;
+	    baseUrl: string;
+	    defaultModel: string;
+	    visionModel?: string;
+	    models: string[];
+	    capabilities: ProviderCapabilities;
+	    hasApiKey: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProviderInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.kind = source["kind"];
+	        this.baseUrl = source["baseUrl"];
+	        this.defaultModel = source["defaultModel"];
+	        this.visionModel = source["visionModel"];
+	        this.models = source["models"];
+	        this.capabilities = this.convertValues(source["capabilities"], ProviderCapabilities);
+	        this.hasApiKey = source["hasApiKey"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	
+	
+	
+	export class VaultStatus {
+	    initialized: boolean;
+	    keySource: string;
+	    locked: boolean;
+	    encryptHistory: boolean;
+	    secrets: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new VaultStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.initialized = source["initialized"];
+	        this.keySource = source["keySource"];
+	        this.locked = source["locked"];
+	        this.encryptHistory = source["encryptHistory"];
+	        this.secrets = source["secrets"];
+	    }
+	}
 
 }
 
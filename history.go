@@ -0,0 +1,552 @@
+package main
+
+import (
+	"context"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	HistoryKindChat = "chat"
+	HistoryKindAsk  = "ask"
+
+	historyDBFile = "history.db"
+)
+
+var (
+	historySessionsBucket = []byte("sessions")
+	historyMessagesBucket = []byte("messages")
+	historyMetaBucket     = []byte("meta")
+
+	// historyImportedKey 记录 localStorage 历史的导入时间，保证只导入一次
+	historyImportedKey = []byte("localStorageImportedAt")
+)
+
+// ErrHistorySessionNotFound 会话不存在
+var ErrHistorySessionNotFound = errors.New("history session not found")
+
+// TokenUsage 一次回答或整个会话消耗的 token
+type TokenUsage struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	TotalTokens      int `json:"totalTokens"`
+}
+
+func (u *TokenUsage) add(other TokenUsage) {
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+}
+
+// HistorySession 一次对话（Chat 页的多轮对话，或 Ask 页的一问一答）
+type HistorySession struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"`
+	Title string `json:"title"`
+	// Prompt 会话使用的提示词，Provider/Model 为最近一次回答的提供方和模型
+	Prompt       string     `json:"prompt,omitempty"`
+	Provider     string     `json:"provider,omitempty"`
+	Model        string     `json:"model,omitempty"`
+	MessageCount int        `json:"messageCount"`
+	Usage        TokenUsage `json:"usage"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// HistoryMessage 会话中的一条消息，Role 为 user 或 assistant
+type HistoryMessage struct {
+	ID        string     `json:"id"`
+	SessionID string     `json:"sessionId"`
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Prompt    string     `json:"prompt,omitempty"`
+	Provider  string     `json:"provider,omitempty"`
+	Model     string     `json:"model,omitempty"`
+	Usage     TokenUsage `json:"usage"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// HistoryConversation 会话及其全部消息，历史页面一次读取
+type HistoryConversation struct {
+	Session  HistorySession   `json:"session"`
+	Messages []HistoryMessage `json:"messages"`
+}
+
+// HistoryService 基于 bbolt 的会话历史，数据库位于应用数据目录，不受 WebView 数据目录重置影响。
+// sessions 桶以会话 ID 为键；messages 桶下每个会话一个子桶，键为递增序号，保证消息按写入顺序读取
+type HistoryService struct {
+	BaseService
+	mu sync.Mutex
+	db *bolt.DB
+
+	// aead 为 vault 的数据密钥，encrypt 为 true 时记录加密保存
+	cipherMu sync.RWMutex
+	aead     cipher.AEAD
+	encrypt  bool
+
+	// index 全文搜索索引，第一次搜索时构建
+	indexMu sync.Mutex
+	index   *historyIndex
+}
+
+// NewHistoryService 创建新的历史服务并打开数据库；打开失败时服务仍可创建，各方法返回错误
+func NewHistoryService(ctx context.Context, app *App) *HistoryService {
+	service := &HistoryService{}
+	service.SetContext(ctx)
+	service.SetApp(app)
+	if err := service.open(); err != nil {
+		service.logSvc.Error("Failed to open history database: %v", err)
+	}
+	return service
+}
+
+func (h *HistoryService) open() error {
+	dir, err := h.AppDataDir()
+	if err != nil {
+		return fmt.Errorf("resolve data dir: %w", err)
+	}
+	if err := h.EnsureDirectory(dir); err != nil {
+		return fmt.Errorf("create data dir: %w", err)
+	}
+	path := h.JoinPath(dir, historyDBFile)
+	// 另一个实例持有文件锁时不要无限等待
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{historySessionsBucket, historyMessagesBucket, historyMetaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("init history buckets: %w", err)
+	}
+	h.mu.Lock()
+	h.db = db
+	h.mu.Unlock()
+	h.logSvc.Info("History database opened at %s", path)
+	return nil
+}
+
+// Close 关闭数据库
+func (h *HistoryService) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.db == nil {
+		return nil
+	}
+	err := h.db.Close()
+	h.db = nil
+	return err
+}
+
+func (h *HistoryService) database() (*bolt.DB, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.db == nil {
+		return nil, errors.New("history database is not open")
+	}
+	return h.db, nil
+}
+
+func (h *HistoryService) update(fn func(tx *bolt.Tx) error) error {
+	db, err := h.database()
+	if err != nil {
+		return err
+	}
+	return db.Update(fn)
+}
+
+func (h *HistoryService) view(fn func(tx *bolt.Tx) error) error {
+	db, err := h.database()
+	if err != nil {
+		return err
+	}
+	return db.View(fn)
+}
+
+func (h *HistoryService) getSession(tx *bolt.Tx, id string) (HistorySession, error) {
+	var session HistorySession
+	data := tx.Bucket(historySessionsBucket).Get([]byte(id))
+	if data == nil {
+		return session, fmt.Errorf("%w: %s", ErrHistorySessionNotFound, id)
+	}
+	if err := h.decodeJSON(data, &session); err != nil {
+		return session, fmt.Errorf("decode session %s: %w", id, err)
+	}
+	return session, nil
+}
+
+func (h *HistoryService) putJSON(bucket *bolt.Bucket, key []byte, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	h.cipherMu.RLock()
+	data, err = sealHistoryValue(h.aead, h.encrypt, data)
+	h.cipherMu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, data)
+}
+
+// decodeJSON 解码一条记录，加密的记录先用 vault 的数据密钥解密
+func (h *HistoryService) decodeJSON(data []byte, value interface{}) error {
+	h.cipherMu.RLock()
+	data, err := openHistoryValue(h.aead, data)
+	h.cipherMu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+// setCipher 由 VaultService 设置数据密钥，encrypt 为 true 时新写入的记录加密保存
+func (h *HistoryService) setCipher(aead cipher.AEAD, encrypt bool) {
+	h.cipherMu.Lock()
+	defer h.cipherMu.Unlock()
+	h.aead = aead
+	h.encrypt = encrypt
+}
+
+// reseal 按当前的加密设置重写全部会话和消息
+func (h *HistoryService) reseal() error {
+	return h.update(func(tx *bolt.Tx) error {
+		if err := h.resealBucket(tx.Bucket(historySessionsBucket)); err != nil {
+			return err
+		}
+		messages := tx.Bucket(historyMessagesBucket)
+		var sessionIDs [][]byte
+		if err := messages.ForEachBucket(func(k []byte) error {
+			sessionIDs = append(sessionIDs, append([]byte{}, k...))
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, id := range sessionIDs {
+			if err := h.resealBucket(messages.Bucket(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (h *HistoryService) resealBucket(bucket *bolt.Bucket) error {
+	// 遍历时不能修改桶，先取出全部记录
+	var keys [][]byte
+	var values []json.RawMessage
+	err := bucket.ForEach(func(k, v []byte) error {
+		if v == nil {
+			return nil
+		}
+		var value json.RawMessage
+		if err := h.decodeJSON(v, &value); err != nil {
+			return fmt.Errorf("decode %x: %w", k, err)
+		}
+		keys = append(keys, append([]byte{}, k...))
+		values = append(values, value)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, key := range keys {
+		if err := h.putJSON(bucket, key, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+// historyTitle 取第一行的前 60 个字符作为会话标题
+func historyTitle(content string) string {
+	title := strings.TrimSpace(content)
+	if i := strings.IndexByte(title, '\n'); i >= 0 {
+		title = strings.TrimSpace(title[:i])
+	}
+	if runes := []rune(title); len(runes) > 60 {
+		title = string(runes[:60]) + "…"
+	}
+	return title
+}
+
+// CreateSession 新建会话，Kind 为空时为 chat
+func (h *HistoryService) CreateSession(session HistorySession) (HistorySession, error) {
+	err := h.update(func(tx *bolt.Tx) error {
+		var err error
+		session, err = h.createSession(tx, session)
+		return err
+	})
+	if err != nil {
+		return session, fmt.Errorf("create session: %w", err)
+	}
+	return session, nil
+}
+
+func (h *HistoryService) createSession(tx *bolt.Tx, session HistorySession) (HistorySession, error) {
+	if session.Kind == "" {
+		session.Kind = HistoryKindChat
+	}
+	if session.Kind != HistoryKindChat && session.Kind != HistoryKindAsk {
+		return session, fmt.Errorf("unknown history kind %q", session.Kind)
+	}
+	session.ID = newRequestID()
+	session.MessageCount = 0
+	session.Usage = TokenUsage{}
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+	session.UpdatedAt = session.CreatedAt
+	if _, err := tx.Bucket(historyMessagesBucket).CreateBucket([]byte(session.ID)); err != nil {
+		return session, err
+	}
+	return session, h.putJSON(tx.Bucket(historySessionsBucket), []byte(session.ID), session)
+}
+
+// GetSession 返回会话
+func (h *HistoryService) GetSession(id string) (HistorySession, error) {
+	var session HistorySession
+	err := h.view(func(tx *bolt.Tx) error {
+		var err error
+		session, err = h.getSession(tx, id)
+		return err
+	})
+	return session, err
+}
+
+// ListSessions 按最近更新时间倒序列出会话，kind 为空时列出全部
+func (h *HistoryService) ListSessions(kind string) ([]HistorySession, error) {
+	sessions := []HistorySession{}
+	err := h.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(historySessionsBucket).ForEach(func(k, v []byte) error {
+			var session HistorySession
+			if err := h.decodeJSON(v, &session); err != nil {
+				return fmt.Errorf("decode session %s: %w", k, err)
+			}
+			if kind == "" || session.Kind == kind {
+				sessions = append(sessions, session)
+			}
+			return nil
+		})
+	})
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, err
+}
+
+// UpdateSession 修改会话的标题、提示词、提供方和模型，其余字段由服务维护
+func (h *HistoryService) UpdateSession(update HistorySession) (HistorySession, error) {
+	var session HistorySession
+	err := h.update(func(tx *bolt.Tx) error {
+		var err error
+		if session, err = h.getSession(tx, update.ID); err != nil {
+			return err
+		}
+		session.Title = update.Title
+		session.Prompt = update.Prompt
+		session.Provider = update.Provider
+		session.Model = update.Model
+		return h.putJSON(tx.Bucket(historySessionsBucket), []byte(session.ID), session)
+	})
+	if err == nil {
+		if index := h.loadedIndex(); index != nil {
+			index.putSession(session)
+		}
+	}
+	return session, err
+}
+
+// DeleteSession 删除会话及其全部消息
+func (h *HistoryService) DeleteSession(id string) error {
+	err := h.update(func(tx *bolt.Tx) error {
+		if _, err := h.getSession(tx, id); err != nil {
+			return err
+		}
+		if err := tx.Bucket(historyMessagesBucket).DeleteBucket([]byte(id)); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		return tx.Bucket(historySessionsBucket).Delete([]byte(id))
+	})
+	if err == nil {
+		h.unindexSession(id)
+	}
+	return err
+}
+
+// ClearSessions 删除某类会话，kind 为空时删除全部
+func (h *HistoryService) ClearSessions(kind string) error {
+	sessions, err := h.ListSessions(kind)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := h.DeleteSession(session.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddMessage 追加一条消息，并更新会话的消息数、token 用量、提供方和模型；
+// 会话还没有标题时用第一条用户消息生成
+func (h *HistoryService) AddMessage(sessionID string, message HistoryMessage) (HistoryMessage, error) {
+	// 提交和更新索引都在 indexMu 下完成；构建索引时同样持有 indexMu，
+	// 所以构建要么在提交前读完数据库、要么在索引更新后才开始，消息不会被索引两次
+	h.indexMu.Lock()
+	defer h.indexMu.Unlock()
+	err := h.update(func(tx *bolt.Tx) error {
+		var err error
+		message, err = h.addMessage(tx, sessionID, message)
+		return err
+	})
+	if err != nil {
+		return message, fmt.Errorf("add message: %w", err)
+	}
+	h.indexMessage(message)
+	return message, nil
+}
+
+func (h *HistoryService) addMessage(tx *bolt.Tx, sessionID string, message HistoryMessage) (HistoryMessage, error) {
+	if message.Role == "" {
+		return message, errors.New("message role is required")
+	}
+	session, err := h.getSession(tx, sessionID)
+	if err != nil {
+		return message, err
+	}
+	messages, err := tx.Bucket(historyMessagesBucket).CreateBucketIfNotExists([]byte(sessionID))
+	if err != nil {
+		return message, err
+	}
+	seq, err := messages.NextSequence()
+	if err != nil {
+		return message, err
+	}
+	message.ID = fmt.Sprintf("%s-%d", sessionID, seq)
+	message.SessionID = sessionID
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = time.Now()
+	}
+	if err := h.putJSON(messages, sequenceKey(seq), message); err != nil {
+		return message, err
+	}
+
+	session.MessageCount++
+	session.Usage.add(message.Usage)
+	if message.CreatedAt.After(session.UpdatedAt) {
+		session.UpdatedAt = message.CreatedAt
+	}
+	if session.Title == "" && message.Role == "user" {
+		session.Title = historyTitle(message.Content)
+	}
+	if message.Prompt != "" && session.Prompt == "" {
+		session.Prompt = message.Prompt
+	}
+	if message.Provider != "" {
+		session.Provider = message.Provider
+	}
+	if message.Model != "" {
+		session.Model = message.Model
+	}
+	return message, h.putJSON(tx.Bucket(historySessionsBucket), []byte(sessionID), session)
+}
+
+// Messages 按写入顺序返回会话的全部消息
+func (h *HistoryService) Messages(sessionID string) ([]HistoryMessage, error) {
+	messages := []HistoryMessage{}
+	err := h.view(func(tx *bolt.Tx) error {
+		if _, err := h.getSession(tx, sessionID); err != nil {
+			return err
+		}
+		bucket := tx.Bucket(historyMessagesBucket).Bucket([]byte(sessionID))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var message HistoryMessage
+			if err := h.decodeJSON(v, &message); err != nil {
+				return fmt.Errorf("decode message %s/%d: %w", sessionID, binary.BigEndian.Uint64(k), err)
+			}
+			messages = append(messages, message)
+			return nil
+		})
+	})
+	return messages, err
+}
+
+// Conversations 按最近更新时间倒序返回某类会话及其消息，kind 为空时返回全部
+func (h *HistoryService) Conversations(kind string) ([]HistoryConversation, error) {
+	sessions, err := h.ListSessions(kind)
+	if err != nil {
+		return nil, err
+	}
+	conversations := make([]HistoryConversation, 0, len(sessions))
+	for _, session := range sessions {
+		messages, err := h.Messages(session.ID)
+		if err != nil {
+			return nil, err
+		}
+		conversations = append(conversations, HistoryConversation{Session: session, Messages: messages})
+	}
+	return conversations, nil
+}
+
+// 以下为历史相关的前端绑定
+func (a *App) CreateHistorySession(session HistorySession) (HistorySession, error) {
+	return a.history().CreateSession(session)
+}
+
+func (a *App) GetHistorySession(id string) (HistorySession, error) {
+	return a.history().GetSession(id)
+}
+
+func (a *App) ListHistorySessions(kind string) ([]HistorySession, error) {
+	return a.history().ListSessions(kind)
+}
+
+func (a *App) UpdateHistorySession(session HistorySession) (HistorySession, error) {
+	return a.history().UpdateSession(session)
+}
+
+func (a *App) DeleteHistorySession(id string) error {
+	return a.history().DeleteSession(id)
+}
+
+func (a *App) ClearHistorySessions(kind string) error {
+	return a.history().ClearSessions(kind)
+}
+
+func (a *App) AddHistoryMessage(sessionID string, message HistoryMessage) (HistoryMessage, error) {
+	return a.history().AddMessage(sessionID, message)
+}
+
+func (a *App) GetHistoryMessages(sessionID string) ([]HistoryMessage, error) {
+	return a.history().Messages(sessionID)
+}
+
+func (a *App) ListHistoryConversations(kind string) ([]HistoryConversation, error) {
+	return a.history().Conversations(kind)
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	HistoryExportMarkdown = "markdown"
+	HistoryExportJSON     = "json"
+	HistoryExportHTML     = "html"
+
+	// historyExportVersion JSON 导出格式的版本，格式不兼容地变化时递增
+	historyExportVersion = 1
+)
+
+// HistoryExportOptions 导出选项。SessionIDs 非空时只导出这些会话，否则导出在 From/To 范围内有活动的会话，
+// 都为空时导出全部历史
+type HistoryExportOptions struct {
+	Format     string    `json:"format"`
+	Dir        string    `json:"dir"`
+	SessionIDs []string  `json:"sessionIds,omitempty"`
+	From       time.Time `json:"from,omitempty"`
+	To         time.Time `json:"to,omitempty"`
+}
+
+// HistoryExportSession 导出文件中的一个会话及其全部消息
+type HistoryExportSession struct {
+	HistorySession
+	Messages []HistoryMessage `json:"messages"`
+}
+
+// HistoryExport JSON 导出文件，保存会话和消息的全部字段，可以原样导入
+type HistoryExport struct {
+	Version    int                    `json:"version"`
+	ExportedAt time.Time              `json:"exportedAt"`
+	Sessions   []HistoryExportSession `json:"sessions"`
+}
+
+// exportSessions 按导出选项收集会话和消息，最新的在前
+func (h *HistoryService) exportSessions(options HistoryExportOptions) ([]HistoryExportSession, error) {
+	var sessions []HistorySession
+	if len(options.SessionIDs) > 0 {
+		for _, id := range options.SessionIDs {
+			session, err := h.GetSession(id)
+			if err != nil {
+				return nil, err
+			}
+			sessions = append(sessions, session)
+		}
+	} else {
+		all, err := h.ListSessions("")
+		if err != nil {
+			return nil, err
+		}
+		for _, session := range all {
+			if !options.From.IsZero() && session.UpdatedAt.Before(options.From) {
+				continue
+			}
+			if !options.To.IsZero() && session.CreatedAt.After(options.To) {
+				continue
+			}
+			sessions = append(sessions, session)
+		}
+	}
+
+	exported := make([]HistoryExportSession, 0, len(sessions))
+	for _, session := range sessions {
+		messages, err := h.Messages(session.ID)
+		if err != nil {
+			return nil, err
+		}
+		exported = append(exported, HistoryExportSession{HistorySession: session, Messages: messages})
+	}
+	return exported, nil
+}
+
+// Export 把会话导出到 options.Dir，返回写入的文件。JSON 导出为一个文件，Markdown 和 HTML 每个会话一个文件
+func (h *HistoryService) Export(options HistoryExportOptions) ([]string, error) {
+	if options.Dir == "" {
+		return nil, fmt.Errorf("export directory is required")
+	}
+	sessions, err := h.exportSessions(options)
+	if err != nil {
+		return nil, fmt.Errorf("export history: %w", err)
+	}
+	if err := h.EnsureDirectory(options.Dir); err != nil {
+		return nil, fmt.Errorf("create export directory: %w", err)
+	}
+
+	var files []string
+	write := func(name string, data []byte) error {
+		path := h.JoinPath(options.Dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+		files = append(files, path)
+		return nil
+	}
+
+	switch options.Format {
+	case HistoryExportJSON:
+		data, err := json.MarshalIndent(HistoryExport{Version: historyExportVersion, ExportedAt: time.Now(), Sessions: sessions}, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("encode history: %w", err)
+		}
+		if err := write("popask-history-"+h.FormatTimestamp()+".json", data); err != nil {
+			return nil, err
+		}
+	case HistoryExportMarkdown, HistoryExportHTML:
+		for _, session := range sessions {
+			var data []byte
+			ext := ".md"
+			if options.Format == HistoryExportHTML {
+				ext = ".html"
+				if data, err = sessionHTML(session); err != nil {
+					return files, fmt.Errorf("render session %s: %w", session.ID, err)
+				}
+			} else {
+				data = sessionMarkdown(session)
+			}
+			if err := write(exportFileName(session.HistorySession)+ext, data); err != nil {
+				return files, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown export format %q", options.Format)
+	}
+	h.logSvc.Info("Exported %d sessions as %s to %s", len(sessions), options.Format, options.Dir)
+	return files, nil
+}
+
+// exportFileName 由日期、标题和会话 ID 前缀组成文件名，如 2025-03-14-what-is-go-1a2b3c4d
+func exportFileName(session HistorySession) string {
+	var slug strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(session.Title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+		if slug.Len() >= 48 {
+			break
+		}
+	}
+	name := slug.String()
+	if name == "" {
+		name = session.Kind
+	}
+	id := session.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return session.CreatedAt.Format("2006-01-02") + "-" + name + "-" + id
+}
+
+// yamlString 生成 front matter 中的双引号字符串，JSON 字符串同时是合法的 YAML
+func yamlString(value string) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func roleLabel(role string) string {
+	if role == "" {
+		return role
+	}
+	return strings.ToUpper(role[:1]) + role[1:]
+}
+
+// sessionMarkdown 把会话渲染为带 front matter 的 Markdown，消息内容原样保留
+func sessionMarkdown(session HistoryExportSession) []byte {
+	var b bytes.Buffer
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %s\n", yamlString(session.Title))
+	fmt.Fprintf(&b, "kind: %s\n", session.Kind)
+	for _, field := range []struct{ key, value string }{
+		{"prompt", session.Prompt},
+		{"provider", session.Provider},
+		{"model", session.Model},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", field.key, yamlString(field.value))
+		}
+	}
+	fmt.Fprintf(&b, "created: %s\n", session.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "updated: %s\n", session.UpdatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "messages: %d\n", session.MessageCount)
+	if session.Usage.TotalTokens > 0 {
+		fmt.Fprintf(&b, "tokens: %d\n", session.Usage.TotalTokens)
+	}
+	b.WriteString("---\n\n")
+	if session.Title != "" {
+		fmt.Fprintf(&b, "# %s\n\n", session.Title)
+	}
+	for _, message := range session.Messages {
+		heading := roleLabel(message.Role)
+		if message.Model != "" {
+			heading += " (" + message.Model + ")"
+		}
+		fmt.Fprintf(&b, "### %s · %s\n\n", heading, message.CreatedAt.Format("2006-01-02 15:04"))
+		b.WriteString(strings.TrimSpace(message.Content))
+		b.WriteString("\n\n")
+	}
+	return bytes.TrimRight(b.Bytes(), "\n")
+}
+
+// sessionHTMLTemplate 导出的 HTML 页面，样式内联，不依赖外部资源
+var sessionHTMLTemplate = template.Must(template.New("session").Funcs(template.FuncMap{
+	"role": roleLabel,
+	"time": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if .Title}}{{.Title}}{{else}}PopAsk conversation{{end}}</title>
+<style>
+body { max-width: 820px; margin: 2rem auto; padding: 0 1rem; font: 15px/1.6 -apple-system, BlinkMacSystemFont, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; color: #1f1f1f; background: #fafafa; }
+header { border-bottom: 1px solid #e5e5e5; margin-bottom: 1.5rem; }
+header h1 { font-size: 1.4rem; margin: 0 0 .25rem; }
+.meta { color: #777; font-size: .85rem; margin: 0 0 1rem; }
+.message { background: #fff; border: 1px solid #e5e5e5; border-radius: 8px; padding: .75rem 1rem; margin-bottom: 1rem; }
+.message.user { background: #f0f6ff; border-color: #d6e6ff; }
+.message h2 { font-size: .85rem; color: #555; margin: 0 0 .5rem; font-weight: 600; }
+.content { white-space: pre-wrap; word-wrap: break-word; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{if .Title}}{{.Title}}{{else}}PopAsk conversation{{end}}</h1>
+<p class="meta">{{time .CreatedAt}}{{if .Provider}} · {{.Provider}}{{end}}{{if .Model}} · {{.Model}}{{end}}{{if .Usage.TotalTokens}} · {{.Usage.TotalTokens}} tokens{{end}}</p>
+{{if .Prompt}}<p class="meta">Prompt: {{.Prompt}}</p>{{end}}
+</header>
+{{range .Messages}}<section class="message {{.Role}}">
+<h2>{{role .Role}}{{if .Model}} ({{.Model}}){{end}} · {{time .CreatedAt}}</h2>
+<div class="content">{{.Content}}</div>
+</section>
+{{end}}</body>
+</html>
+`))
+
+func sessionHTML(session HistoryExportSession) ([]byte, error) {
+	var b bytes.Buffer
+	if err := sessionHTMLTemplate.Execute(&b, session); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// ImportJSON 导入 Export 生成的 JSON。会话和消息保持原有 ID 和时间；ID 已存在的会话跳过，
+// 因此重复导入同一个文件不会产生重复数据。返回新导入的会话数
+func (h *HistoryService) ImportJSON(data []byte) (int, error) {
+	var export HistoryExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return 0, fmt.Errorf("decode history export: %w", err)
+	}
+	if export.Version < 1 || export.Version > historyExportVersion {
+		return 0, fmt.Errorf("unsupported history export version %d", export.Version)
+	}
+	imported := 0
+	err := h.update(func(tx *bolt.Tx) error {
+		for _, session := range export.Sessions {
+			ok, err := h.restoreSession(tx, session)
+			if err != nil {
+				return err
+			}
+			if ok {
+				imported++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("import history: %w", err)
+	}
+	if imported > 0 {
+		h.resetSearchIndex()
+	}
+	h.logSvc.Info("Imported %d of %d sessions from history export", imported, len(export.Sessions))
+	return imported, nil
+}
+
+// ImportFile 导入 JSON 导出文件
+func (h *HistoryService) ImportFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %w", path, err)
+	}
+	return h.ImportJSON(data)
+}
+
+// restoreSession 按导出时的内容写回会话，会话已存在时返回 false
+func (h *HistoryService) restoreSession(tx *bolt.Tx, export HistoryExportSession) (bool, error) {
+	session := export.HistorySession
+	if session.ID == "" {
+		session.ID = newRequestID()
+	}
+	if session.Kind != HistoryKindChat && session.Kind != HistoryKindAsk {
+		return false, fmt.Errorf("session %s: unknown history kind %q", session.ID, session.Kind)
+	}
+	sessions := tx.Bucket(historySessionsBucket)
+	if sessions.Get([]byte(session.ID)) != nil {
+		return false, nil
+	}
+	messages, err := tx.Bucket(historyMessagesBucket).CreateBucketIfNotExists([]byte(session.ID))
+	if err != nil {
+		return false, err
+	}
+	for _, message := range export.Messages {
+		if message.Role == "" {
+			return false, fmt.Errorf("session %s: message role is required", session.ID)
+		}
+		// 保留原来的序号，使消息 ID 不变；无法解析时追加到最后
+		seq, err := strconv.ParseUint(strings.TrimPrefix(message.ID, session.ID+"-"), 10, 64)
+		if err != nil || seq == 0 || messages.Get(sequenceKey(seq)) != nil {
+			seq = messages.Sequence() + 1
+		}
+		if seq > messages.Sequence() {
+			if err := messages.SetSequence(seq); err != nil {
+				return false, err
+			}
+		}
+		message.ID = fmt.Sprintf("%s-%d", session.ID, seq)
+		message.SessionID = session.ID
+		if err := h.putJSON(messages, sequenceKey(seq), message); err != nil {
+			return false, err
+		}
+	}
+	return true, h.putJSON(sessions, []byte(session.ID), session)
+}
+
+// ExportHistory 导出历史，Dir 为空时弹出目录选择框；取消选择时返回空列表
+func (a *App) ExportHistory(options HistoryExportOptions) ([]string, error) {
+	if options.Dir == "" {
+		dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+			Title:                "Export history to",
+			CanCreateDirectories: true,
+		})
+		if err != nil || dir == "" {
+			return []string{}, err
+		}
+		options.Dir = dir
+	}
+	return a.history().Export(options)
+}
+
+// ImportHistory 导入 JSON 导出文件，path 为空时弹出文件选择框
+func (a *App) ImportHistory(path string) (int, error) {
+	if path == "" {
+		var err error
+		path, err = runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title:   "Import history",
+			Filters: []runtime.FileFilter{{DisplayName: "PopAsk history (*.json)", Pattern: "*.json"}},
+		})
+		if err != nil || path == "" {
+			return 0, err
+		}
+	}
+	return a.history().ImportFile(path)
+}
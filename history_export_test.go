@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func seedExportHistory(t *testing.T, h *HistoryService) HistorySession {
+	t.Helper()
+	created := time.Date(2025, 3, 14, 9, 26, 0, 0, time.Local)
+	session, err := h.CreateSession(HistorySession{Kind: HistoryKindAsk, Prompt: "Explain:\n", CreatedAt: created})
+	if err != nil {
+		t.Fatalf("CreateSession() error: %v", err)
+	}
+	h.AddMessage(session.ID, HistoryMessage{Role: "user", Content: "What is <b>Go</b>?", CreatedAt: created})
+	h.AddMessage(session.ID, HistoryMessage{Role: "assistant", Content: "A language.\n\n```go\nfmt.Println(\"hi\")\n```", Provider: "openai", Model: "gpt-4o", Usage: TokenUsage{TotalTokens: 12}, CreatedAt: created.Add(time.Minute)})
+
+	old, _ := h.CreateSession(HistorySession{CreatedAt: created.AddDate(-1, 0, 0)})
+	h.AddMessage(old.ID, HistoryMessage{Role: "user", Content: "old", CreatedAt: created.AddDate(-1, 0, 0)})
+	session, _ = h.GetSession(session.ID)
+	return session
+}
+
+func TestHistoryService_ExportMarkdownAndHTML(t *testing.T) {
+	h := newTestHistoryService(t)
+	session := seedExportHistory(t, h)
+	dir := t.TempDir()
+
+	files, err := h.Export(HistoryExportOptions{Format: HistoryExportMarkdown, Dir: dir, SessionIDs: []string{session.ID}})
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Export(markdown) = %v, %v", files, err)
+	}
+	if name := filepath.Base(files[0]); !strings.HasPrefix(name, "2025-03-14-what-is-b-go-b-") || !strings.HasSuffix(name, ".md") {
+		t.Errorf("markdown file name = %q", name)
+	}
+	data, _ := os.ReadFile(files[0])
+	markdown := string(data)
+	for _, want := range []string{
+		"---\ntitle: \"What is \\u003cb\\u003eGo\\u003c/b\\u003e?\"\nkind: ask\nprompt: \"Explain:\\n\"\nprovider: \"openai\"\nmodel: \"gpt-4o\"\n",
+		"created: " + session.CreatedAt.Format(time.RFC3339) + "\n",
+		"messages: 2\ntokens: 12\n---\n",
+		"### Assistant (gpt-4o) · 2025-03-14 09:27\n\nA language.\n\n```go\nfmt.Println(\"hi\")\n```",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown missing %q:\n%s", want, markdown)
+		}
+	}
+
+	files, err = h.Export(HistoryExportOptions{Format: HistoryExportHTML, Dir: dir, From: session.CreatedAt.Add(-time.Hour)})
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Export(html) with date range = %v, %v", files, err)
+	}
+	data, _ = os.ReadFile(files[0])
+	page := string(data)
+	if !strings.Contains(page, "What is &lt;b&gt;Go&lt;/b&gt;?") || strings.Contains(page, "<b>Go</b>") || strings.Contains(page, "<link") {
+		t.Errorf("html page not escaped or not self-contained:\n%s", page)
+	}
+
+	if _, err := h.Export(HistoryExportOptions{Format: "pdf", Dir: dir}); err == nil {
+		t.Error("Export() with unknown format should fail")
+	}
+}
+
+func TestHistoryService_ExportJSONRoundTrip(t *testing.T) {
+	source := newTestHistoryService(t)
+	seedExportHistory(t, source)
+	files, err := source.Export(HistoryExportOptions{Format: HistoryExportJSON, Dir: t.TempDir()})
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Export(json) = %v, %v", files, err)
+	}
+
+	target := newTestHistoryService(t)
+	imported, err := target.ImportFile(files[0])
+	if err != nil || imported != 2 {
+		t.Fatalf("ImportFile() = %d, %v; want 2 sessions", imported, err)
+	}
+	want, _ := source.exportSessions(HistoryExportOptions{})
+	got, _ := target.exportSessions(HistoryExportOptions{})
+	wantJSON, _ := json.Marshal(want)
+	gotJSON, _ := json.Marshal(got)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("round trip mismatch:\n got %s\nwant %s", gotJSON, wantJSON)
+	}
+
+	// 重复导入跳过已有会话，新消息继续使用后续序号
+	if imported, err := target.ImportFile(files[0]); err != nil || imported != 0 {
+		t.Errorf("second ImportFile() = %d, %v; want 0", imported, err)
+	}
+	message, err := target.AddMessage(got[0].ID, HistoryMessage{Role: "user", Content: "more"})
+	if err != nil || message.ID != got[0].ID+"-3" {
+		t.Errorf("AddMessage() after import = %q, %v", message.ID, err)
+	}
+	if _, err := target.ImportJSON([]byte(`{"version": 99, "sessions": []}`)); err == nil {
+		t.Error("ImportJSON() with newer version should fail")
+	}
+}
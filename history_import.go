@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// localStorageAsk 前端 historyList 中的一项（Ask 页的一问一答），timestamp 为 toLocaleString() 的结果
+type localStorageAsk struct {
+	Message   string      `json:"message"`
+	Response  interface{} `json:"response"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// localStorageChatMessage 前端 chatHistoryList 中一次对话的一条消息，timestamp 为毫秒时间戳
+type localStorageChatMessage struct {
+	Type      string      `json:"type"`
+	Content   interface{} `json:"content"`
+	Timestamp float64     `json:"timestamp"`
+}
+
+// localeTimeLayouts 常见系统语言下 toLocaleString() 的格式
+var localeTimeLayouts = []string{
+	"1/2/2006, 3:04:05 PM",
+	"2006/1/2 15:04:05",
+	"2/1/2006, 15:04:05",
+	"02/01/2006, 15:04:05",
+	"2.1.2006, 15:04:05",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+}
+
+func parseLocaleTime(value string) (time.Time, bool) {
+	// 部分语言的 toLocaleString() 使用窄不换行空格
+	value = strings.NewReplacer("\u202f", " ", "\u00a0", " ").Replace(strings.TrimSpace(value))
+	for _, layout := range localeTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// contentString 把前端消息内容转成文本，非字符串的回答按 JSON 保存
+func contentString(content interface{}) string {
+	switch c := content.(type) {
+	case nil:
+		return ""
+	case string, []interface{}:
+		return messageText(c)
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return fmt.Sprint(content)
+	}
+	return string(data)
+}
+
+func decodeLocalStorage(raw string, v interface{}) error {
+	if strings.TrimSpace(raw) == "" || strings.TrimSpace(raw) == "null" {
+		return nil
+	}
+	return json.Unmarshal([]byte(raw), v)
+}
+
+// ImportLocalStorage 把前端 localStorage 中的 historyList 和 chatHistoryList（原始 JSON 字符串）导入数据库。
+// 只在第一次调用时导入，之后直接返回 0；导入在一个事务中完成，失败时不留下部分数据
+func (h *HistoryService) ImportLocalStorage(historyList, chatHistoryList string) (int, error) {
+	var asks []localStorageAsk
+	if err := decodeLocalStorage(historyList, &asks); err != nil {
+		return 0, fmt.Errorf("decode historyList: %w", err)
+	}
+	var chats [][]localStorageChatMessage
+	if err := decodeLocalStorage(chatHistoryList, &chats); err != nil {
+		return 0, fmt.Errorf("decode chatHistoryList: %w", err)
+	}
+
+	imported := 0
+	err := h.update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(historyMetaBucket)
+		if meta.Get(historyImportedKey) != nil {
+			return nil
+		}
+		now := time.Now()
+		// 两个列表都是最新的在前；缺少可解析时间的记录按列表顺序往前排
+		for i, ask := range asks {
+			createdAt, ok := parseLocaleTime(ask.Timestamp)
+			if !ok {
+				createdAt = now.Add(-time.Duration(i) * time.Second)
+			}
+			session, err := h.createSession(tx, HistorySession{Kind: HistoryKindAsk, CreatedAt: createdAt})
+			if err != nil {
+				return err
+			}
+			if _, err := h.addMessage(tx, session.ID, HistoryMessage{Role: "user", Content: ask.Message, CreatedAt: createdAt}); err != nil {
+				return err
+			}
+			if _, err := h.addMessage(tx, session.ID, HistoryMessage{Role: "assistant", Content: contentString(ask.Response), CreatedAt: createdAt}); err != nil {
+				return err
+			}
+			imported++
+		}
+		for i, chat := range chats {
+			if len(chat) == 0 {
+				continue
+			}
+			fallback := now.Add(-time.Duration(i) * time.Second)
+			createdAt := fallback
+			if chat[0].Timestamp > 0 {
+				createdAt = time.UnixMilli(int64(chat[0].Timestamp))
+			}
+			session, err := h.createSession(tx, HistorySession{Kind: HistoryKindChat, CreatedAt: createdAt})
+			if err != nil {
+				return err
+			}
+			for _, message := range chat {
+				at := fallback
+				if message.Timestamp > 0 {
+					at = time.UnixMilli(int64(message.Timestamp))
+				}
+				role := message.Type
+				if role == "" {
+					role = "user"
+				}
+				if _, err := h.addMessage(tx, session.ID, HistoryMessage{Role: role, Content: contentString(message.Content), CreatedAt: at}); err != nil {
+					return err
+				}
+			}
+			imported++
+		}
+		return meta.Put(historyImportedKey, []byte(now.Format(time.RFC3339)))
+	})
+	if err != nil {
+		return 0, fmt.Errorf("import localStorage history: %w", err)
+	}
+	if imported > 0 {
+		h.resetSearchIndex()
+		h.logSvc.Info("Imported %d sessions from localStorage (%d ask, %d chat)", imported, len(asks), len(chats))
+	}
+	return imported, nil
+}
+
+func (a *App) ImportLocalStorageHistory(historyList, chatHistoryList string) (int, error) {
+	return a.history().ImportLocalStorage(historyList, chatHistoryList)
+}
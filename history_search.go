@@ -0,0 +1,694 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// BM25 参数
+	searchBM25K1 = 1.2
+	searchBM25B  = 0.75
+
+	defaultSearchLimit = 50
+	// snippetRadius 摘要中命中位置前后保留的字节数
+	snippetRadius = 80
+)
+
+// HistorySearchQuery 历史搜索条件。Query 中空格分隔的词需要全部命中，双引号括起来的为短语，
+// 以 - 开头的词表示排除；Query 为空时按时间倒序列出满足过滤条件的消息
+type HistorySearchQuery struct {
+	Query string `json:"query"`
+	Kind  string `json:"kind,omitempty"`
+	// Prompt 匹配会话或消息使用的提示词
+	Prompt   string    `json:"prompt,omitempty"`
+	Provider string    `json:"provider,omitempty"`
+	From     time.Time `json:"from,omitempty"`
+	To       time.Time `json:"to,omitempty"`
+	Offset   int       `json:"offset,omitempty"`
+	Limit    int       `json:"limit,omitempty"`
+}
+
+// SnippetPart 摘要的一段文本，Match 为 true 表示这段命中了搜索词
+type SnippetPart struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match,omitempty"`
+}
+
+// HistorySearchHit 一条命中的消息
+type HistorySearchHit struct {
+	SessionID    string        `json:"sessionId"`
+	SessionTitle string        `json:"sessionTitle"`
+	Kind         string        `json:"kind"`
+	MessageID    string        `json:"messageId"`
+	Role         string        `json:"role"`
+	Prompt       string        `json:"prompt,omitempty"`
+	Provider     string        `json:"provider,omitempty"`
+	Model        string        `json:"model,omitempty"`
+	Score        float64       `json:"score"`
+	Snippet      []SnippetPart `json:"snippet"`
+	CreatedAt    time.Time     `json:"createdAt"`
+}
+
+// HistorySearchResult 搜索结果，Total 为分页前的命中数
+type HistorySearchResult struct {
+	Total int                `json:"total"`
+	Hits  []HistorySearchHit `json:"hits"`
+}
+
+// textToken 分词结果，start/end 为在原文中的字节位置
+type textToken struct {
+	term       string
+	start, end int
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// tokenize 把文本切成小写的词；中日韩文字没有空格分词，连续的汉字按二元组切分，单个汉字单独成词
+func tokenize(text string) []textToken {
+	var tokens []textToken
+	wordStart := -1
+	flushWord := func(end int) {
+		if wordStart >= 0 {
+			tokens = append(tokens, textToken{strings.ToLower(text[wordStart:end]), wordStart, end})
+			wordStart = -1
+		}
+	}
+	cjkStart, cjkRunes := -1, 0
+	flushCJK := func(end int) {
+		if cjkStart < 0 {
+			return
+		}
+		if cjkRunes == 1 {
+			tokens = append(tokens, textToken{text[cjkStart:end], cjkStart, end})
+		}
+		for i := cjkStart; i < end; {
+			_, first := utf8.DecodeRuneInString(text[i:])
+			if i+first >= end {
+				break
+			}
+			_, second := utf8.DecodeRuneInString(text[i+first:])
+			tokens = append(tokens, textToken{text[i : i+first+second], i, i + first + second})
+			i += first
+		}
+		cjkStart, cjkRunes = -1, 0
+	}
+	for i, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord(i)
+			if cjkStart < 0 {
+				cjkStart = i
+			}
+			cjkRunes++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			flushCJK(i)
+			if wordStart < 0 {
+				wordStart = i
+			}
+		default:
+			flushWord(i)
+			flushCJK(i)
+		}
+	}
+	flushWord(len(text))
+	flushCJK(len(text))
+	return tokens
+}
+
+// searchClause 查询中的一项：单个词或短语（多个词需按顺序相邻出现）
+type searchClause struct {
+	terms  []string
+	negate bool
+}
+
+// parseSearchQuery 解析查询字符串。未加引号的连写词（如 foo-bar 或一串汉字）也按短语匹配
+func parseSearchQuery(query string) []searchClause {
+	var clauses []searchClause
+	addClause := func(text string, negate bool) {
+		tokens := tokenize(text)
+		if len(tokens) == 0 {
+			return
+		}
+		terms := make([]string, len(tokens))
+		for i, token := range tokens {
+			terms[i] = token.term
+		}
+		clauses = append(clauses, searchClause{terms: terms, negate: negate})
+	}
+	for i, segment := range strings.Split(query, `"`) {
+		// 引号之间的部分（奇数下标）为短语
+		if i%2 == 1 {
+			addClause(segment, false)
+			continue
+		}
+		for _, word := range strings.Fields(segment) {
+			negate := len(word) > 1 && word[0] == '-'
+			addClause(strings.TrimPrefix(word, "-"), negate)
+		}
+	}
+	return clauses
+}
+
+// indexedMessage 索引中的一条消息，不保存正文，摘要在命中后从数据库读取
+type indexedMessage struct {
+	sessionID string
+	seq       uint64
+	role      string
+	prompt    string
+	provider  string
+	model     string
+	createdAt time.Time
+	length    int
+	deleted   bool
+}
+
+// historyIndex 消息的内存倒排索引，记录每个词在每条消息中出现的位置以支持短语查询
+type historyIndex struct {
+	mu       sync.RWMutex
+	docs     []*indexedMessage
+	postings map[string]map[int][]int
+	sessions map[string]HistorySession
+	// bySession 每个会话包含的文档编号，删除会话时使用
+	bySession   map[string][]int
+	live        int
+	totalLength int
+}
+
+func newHistoryIndex() *historyIndex {
+	return &historyIndex{
+		postings:  map[string]map[int][]int{},
+		sessions:  map[string]HistorySession{},
+		bySession: map[string][]int{},
+	}
+}
+
+func (x *historyIndex) putSession(session HistorySession) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.sessions[session.ID] = session
+}
+
+func (x *historyIndex) add(message HistoryMessage, seq uint64) {
+	tokens := tokenize(message.Content)
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	id := len(x.docs)
+	x.docs = append(x.docs, &indexedMessage{
+		sessionID: message.SessionID,
+		seq:       seq,
+		role:      message.Role,
+		prompt:    message.Prompt,
+		provider:  message.Provider,
+		model:     message.Model,
+		createdAt: message.CreatedAt,
+		length:    len(tokens),
+	})
+	x.bySession[message.SessionID] = append(x.bySession[message.SessionID], id)
+	x.live++
+	x.totalLength += len(tokens)
+	for pos, token := range tokens {
+		docs := x.postings[token.term]
+		if docs == nil {
+			docs = map[int][]int{}
+			x.postings[token.term] = docs
+		}
+		docs[id] = append(docs[id], pos)
+	}
+}
+
+// removeSession 从索引中移除会话。文档只做删除标记，对应的倒排项在下次重建索引时清理
+func (x *historyIndex) removeSession(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, doc := range x.bySession[id] {
+		if !x.docs[doc].deleted {
+			x.docs[doc].deleted = true
+			x.live--
+			x.totalLength -= x.docs[doc].length
+		}
+	}
+	delete(x.bySession, id)
+	delete(x.sessions, id)
+}
+
+// stale 已删除的文档过多时需要重建索引以回收倒排项
+func (x *historyIndex) stale() bool {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.docs) > 2*x.live+1024
+}
+
+// matchTerm 返回包含 term 的文档及其出现位置。单个汉字查询时，匹配所有包含该字的二元组
+func (x *historyIndex) matchTerm(term string) map[int][]int {
+	if r, size := utf8.DecodeRuneInString(term); size == len(term) && isCJK(r) {
+		merged := map[int][]int{}
+		for other, docs := range x.postings {
+			if !strings.Contains(other, term) {
+				continue
+			}
+			for doc, positions := range docs {
+				merged[doc] = append(merged[doc], positions...)
+			}
+		}
+		for doc := range merged {
+			sort.Ints(merged[doc])
+		}
+		return merged
+	}
+	return x.postings[term]
+}
+
+// matchClause 返回满足子句的文档及每个词在文档中的词频
+func (x *historyIndex) matchClause(clause searchClause) map[int][]int {
+	postings := make([]map[int][]int, len(clause.terms))
+	for i, term := range clause.terms {
+		postings[i] = x.matchTerm(term)
+		if len(postings[i]) == 0 {
+			return nil
+		}
+	}
+	matched := map[int][]int{}
+	for doc, positions := range postings[0] {
+		freqs := make([]int, len(clause.terms))
+		if len(clause.terms) == 1 {
+			freqs[0] = len(positions)
+			matched[doc] = freqs
+			continue
+		}
+		for _, start := range positions {
+			if phraseAt(postings, doc, start) {
+				freqs[0]++
+			}
+		}
+		if freqs[0] == 0 {
+			continue
+		}
+		// 短语中每个词都按短语出现次数计分
+		for i := range freqs {
+			freqs[i] = freqs[0]
+		}
+		matched[doc] = freqs
+	}
+	return matched
+}
+
+func phraseAt(postings []map[int][]int, doc, start int) bool {
+	for i := 1; i < len(postings); i++ {
+		positions := postings[i][doc]
+		j := sort.SearchInts(positions, start+i)
+		if j == len(positions) || positions[j] != start+i {
+			return false
+		}
+	}
+	return true
+}
+
+func (x *historyIndex) filter(doc *indexedMessage, query HistorySearchQuery) bool {
+	if doc.deleted {
+		return false
+	}
+	session := x.sessions[doc.sessionID]
+	if query.Kind != "" && session.Kind != query.Kind {
+		return false
+	}
+	if query.Prompt != "" && doc.prompt != query.Prompt && session.Prompt != query.Prompt {
+		return false
+	}
+	if query.Provider != "" && !strings.EqualFold(doc.provider, query.Provider) && !strings.EqualFold(session.Provider, query.Provider) {
+		return false
+	}
+	if !query.From.IsZero() && doc.createdAt.Before(query.From) {
+		return false
+	}
+	if !query.To.IsZero() && doc.createdAt.After(query.To) {
+		return false
+	}
+	return true
+}
+
+// scoredDoc 打分后的文档
+type scoredDoc struct {
+	id    int
+	score float64
+}
+
+// search 返回按 BM25 得分（相同时按时间）倒序排列的全部命中
+func (x *historyIndex) search(query HistorySearchQuery, clauses []searchClause) []scoredDoc {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	var positive []searchClause
+	var negative []searchClause
+	for _, clause := range clauses {
+		if clause.negate {
+			negative = append(negative, clause)
+		} else {
+			positive = append(positive, clause)
+		}
+	}
+
+	scores := map[int]float64{}
+	if len(positive) == 0 {
+		for id, doc := range x.docs {
+			if x.filter(doc, query) {
+				scores[id] = 0
+			}
+		}
+	} else {
+		avgLength := 1.0
+		if x.live > 0 && x.totalLength > 0 {
+			avgLength = float64(x.totalLength) / float64(x.live)
+		}
+		for i, clause := range positive {
+			matched := x.matchClause(clause)
+			idf := x.idf(clause)
+			next := map[int]float64{}
+			for id, freqs := range matched {
+				doc := x.docs[id]
+				previous, ok := scores[id]
+				if i > 0 && !ok {
+					continue
+				}
+				if i == 0 && !x.filter(doc, query) {
+					continue
+				}
+				next[id] = previous + bm25(idf, freqs, doc.length, avgLength)
+			}
+			scores = next
+			if len(scores) == 0 {
+				return nil
+			}
+		}
+	}
+	for _, clause := range negative {
+		for id := range x.matchClause(clause) {
+			delete(scores, id)
+		}
+	}
+
+	results := make([]scoredDoc, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, scoredDoc{id, score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return x.docs[results[i].id].createdAt.After(x.docs[results[j].id].createdAt)
+	})
+	return results
+}
+
+// idf 返回子句中每个词的逆文档频率
+func (x *historyIndex) idf(clause searchClause) []float64 {
+	n := float64(x.live)
+	idf := make([]float64, len(clause.terms))
+	for i, term := range clause.terms {
+		df := float64(len(x.matchTerm(term)))
+		idf[i] = math.Log(1 + (n-df+0.5)/(df+0.5))
+	}
+	return idf
+}
+
+func bm25(idf []float64, freqs []int, length int, avgLength float64) float64 {
+	score := 0.0
+	for i, tf := range freqs {
+		tf := float64(tf)
+		score += idf[i] * tf * (searchBM25K1 + 1) / (tf + searchBM25K1*(1-searchBM25B+searchBM25B*float64(length)/avgLength))
+	}
+	return score
+}
+
+// highlightSnippet 截取第一个命中附近的文本，并标出所有命中的词
+func highlightSnippet(content string, clauses []searchClause) []SnippetPart {
+	terms := map[string]bool{}
+	var chars []string
+	for _, clause := range clauses {
+		if clause.negate {
+			continue
+		}
+		for _, term := range clause.terms {
+			terms[term] = true
+			if r, size := utf8.DecodeRuneInString(term); size == len(term) && isCJK(r) {
+				chars = append(chars, term)
+			}
+		}
+	}
+	// match 返回词中需要高亮的部分；单个汉字只高亮二元组中的这个字
+	match := func(token textToken) (int, int, bool) {
+		if terms[token.term] {
+			return token.start, token.end, true
+		}
+		for _, char := range chars {
+			if i := strings.Index(token.term, char); i >= 0 {
+				return token.start + i, token.start + i + len(char), true
+			}
+		}
+		return 0, 0, false
+	}
+
+	// 合并重叠（汉字二元组）或只隔着空白（短语）的命中区间
+	var ranges [][2]int
+	for _, token := range tokenize(content) {
+		from, to, ok := match(token)
+		if !ok {
+			continue
+		}
+		if n := len(ranges); n > 0 && strings.TrimSpace(content[min(from, ranges[n-1][1]):from]) == "" {
+			ranges[n-1][1] = max(ranges[n-1][1], to)
+			continue
+		}
+		ranges = append(ranges, [2]int{from, to})
+	}
+
+	start, end := 0, len(content)
+	if len(ranges) > 0 {
+		start = max(0, ranges[0][0]-snippetRadius)
+	}
+	if end-start > 3*snippetRadius {
+		end = start + 3*snippetRadius
+	}
+	for start > 0 && !utf8.RuneStart(content[start]) {
+		start--
+	}
+	for end < len(content) && !utf8.RuneStart(content[end]) {
+		end++
+	}
+	// 尽量不在单词中间截断
+	if start > 0 && len(ranges) > 0 {
+		if i := strings.IndexFunc(content[start:ranges[0][0]], unicode.IsSpace); i >= 0 {
+			start += i
+		}
+	}
+	if end < len(content) {
+		if i := strings.LastIndexFunc(content[start:end], unicode.IsSpace); i >= 0 && (len(ranges) == 0 || start+i >= ranges[0][1]) {
+			end = start + i
+		}
+	}
+
+	var parts []SnippetPart
+	appendPart := func(text string, match bool) {
+		if text = whitespaceRun.ReplaceAllString(text, " "); text != "" {
+			parts = append(parts, SnippetPart{Text: text, Match: match})
+		}
+	}
+	cursor := start
+	for _, r := range ranges {
+		if r[1] <= start || r[0] >= end {
+			continue
+		}
+		from, to := max(r[0], start), min(r[1], end)
+		appendPart(content[cursor:from], false)
+		appendPart(content[from:to], true)
+		cursor = to
+	}
+	appendPart(content[cursor:end], false)
+	if n := len(parts); n > 0 {
+		parts[0].Text = strings.TrimLeft(parts[0].Text, " ")
+		parts[n-1].Text = strings.TrimRight(parts[n-1].Text, " ")
+	}
+	if len(parts) > 0 && start > 0 {
+		parts = append([]SnippetPart{{Text: "…"}}, parts...)
+	}
+	if end < len(content) {
+		parts = append(parts, SnippetPart{Text: "…"})
+	}
+	return parts
+}
+
+// searchIndex 返回搜索索引，第一次使用时从数据库构建
+func (h *HistoryService) searchIndex() (*historyIndex, error) {
+	h.indexMu.Lock()
+	defer h.indexMu.Unlock()
+	if h.index != nil {
+		return h.index, nil
+	}
+	index, err := h.buildIndex()
+	if err != nil {
+		return nil, err
+	}
+	h.index = index
+	return index, nil
+}
+
+// buildIndex 从数据库构建索引，调用方持有 indexMu
+func (h *HistoryService) buildIndex() (*historyIndex, error) {
+	index := newHistoryIndex()
+	started := time.Now()
+	err := h.view(func(tx *bolt.Tx) error {
+		messagesBucket := tx.Bucket(historyMessagesBucket)
+		return tx.Bucket(historySessionsBucket).ForEach(func(k, v []byte) error {
+			var session HistorySession
+			if err := h.decodeJSON(v, &session); err != nil {
+				return fmt.Errorf("decode session %s: %w", k, err)
+			}
+			index.putSession(session)
+			bucket := messagesBucket.Bucket(k)
+			if bucket == nil {
+				return nil
+			}
+			return bucket.ForEach(func(key, value []byte) error {
+				var message HistoryMessage
+				if err := h.decodeJSON(value, &message); err != nil {
+					return fmt.Errorf("decode message %s/%d: %w", k, binary.BigEndian.Uint64(key), err)
+				}
+				index.add(message, binary.BigEndian.Uint64(key))
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("build search index: %w", err)
+	}
+	h.logSvc.Info("History search index built: %d messages, %d terms in %v", index.live, len(index.postings), time.Since(started))
+	return index, nil
+}
+
+// loadedIndex 返回已经构建的索引，尚未构建时返回 nil（之后第一次搜索会从数据库完整构建）
+func (h *HistoryService) loadedIndex() *historyIndex {
+	h.indexMu.Lock()
+	defer h.indexMu.Unlock()
+	return h.index
+}
+
+// resetSearchIndex 丢弃索引，下次搜索时重建
+func (h *HistoryService) resetSearchIndex() {
+	h.indexMu.Lock()
+	defer h.indexMu.Unlock()
+	h.index = nil
+}
+
+// unindexSession 删除会话后更新索引
+func (h *HistoryService) unindexSession(id string) {
+	if index := h.loadedIndex(); index != nil {
+		index.removeSession(id)
+		if index.stale() {
+			h.resetSearchIndex()
+		}
+	}
+}
+
+// indexMessage 写入消息后更新索引，调用方持有 indexMu
+func (h *HistoryService) indexMessage(message HistoryMessage) {
+	index := h.index
+	if index == nil {
+		return
+	}
+	seq, err := strconv.ParseUint(message.ID[strings.LastIndexByte(message.ID, '-')+1:], 10, 64)
+	if err != nil {
+		h.index = nil
+		return
+	}
+	if session, err := h.GetSession(message.SessionID); err == nil {
+		index.putSession(session)
+	}
+	index.add(message, seq)
+}
+
+// Search 全文搜索所有会话的消息
+func (h *HistoryService) Search(query HistorySearchQuery) (HistorySearchResult, error) {
+	result := HistorySearchResult{Hits: []HistorySearchHit{}}
+	index, err := h.searchIndex()
+	if err != nil {
+		return result, err
+	}
+	clauses := parseSearchQuery(query.Query)
+	if strings.TrimSpace(query.Query) != "" && len(clauses) == 0 {
+		return result, nil
+	}
+	docs := index.search(query, clauses)
+	result.Total = len(docs)
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	offset := min(max(query.Offset, 0), len(docs))
+	docs = docs[offset:min(offset+limit, len(docs))]
+
+	index.mu.RLock()
+	selected := make([]indexedMessage, len(docs))
+	sessions := make([]HistorySession, len(docs))
+	for i, doc := range docs {
+		selected[i] = *index.docs[doc.id]
+		sessions[i] = index.sessions[selected[i].sessionID]
+	}
+	index.mu.RUnlock()
+
+	err = h.view(func(tx *bolt.Tx) error {
+		messagesBucket := tx.Bucket(historyMessagesBucket)
+		for i, doc := range selected {
+			bucket := messagesBucket.Bucket([]byte(doc.sessionID))
+			if bucket == nil {
+				continue
+			}
+			data := bucket.Get(sequenceKey(doc.seq))
+			if data == nil {
+				continue
+			}
+			var message HistoryMessage
+			if err := h.decodeJSON(data, &message); err != nil {
+				return fmt.Errorf("decode message %s/%d: %w", doc.sessionID, doc.seq, err)
+			}
+			prompt := message.Prompt
+			if prompt == "" {
+				prompt = sessions[i].Prompt
+			}
+			result.Hits = append(result.Hits, HistorySearchHit{
+				SessionID:    doc.sessionID,
+				SessionTitle: sessions[i].Title,
+				Kind:         sessions[i].Kind,
+				MessageID:    message.ID,
+				Role:         message.Role,
+				Prompt:       prompt,
+				Provider:     message.Provider,
+				Model:        message.Model,
+				Score:        docs[i].score,
+				Snippet:      highlightSnippet(message.Content, clauses),
+				CreatedAt:    message.CreatedAt,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("search history: %w", err)
+	}
+	return result, nil
+}
+
+func (a *App) SearchHistory(query HistorySearchQuery) (HistorySearchResult, error) {
+	return a.history().Search(query)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	var terms []string
+	for _, token := range tokenize("Hello, World! 数据库索引 and 字 v2.0") {
+		terms = append(terms, token.term)
+	}
+	want := []string{"hello", "world", "数据", "据库", "库索", "索引", "and", "字", "v2", "0"}
+	if !reflect.DeepEqual(terms, want) {
+		t.Errorf("tokenize() = %q, want %q", terms, want)
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	got := parseSearchQuery(`go "error handling" -java 中文`)
+	want := []searchClause{
+		{terms: []string{"go"}},
+		{terms: []string{"error", "handling"}},
+		{terms: []string{"java"}, negate: true},
+		{terms: []string{"中文"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSearchQuery() = %+v, want %+v", got, want)
+	}
+}
+
+func snippetText(parts []SnippetPart) string {
+	var b strings.Builder
+	for _, part := range parts {
+		if part.Match {
+			b.WriteString("[" + part.Text + "]")
+		} else {
+			b.WriteString(part.Text)
+		}
+	}
+	return b.String()
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		query   string
+		want    string
+	}{
+		{"latin words", "Use errors.Is for\nerror handling in Go.", `"error handling" go`, "Use errors.Is for [error handling] in [Go]."},
+		{"cjk bigrams merge", "我们用数据库索引加速查询", "数据库", "我们用[数据库]索引加速查询"},
+		{"single cjk char", "一个字", "字", "一个[字]"},
+		{
+			"long content is trimmed",
+			strings.Repeat("lorem ", 40) + "needle " + strings.Repeat("ipsum ", 60),
+			"needle",
+			"…" + strings.Repeat("lorem ", 13) + "[needle] " + strings.TrimSpace(strings.Repeat("ipsum ", 25)) + "…",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := snippetText(highlightSnippet(tt.content, parseSearchQuery(tt.query)))
+			if got != tt.want {
+				t.Errorf("highlightSnippet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryService_Search(t *testing.T) {
+	h := newTestHistoryService(t)
+	day := time.Date(2025, 3, 14, 9, 0, 0, 0, time.Local)
+
+	translate, _ := h.CreateSession(HistorySession{Kind: HistoryKindAsk, Prompt: "Translate:\n", CreatedAt: day})
+	h.AddMessage(translate.ID, HistoryMessage{Role: "user", Content: "How do I handle errors in Go?", CreatedAt: day})
+	h.AddMessage(translate.ID, HistoryMessage{Role: "assistant", Content: "在 Go 中使用 error handling，返回错误值。", Provider: "openai", CreatedAt: day})
+
+	chat, _ := h.CreateSession(HistorySession{CreatedAt: day.AddDate(0, 0, 2)})
+	h.AddMessage(chat.ID, HistoryMessage{Role: "user", Content: "Handling errors: errors handling errors everywhere", CreatedAt: day.AddDate(0, 0, 2)})
+	h.AddMessage(chat.ID, HistoryMessage{Role: "assistant", Content: "Java exceptions differ from Go errors.", Provider: "gemini", CreatedAt: day.AddDate(0, 0, 2)})
+
+	search := func(query HistorySearchQuery) []string {
+		t.Helper()
+		result, err := h.Search(query)
+		if err != nil {
+			t.Fatalf("Search(%+v) error: %v", query, err)
+		}
+		if result.Total < len(result.Hits) {
+			t.Errorf("Search(%+v) total %d < %d hits", query, result.Total, len(result.Hits))
+		}
+		var contents []string
+		for _, hit := range result.Hits {
+			contents = append(contents, snippetText(hit.Snippet))
+		}
+		return contents
+	}
+
+	if got := search(HistorySearchQuery{Query: "errors"}); len(got) != 3 || !strings.HasPrefix(got[0], "Handling [errors]") {
+		t.Errorf("errors = %q, want 3 hits ranked by term frequency", got)
+	}
+	if got := search(HistorySearchQuery{Query: `"error handling"`}); len(got) != 1 || !strings.Contains(got[0], "[error handling]") {
+		t.Errorf("phrase = %q", got)
+	}
+	if got := search(HistorySearchQuery{Query: "errors -java"}); len(got) != 2 {
+		t.Errorf("negation = %q", got)
+	}
+	if got := search(HistorySearchQuery{Query: "错误"}); len(got) != 1 || !strings.Contains(got[0], "[错误]") {
+		t.Errorf("cjk = %q", got)
+	}
+	if got := search(HistorySearchQuery{Query: "go", Provider: "gemini"}); len(got) != 1 {
+		t.Errorf("provider filter = %q", got)
+	}
+	if got := search(HistorySearchQuery{Query: "errors", Prompt: "Translate:\n"}); len(got) != 1 {
+		t.Errorf("prompt filter = %q", got)
+	}
+	if got := search(HistorySearchQuery{Query: "errors", From: day.AddDate(0, 0, 1)}); len(got) != 2 {
+		t.Errorf("date filter = %q", got)
+	}
+	if got := search(HistorySearchQuery{Kind: HistoryKindAsk}); len(got) != 2 {
+		t.Errorf("filters only = %q", got)
+	}
+	if got := search(HistorySearchQuery{Query: "errors", Offset: 1, Limit: 1}); len(got) != 1 {
+		t.Errorf("paging = %q", got)
+	}
+
+	// 索引建立后的写入和删除同步到索引
+	h.AddMessage(chat.ID, HistoryMessage{Role: "user", Content: "What about panics?"})
+	if got := search(HistorySearchQuery{Query: "panics"}); len(got) != 1 {
+		t.Errorf("after add = %q", got)
+	}
+	if err := h.DeleteSession(chat.ID); err != nil {
+		t.Fatalf("DeleteSession() error: %v", err)
+	}
+	if got := search(HistorySearchQuery{Query: "errors"}); len(got) != 1 {
+		t.Errorf("after delete = %q", got)
+	}
+}
+
+func TestHistoryService_indexWhileAdding(t *testing.T) {
+	h := newTestHistoryService(t)
+	session, err := h.CreateSession(HistorySession{})
+	if err != nil {
+		t.Fatalf("CreateSession() error: %v", err)
+	}
+
+	// 模拟一次正在进行的构建：持有 indexMu 时写入的消息不能提交，
+	// 否则构建会读到它，写入完成后又增量加入一次
+	h.indexMu.Lock()
+	added := make(chan error, 1)
+	go func() {
+		_, err := h.AddMessage(session.ID, HistoryMessage{Role: "user", Content: "needle"})
+		added <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	index, err := h.buildIndex()
+	if err != nil {
+		h.indexMu.Unlock()
+		t.Fatalf("buildIndex() error: %v", err)
+	}
+	h.index = index
+	h.indexMu.Unlock()
+	if err := <-added; err != nil {
+		t.Fatalf("AddMessage() error: %v", err)
+	}
+
+	index.mu.RLock()
+	live := index.live
+	index.mu.RUnlock()
+	if live != 1 {
+		t.Errorf("index has %d messages, want the message indexed once", live)
+	}
+	if result, err := h.Search(HistorySearchQuery{Query: "needle"}); err != nil || result.Total != 1 {
+		t.Errorf("Search() total = %d, err %v; want 1", result.Total, err)
+	}
+}
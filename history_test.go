@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestHistoryService(t *testing.T) *HistoryService {
+	t.Helper()
+	t.Setenv(DataDirEnv, t.TempDir())
+	h := NewHistoryService(context.Background(), NewApp())
+	if _, err := h.database(); err != nil {
+		t.Fatalf("history database not open: %v", err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+func TestHistoryService_sessionsAndMessages(t *testing.T) {
+	h := newTestHistoryService(t)
+
+	older, err := h.CreateSession(HistorySession{Kind: HistoryKindAsk, CreatedAt: time.Now().Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("CreateSession() error: %v", err)
+	}
+	chat, err := h.CreateSession(HistorySession{Prompt: "Translate:\n"})
+	if err != nil {
+		t.Fatalf("CreateSession() error: %v", err)
+	}
+	if chat.Kind != HistoryKindChat {
+		t.Errorf("default kind = %q, want chat", chat.Kind)
+	}
+	if _, err := h.CreateSession(HistorySession{Kind: "other"}); err == nil {
+		t.Error("CreateSession() with unknown kind should fail")
+	}
+
+	messages := []HistoryMessage{
+		{Role: "user", Content: "first line\nsecond line"},
+		{Role: "assistant", Content: "answer", Provider: "openai", Model: "gpt-4o-mini", Usage: TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+		{Role: "user", Content: "follow up"},
+		{Role: "assistant", Content: "again", Provider: "openai", Model: "gpt-4o", Usage: TokenUsage{PromptTokens: 20, CompletionTokens: 7, TotalTokens: 27}},
+	}
+	for _, message := range messages {
+		if _, err := h.AddMessage(chat.ID, message); err != nil {
+			t.Fatalf("AddMessage() error: %v", err)
+		}
+	}
+	if _, err := h.AddMessage(chat.ID, HistoryMessage{Content: "no role"}); err == nil {
+		t.Error("AddMessage() without role should fail")
+	}
+
+	got, err := h.Messages(chat.ID)
+	if err != nil || len(got) != len(messages) {
+		t.Fatalf("Messages() = %d messages, err %v", len(got), err)
+	}
+	for i, message := range got {
+		if message.Content != messages[i].Content || message.SessionID != chat.ID {
+			t.Errorf("message %d = %+v, want content %q", i, message, messages[i].Content)
+		}
+	}
+
+	session, err := h.GetSession(chat.ID)
+	if err != nil {
+		t.Fatalf("GetSession() error: %v", err)
+	}
+	if session.Title != "first line" || session.MessageCount != 4 || session.Model != "gpt-4o" || session.Prompt != "Translate:\n" {
+		t.Errorf("session = %+v", session)
+	}
+	if session.Usage != (TokenUsage{PromptTokens: 30, CompletionTokens: 12, TotalTokens: 42}) {
+		t.Errorf("session usage = %+v", session.Usage)
+	}
+
+	list, err := h.ListSessions("")
+	if err != nil || len(list) != 2 || list[0].ID != chat.ID || list[1].ID != older.ID {
+		t.Errorf("ListSessions() = %+v, err %v; want newest first", list, err)
+	}
+	if asks, _ := h.ListSessions(HistoryKindAsk); len(asks) != 1 {
+		t.Errorf("ListSessions(ask) = %d sessions, want 1", len(asks))
+	}
+	if chats, err := h.Conversations(HistoryKindChat); err != nil || len(chats) != 1 || len(chats[0].Messages) != 4 || chats[0].Session.ID != chat.ID {
+		t.Errorf("Conversations(chat) = %+v, err %v; want the chat with its 4 messages", chats, err)
+	}
+
+	renamed, err := h.UpdateSession(HistorySession{ID: chat.ID, Title: "Renamed", Model: "gpt-4o"})
+	if err != nil || renamed.Title != "Renamed" || renamed.MessageCount != 4 {
+		t.Errorf("UpdateSession() = %+v, err %v", renamed, err)
+	}
+
+	if err := h.DeleteSession(chat.ID); err != nil {
+		t.Fatalf("DeleteSession() error: %v", err)
+	}
+	if _, err := h.Messages(chat.ID); !errors.Is(err, ErrHistorySessionNotFound) {
+		t.Errorf("Messages() after delete error = %v, want not found", err)
+	}
+	if err := h.ClearSessions(""); err != nil {
+		t.Fatalf("ClearSessions() error: %v", err)
+	}
+	if list, _ := h.ListSessions(""); len(list) != 0 {
+		t.Errorf("ListSessions() after clear = %d sessions", len(list))
+	}
+}
+
+func TestHistoryService_persistsAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(DataDirEnv, dir)
+	h := NewHistoryService(context.Background(), NewApp())
+	session, err := h.CreateSession(HistorySession{})
+	if err != nil {
+		t.Fatalf("CreateSession() error: %v", err)
+	}
+	h.AddMessage(session.ID, HistoryMessage{Role: "user", Content: "hello"})
+	h.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, historyDBFile)); err != nil {
+		t.Fatalf("history database not created in data dir: %v", err)
+	}
+	h = NewHistoryService(context.Background(), NewApp())
+	defer h.Close()
+	messages, err := h.Messages(session.ID)
+	if err != nil || len(messages) != 1 || messages[0].Content != "hello" {
+		t.Errorf("Messages() after reopen = %+v, err %v", messages, err)
+	}
+}
+
+func TestHistoryService_ImportLocalStorage(t *testing.T) {
+	h := newTestHistoryService(t)
+	historyList := `[
+		{"message": "What is Go?", "response": "A language.", "timestamp": "3/14/2025, 9:26:53 AM"},
+		{"message": "Unknown time", "response": {"text": "structured"}, "timestamp": "yesterday"}
+	]`
+	chatHistoryList := `[
+		[
+			{"id": 1741944413000, "type": "user", "content": "hi", "timestamp": 1741944413000},
+			{"id": 1741944414000, "type": "assistant", "content": "hello", "timestamp": 1741944414000}
+		],
+		[]
+	]`
+
+	imported, err := h.ImportLocalStorage(historyList, chatHistoryList)
+	if err != nil || imported != 3 {
+		t.Fatalf("ImportLocalStorage() = %d, %v; want 3 sessions", imported, err)
+	}
+	asks, _ := h.ListSessions(HistoryKindAsk)
+	chats, _ := h.ListSessions(HistoryKindChat)
+	if len(asks) != 2 || len(chats) != 1 {
+		t.Fatalf("imported %d ask and %d chat sessions", len(asks), len(chats))
+	}
+	want := time.Date(2025, 3, 14, 9, 26, 53, 0, time.Local)
+	var found bool
+	for _, ask := range asks {
+		if ask.Title == "What is Go?" {
+			found = true
+			if !ask.CreatedAt.Equal(want) || ask.MessageCount != 2 {
+				t.Errorf("ask session = %+v, want created at %v", ask, want)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("ask sessions = %+v", asks)
+	}
+	messages, _ := h.Messages(chats[0].ID)
+	if len(messages) != 2 || messages[1].Role != "assistant" || !messages[0].CreatedAt.Equal(time.UnixMilli(1741944413000)) {
+		t.Errorf("chat messages = %+v", messages)
+	}
+
+	// 第二次启动时不再重复导入
+	if imported, err := h.ImportLocalStorage(historyList, chatHistoryList); err != nil || imported != 0 {
+		t.Errorf("second ImportLocalStorage() = %d, %v; want 0", imported, err)
+	}
+	if _, err := h.ImportLocalStorage("{", ""); err == nil {
+		t.Error("ImportLocalStorage() with invalid JSON should fail")
+	}
+}
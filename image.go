@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// imageJPEGQualities PNG 仍然超过 DefaultMaxImageBytes 时依次尝试的 JPEG 质量
+var imageJPEGQualities = []int{85, 70, 55, 40}
+
+// imageMinDimension 为满足体积限制继续缩小时，长边不会小于这个值
+const imageMinDimension = 256
+
+// parseDataURL 解析 data:<mediaType>;base64,<data> 格式的图片
+func parseDataURL(dataURL string) (string, []byte, error) {
+	header, payload, ok := strings.Cut(dataURL, ",")
+	if !ok || !strings.HasPrefix(header, "data:") || !strings.HasSuffix(header, ";base64") {
+		return "", nil, fmt.Errorf("invalid image data URL")
+	}
+	mediaType := strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64")
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("decode image data URL: %w", err)
+	}
+	return mediaType, data, nil
+}
+
+func encodeDataURL(mediaType string, data []byte) string {
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// prepareImage 在上传给视觉模型前处理截图：尺寸和体积都在限制内时原样返回，
+// 否则按长边缩小到 DefaultMaxImageDimension 后重新编码；仍然超过 DefaultMaxImageBytes 时
+// 依次降低 JPEG 质量，再每次把长边缩小四分之一，直到满足体积限制
+func prepareImage(dataURL string) (string, error) {
+	_, data, err := parseDataURL(dataURL)
+	if err != nil {
+		return "", err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("decode image config: %w", err)
+	}
+	limits := currentLimits()
+	if max(config.Width, config.Height) <= limits.MaxImageDimension && len(data) <= limits.MaxImageBytes {
+		return dataURL, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("decode image: %w", err)
+	}
+	dimension := min(max(config.Width, config.Height), limits.MaxImageDimension)
+	for {
+		encoded, mediaType, err := encodeWithinBudget(scaleImage(src, dimension), limits.MaxImageBytes)
+		if err != nil {
+			return "", err
+		}
+		if encoded != nil {
+			return encodeDataURL(mediaType, encoded), nil
+		}
+		if dimension <= imageMinDimension {
+			return "", fmt.Errorf("image exceeds %d bytes even at %dpx", limits.MaxImageBytes, dimension)
+		}
+		dimension = max(imageMinDimension, dimension*3/4)
+	}
+}
+
+// encodeWithinBudget 先尝试 PNG，再按 imageJPEGQualities 尝试 JPEG，都超过 maxBytes 时返回 nil
+func encodeWithinBudget(img image.Image, maxBytes int) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", fmt.Errorf("encode png: %w", err)
+	}
+	if buf.Len() <= maxBytes {
+		return buf.Bytes(), "image/png", nil
+	}
+	for _, quality := range imageJPEGQualities {
+		buf.Reset()
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, "", fmt.Errorf("encode jpeg: %w", err)
+		}
+		if buf.Len() <= maxBytes {
+			return buf.Bytes(), "image/jpeg", nil
+		}
+	}
+	return nil, "", nil
+}
+
+// scaleImage 按比例缩小到长边不超过 maxDimension，已经足够小时原样返回
+func scaleImage(src image.Image, maxDimension int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	longest := max(width, height)
+	if longest <= maxDimension {
+		return src
+	}
+	width = max(1, width*maxDimension/longest)
+	height = max(1, height*maxDimension/longest)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+	return dst
+}
@@ -78,11 +78,11 @@ func (l *LogService) Info(msg string, args ...interface{}) {
 	formattedMsg := fmt.Sprintf("[%s] [INFO] "+msg, append([]interface{}{timestamp}, args...)...)
 
 	// 输出到控制台
-	fmt.Printf(formattedMsg + "\n")
+	fmt.Println(formattedMsg)
 
 	// 生产环境才输出到文件
 	if l.fileLogger != nil {
-		l.fileLogger.Printf(formattedMsg)
+		l.fileLogger.Print(formattedMsg)
 	}
 }
 
@@ -92,7 +92,7 @@ func (l *LogService) Error(msg string, args ...interface{}) {
 
 	// 生产环境才输出到文件
 	if l.fileLogger != nil {
-		l.fileLogger.Printf(formattedMsg)
+		l.fileLogger.Print(formattedMsg)
 	}
 }
 
@@ -3,6 +3,7 @@ package main
 import (
 	"embed"
 	"log"
+	"path/filepath"
 
 	"github.com/joho/godotenv"
 	"github.com/wailsapp/wails/v2"
@@ -20,8 +21,12 @@ var assets embed.FS
 var icon []byte
 
 func main() {
-	// Load .env from current working directory
+	// Load .env from the current working directory (development) and the config dir (installed apps).
+	// godotenv never overrides variables that are already set, so the working directory wins.
 	_ = godotenv.Load(".env")
+	if dir, err := userConfigDir(); err == nil {
+		_ = godotenv.Load(filepath.Join(dir, ".env"))
+	}
 
 	// Create an instance of the app structure
 	app := NewApp()
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var whitespaceRun = regexp.MustCompile(`\s+`)
+
+// markdownBlockTags 按块处理的标签，其余标签按行内内容处理
+var markdownBlockTags = map[atom.Atom]bool{
+	atom.Html: true, atom.Body: true, atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true,
+	atom.Header: true, atom.Footer: true, atom.Main: true, atom.Nav: true, atom.Aside: true,
+	atom.Figure: true, atom.Figcaption: true, atom.Address: true, atom.Details: true, atom.Summary: true,
+	atom.Form: true, atom.Fieldset: true, atom.Center: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Blockquote: true, atom.Pre: true, atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Table: true, atom.Hr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+}
+
+// markdownSkipTags 不产生可见内容的标签
+var markdownSkipTags = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Title: true, atom.Meta: true,
+	atom.Link: true, atom.Noscript: true, atom.Template: true, atom.Button: true, atom.Input: true,
+}
+
+// htmlToMarkdown 把剪贴板中的 HTML 片段转换为 Markdown，保留标题、列表、表格、链接和代码块
+func htmlToMarkdown(source string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return "", fmt.Errorf("parse html: %w", err)
+	}
+	return strings.TrimSpace(markdownBlocks(doc, "\n\n")), nil
+}
+
+// markdownBlocks 渲染子节点：连续的行内内容合并成一个段落，块之间用 sep 分隔
+func markdownBlocks(n *html.Node, sep string) string {
+	var blocks []string
+	var inline strings.Builder
+	flush := func() {
+		if text := trimLines(inline.String()); text != "" {
+			blocks = append(blocks, text)
+		}
+		inline.Reset()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && markdownBlockTags[child.DataAtom] {
+			flush()
+			if block := markdownBlock(child); block != "" {
+				blocks = append(blocks, block)
+			}
+			continue
+		}
+		inline.WriteString(markdownInline(child))
+	}
+	flush()
+	return strings.Join(blocks, sep)
+}
+
+func markdownBlock(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := oneLine(markdownInlineChildren(n))
+		if text == "" {
+			return ""
+		}
+		level := int(n.Data[1] - '0')
+		return strings.Repeat("#", level) + " " + text
+	case atom.Hr:
+		return "---"
+	case atom.Pre:
+		return markdownCodeBlock(n)
+	case atom.Blockquote:
+		return prefixLines(markdownBlocks(n, "\n\n"), "> ")
+	case atom.Ul, atom.Ol:
+		return markdownList(n)
+	case atom.Li:
+		// 没有 ul/ol 包裹的 li（只复制了列表中的几项）
+		return indentAfterFirst("- "+markdownBlocks(n, "\n"), "  ")
+	case atom.Table:
+		return markdownTable(n)
+	}
+	return markdownBlocks(n, "\n\n")
+}
+
+func markdownInline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return whitespaceRun.ReplaceAllString(n.Data, " ")
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if markdownSkipTags[n.DataAtom] {
+		return ""
+	}
+	switch n.DataAtom {
+	case atom.Br:
+		return "\n"
+	case atom.Strong, atom.B:
+		return wrapInline(markdownInlineChildren(n), "**")
+	case atom.Em, atom.I:
+		return wrapInline(markdownInlineChildren(n), "*")
+	case atom.Del, atom.S, atom.Strike:
+		return wrapInline(markdownInlineChildren(n), "~~")
+	case atom.Code, atom.Kbd, atom.Samp:
+		return inlineCode(textContent(n))
+	case atom.A:
+		text := markdownInlineChildren(n)
+		href := strings.TrimSpace(attr(n, "href"))
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			return text
+		}
+		label := strings.TrimSpace(text)
+		if label == "" {
+			return ""
+		}
+		if label == href {
+			return "<" + href + ">"
+		}
+		return "[" + label + "](" + href + ")"
+	case atom.Img:
+		alt := strings.TrimSpace(attr(n, "alt"))
+		src := strings.TrimSpace(attr(n, "src"))
+		// data URL 图片动辄几百 KB，只保留替代文本
+		if src == "" || strings.HasPrefix(src, "data:") {
+			return alt
+		}
+		return "![" + alt + "](" + src + ")"
+	}
+	return markdownInlineChildren(n)
+}
+
+// markdownInlineChildren 把子节点都当作行内内容渲染，块级子节点之间用空格隔开
+func markdownInlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && markdownBlockTags[child.DataAtom] {
+			b.WriteString(" " + markdownInlineChildren(child) + " ")
+			continue
+		}
+		b.WriteString(markdownInline(child))
+	}
+	return b.String()
+}
+
+func markdownList(n *html.Node) string {
+	ordered := n.DataAtom == atom.Ol
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+	var items []string
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		if child.DataAtom == atom.Ul || child.DataAtom == atom.Ol {
+			// 部分网页把嵌套列表直接放在 ul 下而不是 li 里
+			if nested := markdownList(child); nested != "" {
+				items = append(items, prefixLines(nested, "  "))
+			}
+			continue
+		}
+		if child.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		content := markdownBlocks(child, "\n")
+		items = append(items, indentAfterFirst(marker+content, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+func markdownCodeBlock(n *html.Node) string {
+	code := strings.Trim(textContent(n), "\n")
+	if strings.TrimSpace(code) == "" {
+		return ""
+	}
+	language := ""
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Code {
+			language = codeLanguage(attr(child, "class"))
+			break
+		}
+	}
+	if language == "" {
+		language = codeLanguage(attr(n, "class"))
+	}
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+// codeLanguage 从 language-go、lang-go 这样的 class 中取出语言
+func codeLanguage(class string) string {
+	for _, name := range strings.Fields(class) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(name, prefix) {
+				return strings.TrimPrefix(name, prefix)
+			}
+		}
+	}
+	return ""
+}
+
+func markdownTable(n *html.Node) string {
+	var rows [][]string
+	var collect func(*html.Node)
+	collect = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collect(child)
+			case atom.Tr:
+				var cells []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Th || cell.DataAtom == atom.Td) {
+						text := oneLine(markdownInlineChildren(cell))
+						cells = append(cells, strings.ReplaceAll(text, "|", `\|`))
+					}
+				}
+				if len(cells) > 0 {
+					rows = append(rows, cells)
+				}
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return ""
+	}
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	line := func(cells []string) string {
+		padded := append(cells, make([]string, columns-len(cells))...)
+		return "| " + strings.Join(padded, " | ") + " |"
+	}
+	separator := make([]string, columns)
+	for i := range separator {
+		separator[i] = "---"
+	}
+	// Markdown 表格必须有表头，第一行作为表头
+	lines := []string{line(rows[0]), line(separator)}
+	for _, row := range rows[1:] {
+		lines = append(lines, line(row))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func wrapInline(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	// 标记必须紧贴文字，原有的前后空格留在标记外面
+	leading := text[:len(text)-len(strings.TrimLeft(text, " \n"))]
+	trailing := text[len(strings.TrimRight(text, " \n")):]
+	return leading + marker + trimmed + marker + trailing
+}
+
+func inlineCode(code string) string {
+	code = whitespaceRun.ReplaceAllString(code, " ")
+	if strings.TrimSpace(code) == "" {
+		return code
+	}
+	if strings.Contains(code, "`") {
+		return "`` " + code + " ``"
+	}
+	return "`" + code + "`"
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Br {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// trimLines 去掉每行首尾空白和空行（段落内的换行来自 <br>）
+func trimLines(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func oneLine(text string) string {
+	return strings.TrimSpace(whitespaceRun.ReplaceAllString(text, " "))
+}
+
+func prefixLines(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(prefix+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func indentAfterFirst(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
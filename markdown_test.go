@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "headings and inline",
+			html: `<h2>Title</h2><p>Some <b>bold</b>, <em>italic</em> and <code>code</code> with a <a href="https://example.com">link</a>.</p>`,
+			want: "## Title\n\nSome **bold**, *italic* and `code` with a [link](https://example.com).",
+		},
+		{
+			name: "nested lists",
+			html: `<ul><li>one</li><li>two<ol start="3"><li>three</li><li>four</li></ol></li></ul>`,
+			want: "- one\n- two\n  3. three\n  4. four",
+		},
+		{
+			name: "table with header",
+			html: `<table><thead><tr><th>Name</th><th>Value</th></tr></thead><tbody><tr><td>a|b</td><td><p>1</p></td></tr><tr><td>c</td></tr></tbody></table>`,
+			want: "| Name | Value |\n| --- | --- |\n| a\\|b | 1 |\n| c |  |",
+		},
+		{
+			name: "code block keeps whitespace",
+			html: "<pre><code class=\"language-go\">func main() {\n\tfmt.Println(\"```\")\n}</code></pre>",
+			want: "````go\nfunc main() {\n\tfmt.Println(\"```\")\n}\n````",
+		},
+		{
+			name: "blockquote and line breaks",
+			html: `<blockquote><p>first<br>second</p><p>third</p></blockquote>`,
+			want: "> first\n> second\n>\n> third",
+		},
+		{
+			name: "fragment with markers and styles",
+			html: `<html><head><style>p{}</style></head><body><!--StartFragment--><span>plain   text</span><img src="data:image/png;base64,AAAA" alt="logo"><!--EndFragment--></body></html>`,
+			want: "plain textlogo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := htmlToMarkdown(tt.html)
+			if err != nil {
+				t.Fatalf("htmlToMarkdown() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("htmlToMarkdown() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
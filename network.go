@@ -44,6 +44,23 @@ func (n *NetworkService) CanAccessGoogle() bool {
 	return result
 }
 
+// CanReachProvider 检测提供方是否可达；本地提供方只探测本机端口，离线时也不会被外网检测拖住
+func (n *NetworkService) CanReachProvider(providerID string) bool {
+	provider, err := n.app.apiSvc.providers.Get(providerID)
+	if err != nil {
+		n.logSvc.Error("CanReachProvider: %v", err)
+		return false
+	}
+	cfg := provider.Config()
+	timeout := 3 * time.Second
+	if cfg.Capabilities.Local {
+		timeout = time.Second
+	}
+	result := n.CanAccessURL(cfg.BaseURL, timeout)
+	n.logSvc.Info("Provider %s reachable: %v", providerID, result)
+	return result
+}
+
 // 保持向后兼容的方法
 func (a *App) IsUserInChina() bool {
 	networkSvc := NewNetworkService(a.ctx, a)
@@ -54,3 +71,7 @@ func (a *App) CanAccessGoogle() bool {
 	networkSvc := NewNetworkService(a.ctx, a)
 	return networkSvc.CanAccessGoogle()
 }
+
+func (a *App) CanReachProvider(providerID string) bool {
+	return a.networkSvc.CanReachProvider(providerID)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNetworkService_CanReachProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("Ollama is running"))
+	}))
+	defer server.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	app := NewApp()
+	app.apiSvc = NewAPIService(context.Background(), app)
+	network := NewNetworkService(context.Background(), app)
+	for _, cfg := range []ProviderConfig{
+		{ID: "up", BaseURL: server.URL, Capabilities: ProviderCapabilities{Local: true}},
+		{ID: "down", BaseURL: closed.URL, Capabilities: ProviderCapabilities{Local: true}},
+	} {
+		if err := app.apiSvc.providers.Register(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for id, want := range map[string]bool{"up": true, "down": false, "missing": false} {
+		if got := network.CanReachProvider(id); got != want {
+			t.Errorf("CanReachProvider(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
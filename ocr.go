@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrOCRUnavailable 构建时没有加入 tesseract 标签，Go 侧不能识别文字；前端仍可使用 Tesseract.js
+var ErrOCRUnavailable = errors.New("OCR unavailable: this build does not include Tesseract (rebuild with -tags tesseract)")
+
+// defaultOCRLanguage 前端 OCRLang 为空时使用的语言，与 Tesseract.js 的默认值一致
+const defaultOCRLanguage = "eng"
+
+// OCRWord 识别出的单词及其在图片中的位置（像素）
+type OCRWord struct {
+	Text string `json:"text"`
+	// Confidence 0-100
+	Confidence float64 `json:"confidence"`
+	X          int     `json:"x"`
+	Y          int     `json:"y"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+}
+
+// OCRResult 一次识别的结果，Confidence 为所有单词置信度的平均值
+type OCRResult struct {
+	Text       string    `json:"text"`
+	Words      []OCRWord `json:"words"`
+	Languages  []string  `json:"languages"`
+	Confidence float64   `json:"confidence"`
+}
+
+// OCRService 基于 Tesseract（gosseract）的本地 OCR，不依赖 webview。
+// 需要系统安装 libtesseract，只有使用 -tags tesseract 构建时才可用，否则识别返回 ErrOCRUnavailable
+type OCRService struct {
+	BaseService
+	mu        sync.RWMutex
+	languages []string
+}
+
+// NewOCRService 创建新的 OCR 服务
+func NewOCRService(ctx context.Context, app *App) *OCRService {
+	service := &OCRService{languages: []string{defaultOCRLanguage}}
+	service.SetContext(ctx)
+	service.SetApp(app)
+	return service
+}
+
+// SetLanguages 设置识别语言，与前端 OCRLang 相同，例如 ["eng", "chi_sim"]；为空时使用 eng
+func (s *OCRService) SetLanguages(languages []string) {
+	cleaned := make([]string, 0, len(languages))
+	for _, lang := range languages {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			cleaned = append(cleaned, lang)
+		}
+	}
+	if len(cleaned) == 0 {
+		cleaned = []string{defaultOCRLanguage}
+	}
+	s.mu.Lock()
+	s.languages = cleaned
+	s.mu.Unlock()
+	s.logSvc.Info("OCR languages set to %v", cleaned)
+}
+
+// Languages 返回当前的识别语言
+func (s *OCRService) Languages() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.languages...)
+}
+
+// Recognize 识别图片字节（PNG、JPEG 等 Tesseract 支持的格式）
+func (s *OCRService) Recognize(data []byte) (OCRResult, error) {
+	languages := s.Languages()
+	text, words, err := recognize(data, languages)
+	if err != nil {
+		return OCRResult{}, err
+	}
+	result := newOCRResult(text, words, languages)
+	s.logSvc.Info("OCR recognized %d words (%v), confidence: %.1f", len(result.Words), languages, result.Confidence)
+	return result, nil
+}
+
+// RecognizeImage 识别 CreateScreenshot 返回的 data URL
+func (s *OCRService) RecognizeImage(imageDataURL string) (OCRResult, error) {
+	_, data, err := parseDataURL(imageDataURL)
+	if err != nil {
+		return OCRResult{}, err
+	}
+	return s.Recognize(data)
+}
+
+// RecognizeScreenshot 截图后直接识别，供不经过前端的快捷键和命令行流程使用
+func (s *OCRService) RecognizeScreenshot() (OCRResult, error) {
+	dataURL, err := s.app.screenshotSvc.CreateScreenshot()
+	if err != nil {
+		return OCRResult{}, fmt.Errorf("create screenshot: %w", err)
+	}
+	return s.RecognizeImage(dataURL)
+}
+
+// newOCRResult 去掉空白的单词并计算平均置信度
+func newOCRResult(text string, words []OCRWord, languages []string) OCRResult {
+	result := OCRResult{Text: strings.TrimSpace(text), Words: make([]OCRWord, 0, len(words)), Languages: languages}
+	var total float64
+	for _, word := range words {
+		word.Text = strings.TrimSpace(word.Text)
+		if word.Text == "" {
+			continue
+		}
+		result.Words = append(result.Words, word)
+		total += word.Confidence
+	}
+	if len(result.Words) > 0 {
+		result.Confidence = total / float64(len(result.Words))
+	}
+	return result
+}
+
+// 以下为 OCR 相关的前端绑定
+func (a *App) SetOCRLanguages(languages []string) {
+	a.ocrSvc.SetLanguages(languages)
+}
+
+func (a *App) RecognizeImage(imageDataURL string) (OCRResult, error) {
+	return a.ocrSvc.RecognizeImage(imageDataURL)
+}
+
+func (a *App) RecognizeScreenshot() (OCRResult, error) {
+	return a.ocrSvc.RecognizeScreenshot()
+}
@@ -0,0 +1,8 @@
+//go:build !tesseract
+
+package main
+
+// recognize 没有使用 -tags tesseract 构建时不链接 libtesseract
+func recognize(data []byte, languages []string) (string, []OCRWord, error) {
+	return "", nil, ErrOCRUnavailable
+}
@@ -0,0 +1,38 @@
+//go:build tesseract
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/otiai10/gosseract"
+)
+
+// recognize 调用 libtesseract 识别文字和单词位置
+func recognize(data []byte, languages []string) (string, []OCRWord, error) {
+	// gosseract.Client 不是并发安全的，每次识别使用独立的 client
+	client := gosseract.NewClient()
+	defer client.Close()
+	if err := client.SetLanguage(languages...); err != nil {
+		return "", nil, fmt.Errorf("set OCR language: %w", err)
+	}
+	if err := client.SetImageFromBytes(data); err != nil {
+		return "", nil, fmt.Errorf("set OCR image: %w", err)
+	}
+	text, err := client.Text()
+	if err != nil {
+		return "", nil, fmt.Errorf("recognize text: %w", err)
+	}
+	boxes, err := client.GetBoundingBoxes(gosseract.RIL_WORD)
+	if err != nil {
+		return "", nil, fmt.Errorf("get word boxes: %w", err)
+	}
+	words := make([]OCRWord, len(boxes))
+	for i, box := range boxes {
+		words[i] = OCRWord{
+			Text: box.Word, Confidence: box.Confidence,
+			X: box.Box.Min.X, Y: box.Box.Min.Y, Width: box.Box.Dx(), Height: box.Box.Dy(),
+		}
+	}
+	return text, words, nil
+}
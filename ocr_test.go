@@ -0,0 +1,30 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewOCRResult(t *testing.T) {
+	words := []OCRWord{
+		{Text: "Hello", Confidence: 90, X: 1, Y: 2, Width: 30, Height: 10},
+		{Text: "  ", Confidence: 10},
+		{Text: " world\n", Confidence: 70, X: 40, Y: 2, Width: 35, Height: 10},
+	}
+	result := newOCRResult("  Hello world\n\n", words, []string{"eng"})
+	want := OCRResult{
+		Text: "Hello world",
+		Words: []OCRWord{
+			{Text: "Hello", Confidence: 90, X: 1, Y: 2, Width: 30, Height: 10},
+			{Text: "world", Confidence: 70, X: 40, Y: 2, Width: 35, Height: 10},
+		},
+		Languages:  []string{"eng"},
+		Confidence: 80,
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("newOCRResult() = %+v, want %+v", result, want)
+	}
+	if empty := newOCRResult("", nil, []string{"eng"}); empty.Confidence != 0 || len(empty.Words) != 0 {
+		t.Errorf("newOCRResult() without words = %+v", empty)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// DefaultProfile 默认配置方案，使用升级前的配置文件和数据目录
+	DefaultProfile = "default"
+	// ProfileEnv 启动时使用的配置方案，优先于上次切换到的方案
+	ProfileEnv = "POPASK_PROFILE"
+	// ProfileChangedEvent 切换配置方案后推送，数据为新的方案名；前端收到后按新方案重新加载
+	ProfileChangedEvent = "profile:changed"
+
+	profilesDirName   = "profiles"
+	activeProfileFile = "profile"
+)
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,31}$`)
+
+func validateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use up to 32 letters, digits, '-' or '_'", name)
+	}
+	return nil
+}
+
+// profileDir 返回方案在 base 下的目录；默认方案直接使用 base，与未支持方案时的位置一致
+func profileDir(base, profile string) string {
+	if profile == "" || profile == DefaultProfile {
+		return base
+	}
+	return filepath.Join(base, profilesDirName, profile)
+}
+
+// ProfileService 管理配置方案。每个方案有独立的配置文件（提供方设置、快捷键绑定）、
+// 数据目录（会话历史、密钥库）和钥匙串条目；提示词列表由前端按方案分开保存
+type ProfileService struct {
+	BaseService
+	mu     sync.RWMutex
+	active string
+}
+
+// NewProfileService 创建新的配置方案服务，读取上次使用的方案
+func NewProfileService(ctx context.Context, app *App) *ProfileService {
+	service := &ProfileService{active: DefaultProfile}
+	service.SetContext(ctx)
+	service.SetApp(app)
+	name := os.Getenv(ProfileEnv)
+	if name == "" {
+		name = service.loadActive()
+	}
+	if err := validateProfileName(name); err != nil {
+		service.logSvc.Error("Ignoring profile: %v", err)
+		name = DefaultProfile
+	}
+	service.active = name
+	service.logSvc.Info("Using profile %s", name)
+	return service
+}
+
+func (p *ProfileService) activeFilePath() (string, error) {
+	dir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, activeProfileFile), nil
+}
+
+func (p *ProfileService) loadActive() string {
+	path, err := p.activeFilePath()
+	if err != nil {
+		return DefaultProfile
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			p.logSvc.Error("Failed to read active profile: %v", err)
+		}
+		return DefaultProfile
+	}
+	if name := strings.TrimSpace(string(data)); name != "" {
+		return name
+	}
+	return DefaultProfile
+}
+
+// Active 返回当前使用的方案
+func (p *ProfileService) Active() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.active
+}
+
+// setActive 记录下次启动使用的方案
+func (p *ProfileService) setActive(name string) error {
+	path, err := p.activeFilePath()
+	if err != nil {
+		return fmt.Errorf("resolve profile file: %w", err)
+	}
+	if err := p.EnsureDirectory(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(name+"\n"), 0o644); err != nil {
+		return fmt.Errorf("save active profile: %w", err)
+	}
+	p.mu.Lock()
+	p.active = name
+	p.mu.Unlock()
+	return nil
+}
+
+// List 返回已有的方案：默认方案、配置目录或数据目录下已创建的方案，以及当前方案
+func (p *ProfileService) List() []string {
+	seen := map[string]bool{DefaultProfile: true, p.Active(): true}
+	var bases []string
+	if dir, err := userConfigDir(); err == nil {
+		bases = append(bases, dir)
+	}
+	if dir, err := baseDataDir(); err == nil {
+		bases = append(bases, dir)
+	}
+	for _, base := range bases {
+		entries, err := os.ReadDir(filepath.Join(base, profilesDirName))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && validateProfileName(entry.Name()) == nil {
+				seen[entry.Name()] = true
+			}
+		}
+	}
+	profiles := make([]string, 0, len(seen))
+	for name := range seen {
+		profiles = append(profiles, name)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		// 默认方案排在最前
+		if (profiles[i] == DefaultProfile) != (profiles[j] == DefaultProfile) {
+			return profiles[i] == DefaultProfile
+		}
+		return profiles[i] < profiles[j]
+	})
+	return profiles
+}
+
+// ActiveProfile 返回当前使用的方案；没有方案服务时（例如测试中）为默认方案
+func (b *BaseService) ActiveProfile() string {
+	if app := b.GetApp(); app != nil && app.profileSvc != nil {
+		return app.profileSvc.Active()
+	}
+	return DefaultProfile
+}
+
+// config/history/vault 在 profileMu 下返回当前方案的服务
+func (a *App) config() *ConfigService {
+	a.profileMu.RLock()
+	defer a.profileMu.RUnlock()
+	return a.configSvc
+}
+
+func (a *App) history() *HistoryService {
+	a.profileMu.RLock()
+	defer a.profileMu.RUnlock()
+	return a.historySvc
+}
+
+func (a *App) vault() *VaultService {
+	a.profileMu.RLock()
+	defer a.profileMu.RUnlock()
+	return a.vaultSvc
+}
+
+// openProfileServices 创建依赖当前方案的服务：配置、历史和密钥库。
+// 后创建的服务在构造时读取先创建的服务，所以每创建一个就在锁内替换一个，构造期间不持有锁
+func (a *App) openProfileServices(ctx context.Context) {
+	config := NewConfigService(ctx, a)
+	a.profileMu.Lock()
+	a.configSvc = config
+	a.profileMu.Unlock()
+
+	history := NewHistoryService(ctx, a)
+	a.profileMu.Lock()
+	a.historySvc = history
+	a.profileMu.Unlock()
+
+	// vault 在历史服务之后创建，加载后把数据密钥交给历史服务
+	vault := NewVaultService(ctx, a)
+	a.profileMu.Lock()
+	a.vaultSvc = vault
+	a.profileMu.Unlock()
+
+	if err := config.Watch(); err != nil {
+		a.logSvc.Error("Config changes will not be applied until restart: %v", err)
+	}
+}
+
+// closeProfileServices 停止监听配置文件并关闭历史数据库
+func (a *App) closeProfileServices() {
+	if err := a.config().Close(); err != nil {
+		a.logSvc.Error("Failed to stop config watcher: %v", err)
+	}
+	if err := a.history().Close(); err != nil {
+		a.logSvc.Error("Failed to close history database: %v", err)
+	}
+}
+
+// switchProfile 切换到 name 方案（不存在时创建），重新加载依赖方案的服务，
+// 按新方案的配置更新提供方、HTTP 超时和剪贴板上限，丢弃运行时注册的提供方，清空剪贴板历史，
+// 并清空旧方案的快捷键，等待前端同步新方案的提示词列表后重新注册
+func (a *App) switchProfile(name string) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+	a.switchMu.Lock()
+	defer a.switchMu.Unlock()
+	if name == a.profileSvc.Active() {
+		return nil
+	}
+	prev := a.config().Get()
+	if err := a.profileSvc.setActive(name); err != nil {
+		return err
+	}
+	a.closeProfileServices()
+	a.openProfileServices(a.ctx)
+	if a.apiSvc != nil {
+		a.apiSvc.providers.dropRuntime()
+	}
+	if a.clipboardSvc != nil {
+		a.clipboardSvc.history.clear()
+	}
+	if a.shortcutSvc != nil {
+		a.shortcutSvc.clear()
+	}
+	// 与配置文件重新加载一样，把新方案的提供方、HTTP 超时和剪贴板上限交给已创建的服务
+	a.applyConfig(prev, a.config().Get())
+	a.logSvc.Info("Switched to profile %s", name)
+	return nil
+}
+
+// 以下为配置方案相关的前端绑定
+func (a *App) ListProfiles() []string {
+	return a.profileSvc.List()
+}
+
+func (a *App) GetActiveProfile() string {
+	return a.profileSvc.Active()
+}
+
+func (a *App) SwitchProfile(name string) error {
+	if err := a.switchProfile(name); err != nil {
+		return fmt.Errorf("switch profile: %w", err)
+	}
+	runtime.EventsEmit(a.ctx, ProfileChangedEvent, name)
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestApp_switchProfileIsolatesData(t *testing.T) {
+	keyring.MockInit()
+	clearConfigEnv(t)
+	dir := t.TempDir()
+	t.Setenv(DataDirEnv, filepath.Join(dir, "data"))
+	t.Setenv(ConfigPathEnv, filepath.Join(dir, "config", configFileName))
+	t.Setenv(ProfileEnv, "")
+	t.Cleanup(builtinConfig.apply)
+
+	ctx := context.Background()
+	app := NewApp()
+	app.profileSvc = NewProfileService(ctx, app)
+	app.openProfileServices(ctx)
+	defer func() { app.closeProfileServices() }()
+	app.configSvc.emit = func(context.Context, string, ...interface{}) {}
+	app.apiSvc = NewAPIService(ctx, app)
+	app.clipboardSvc = NewClipboardService(ctx, app)
+
+	if _, err := app.configSvc.Patch(map[string]interface{}{
+		"providers": map[string]interface{}{"ollamaHost": "http://gateway.work:11434"},
+		"http":      map[string]interface{}{"timeout": "45s"},
+		"clipboard": map[string]interface{}{"historySize": 5},
+	}); err != nil {
+		t.Fatalf("Patch() error: %v", err)
+	}
+	if err := app.vaultSvc.SetSecret(OpenAIProviderID, "sk-work"); err != nil {
+		t.Fatalf("SetSecret() error: %v", err)
+	}
+	work, _ := app.historySvc.CreateSession(HistorySession{Title: "work"})
+	if err := app.RegisterProvider(`{"id":"work-gateway","baseUrl":"http://gateway.work","apiKey":"sk-gateway"}`); err != nil {
+		t.Fatalf("RegisterProvider() error: %v", err)
+	}
+	app.clipboardSvc.history.add(ClipboardEntry{ID: "work", Kind: ClipboardEntryText, Text: "work notes"})
+
+	// 切换期间前端绑定仍可能被调用，用 -race 运行时检查服务的替换是否加锁
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			app.GetConfig()
+			app.HasSecret(OpenAIProviderID)
+			app.GetVaultStatus()
+		}
+	}()
+	if err := app.switchProfile("personal"); err != nil {
+		t.Fatalf("switchProfile(personal) error: %v", err)
+	}
+	<-done
+	if got := app.configSvc.State().Path; got != filepath.Join(dir, "config", profilesDirName, "personal", configFileName) {
+		t.Errorf("personal config path = %q", got)
+	}
+	if provider, _ := app.apiSvc.providers.Get(OllamaProviderID); provider.Config().BaseURL != builtinConfig.Providers.OllamaHost {
+		t.Errorf("ollama provider after switch = %q, want default", provider.Config().BaseURL)
+	}
+	if app.vaultSvc.HasSecret(OpenAIProviderID) {
+		t.Error("personal profile sees the work API key")
+	}
+	if sessions, _ := app.historySvc.ListSessions(""); len(sessions) != 0 {
+		t.Errorf("personal profile sees work history: %+v", sessions)
+	}
+	if _, err := app.apiSvc.providers.Get("work-gateway"); err == nil {
+		t.Error("personal profile sees the provider registered in the work profile")
+	}
+	if entries := app.clipboardSvc.history.list(); len(entries) != 0 {
+		t.Errorf("personal profile sees work clipboard history: %+v", entries)
+	}
+	if client, _ := app.apiSvc.httpClients(); client.Timeout != builtinConfig.HTTP.Timeout.Duration {
+		t.Errorf("HTTP timeout after switch = %v, want default", client.Timeout)
+	}
+	if limit := app.clipboardSvc.history.maxEntries(); limit != builtinConfig.Clipboard.HistorySize {
+		t.Errorf("clipboard history cap after switch = %d, want default", limit)
+	}
+	if err := app.vaultSvc.SetSecret(OpenAIProviderID, "sk-personal"); err != nil {
+		t.Fatalf("SetSecret() in personal profile error: %v", err)
+	}
+	if got := NewProfileService(ctx, NewApp()).Active(); got != "personal" {
+		t.Errorf("active profile not persisted: %q", got)
+	}
+	if got := app.profileSvc.List(); !reflect.DeepEqual(got, []string{DefaultProfile, "personal"}) {
+		t.Errorf("List() = %v", got)
+	}
+
+	if err := app.switchProfile(DefaultProfile); err != nil {
+		t.Fatalf("switchProfile(default) error: %v", err)
+	}
+	if got, err := app.vaultSvc.secret(OpenAIProviderID); err != nil || got != "sk-work" {
+		t.Errorf("work secret after switching back = %q, %v", got, err)
+	}
+	if sessions, _ := app.historySvc.ListSessions(""); len(sessions) != 1 || sessions[0].ID != work.ID {
+		t.Errorf("work history after switching back = %+v", sessions)
+	}
+	if provider, _ := app.apiSvc.providers.Get(OllamaProviderID); provider.Config().BaseURL != "http://gateway.work:11434" {
+		t.Errorf("ollama provider after switching back = %q", provider.Config().BaseURL)
+	}
+
+	for _, name := range []string{"", "../etc", "has space", "-leading"} {
+		if err := app.switchProfile(name); err == nil {
+			t.Errorf("switchProfile(%q) should fail", name)
+		}
+	}
+}
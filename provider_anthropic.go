@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const anthropicAPIVersion = "2023-06-01"
+
+const defaultAnthropicMaxTokens = 4096
+
+type anthropicMessage struct {
+	Role    string                   `json:"role"`
+	Content []map[string]interface{} `json:"content"`
+}
+
+// anthropicRequest Messages API 请求体：system 是顶层字段，max_tokens 必填
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	MaxTokens int                `json:"max_tokens"`
+	Stream    bool               `json:"stream,omitempty"`
+	// Anthropic 的 temperature 范围是 0..1，没有 seed
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"top_p,omitempty"`
+	StopSequences []string `json:"stop_sequences,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// AnthropicAPIError Anthropic 返回的错误，例如 {"type":"error","error":{"type":"overloaded_error","message":"..."}}
+type AnthropicAPIError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *AnthropicAPIError) Error() string {
+	if e.StatusCode > 0 {
+		return fmt.Sprintf("HTTP %d: anthropic %s: %s", e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("anthropic %s: %s", e.Type, e.Message)
+}
+
+// anthropicProvider 对接 Anthropic Messages API
+type anthropicProvider struct {
+	api *APIService
+	cfg ProviderConfig
+}
+
+func newAnthropicProvider(api *APIService, cfg ProviderConfig) Provider {
+	return &anthropicProvider{api: api, cfg: cfg}
+}
+
+func (p *anthropicProvider) Config() ProviderConfig {
+	return p.cfg
+}
+
+// toAnthropicMessages 把 OpenAI 风格的消息列表转换为 system + 严格 user/assistant 交替的消息
+func toAnthropicMessages(messages []map[string]interface{}) (string, []anthropicMessage) {
+	var system []string
+	var out []anthropicMessage
+	for _, message := range messages {
+		role, _ := message["role"].(string)
+		blocks := anthropicContentBlocks(message["content"])
+		if role == "system" {
+			for _, block := range blocks {
+				if text, ok := block["text"].(string); ok && text != "" {
+					system = append(system, text)
+				}
+			}
+			continue
+		}
+		if role != "assistant" {
+			role = "user"
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		// 相邻的同角色消息合并，Messages API 要求角色交替
+		if n := len(out); n > 0 && out[n-1].Role == role {
+			out[n-1].Content = append(out[n-1].Content, blocks...)
+			continue
+		}
+		out = append(out, anthropicMessage{Role: role, Content: blocks})
+	}
+	return strings.Join(system, "\n\n"), out
+}
+
+// anthropicContentBlocks 把字符串或 OpenAI content parts 转换为 Anthropic content blocks
+func anthropicContentBlocks(content interface{}) []map[string]interface{} {
+	switch c := content.(type) {
+	case string:
+		if c == "" {
+			return nil
+		}
+		return []map[string]interface{}{{"type": "text", "text": c}}
+	case []interface{}:
+		var blocks []map[string]interface{}
+		for _, item := range c {
+			part, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if text, ok := part["text"].(string); ok && part["type"] == "text" && text != "" {
+				blocks = append(blocks, map[string]interface{}{"type": "text", "text": text})
+			}
+			if url, ok := imagePartURL(part); ok {
+				if block := anthropicImageBlock(url); block != nil {
+					blocks = append(blocks, block)
+				}
+			}
+		}
+		return blocks
+	}
+	return nil
+}
+
+// anthropicImageBlock data URL 转为 base64 source，http(s) 地址转为 url source
+func anthropicImageBlock(url string) map[string]interface{} {
+	if strings.HasPrefix(url, "data:") {
+		mediaType, data, err := parseDataURL(url)
+		if err != nil {
+			return nil
+		}
+		return map[string]interface{}{"type": "image", "source": map[string]interface{}{
+			"type": "base64", "media_type": mediaType, "data": base64.StdEncoding.EncodeToString(data),
+		}}
+	}
+	return map[string]interface{}{"type": "image", "source": map[string]interface{}{"type": "url", "url": url}}
+}
+
+func (p *anthropicProvider) requestOptions(req ProviderRequest, stream bool) (HTTPRequestOptions, error) {
+	system, messages := toAnthropicMessages(req.Messages)
+	opts := req.Options
+	maxTokens := p.cfg.MaxTokens
+	if opts.MaxTokens != nil {
+		maxTokens = *opts.MaxTokens
+	}
+	if maxTokens <= 0 {
+		maxTokens = defaultAnthropicMaxTokens
+	}
+	temperature := opts.Temperature
+	if temperature != nil && *temperature > 1 {
+		clamped := 1.0
+		temperature = &clamped
+	}
+	requestBody, err := json.Marshal(anthropicRequest{
+		Model: modelOrDefault(p.cfg, opts.Model), System: system, Messages: messages,
+		MaxTokens: maxTokens, Stream: stream,
+		Temperature: temperature, TopP: opts.TopP, StopSequences: opts.Stop,
+	})
+	if err != nil {
+		return HTTPRequestOptions{}, fmt.Errorf("marshal request: %w", err)
+	}
+	token, headers, err := authOptions(p.api, p.cfg, req.APIKey)
+	if err != nil {
+		return HTTPRequestOptions{}, err
+	}
+	if _, ok := headers["anthropic-version"]; !ok {
+		headers["anthropic-version"] = anthropicAPIVersion
+	}
+	return HTTPRequestOptions{Context: req.Context, Method: "POST", URL: p.cfg.ChatURL(), Token: token, Headers: headers, Payload: requestBody, Retry: p.cfg.Retry}, nil
+}
+
+func (p *anthropicProvider) Chat(req ProviderRequest) (string, error) {
+	opts, err := p.requestOptions(req, false)
+	if err != nil {
+		return "", err
+	}
+	response, err := p.api.makeRequest(opts)
+	if err != nil {
+		return "", parseAnthropicError(err)
+	}
+	var resp anthropicResponse
+	if err := json.Unmarshal(response, &resp); err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+	var content strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+	if content.Len() == 0 {
+		return "", fmt.Errorf("empty content from API")
+	}
+	setUsage(req.Usage, resp.Usage.InputTokens, resp.Usage.OutputTokens, 0)
+	return content.String(), nil
+}
+
+func (p *anthropicProvider) ChatStream(req ProviderRequest, onDelta func(string)) (string, error) {
+	opts, err := p.requestOptions(req, true)
+	if err != nil {
+		return "", err
+	}
+	response, err := p.api.openStream(opts)
+	if err != nil {
+		return "", parseAnthropicError(err)
+	}
+	defer response.Body.Close()
+	return readAnthropicStream(response.Body, onDelta)
+}
+
+// readAnthropicStream 解析 Messages API 的 SSE 事件，只关心 text_delta、error 和 message_stop
+func readAnthropicStream(r io.Reader, onDelta func(string)) (string, error) {
+	var content strings.Builder
+	err := readSSE(r, func(data []byte) error {
+		var event anthropicStreamEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("unmarshal event: %w", err)
+		}
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				content.WriteString(event.Delta.Text)
+				onDelta(event.Delta.Text)
+			}
+		case "error":
+			if event.Error != nil {
+				return &AnthropicAPIError{Type: event.Error.Type, Message: event.Error.Message}
+			}
+			return fmt.Errorf("anthropic stream error")
+		case "message_stop":
+			return errSSEDone
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errSSEDone) {
+		return content.String(), err
+	}
+	return content.String(), nil
+}
+
+// parseAnthropicError 把 HTTP 错误体解析为 AnthropicAPIError，无法解析时原样返回
+func parseAnthropicError(err error) error {
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+	var body struct {
+		Error struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(statusErr.Body, &body) != nil || body.Error.Type == "" {
+		return err
+	}
+	return &AnthropicAPIError{StatusCode: statusErr.StatusCode, Type: body.Error.Type, Message: body.Error.Message}
+}
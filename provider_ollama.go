@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ollamaDefaultPort Ollama 默认监听的端口
+const ollamaDefaultPort = "11434"
+
+// ollamaHostURL 按 Ollama 自身解析 OLLAMA_HOST 的方式补全地址：没有协议时使用 http，
+// 省略主机时为 127.0.0.1，省略协议且没有端口时为 11434，例如 "127.0.0.1:11434"、":11434"、"0.0.0.0"
+func ollamaHostURL(host string) string {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return host
+	}
+	scheme, rest, found := strings.Cut(host, "://")
+	if !found {
+		scheme, rest = "http", host
+	}
+	hostPort, path, _ := strings.Cut(rest, "/")
+	name, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		name, port = strings.Trim(hostPort, "[]"), ""
+	}
+	if name == "" {
+		name = "127.0.0.1"
+	}
+	if port == "" && !found {
+		port = ollamaDefaultPort
+	}
+	u := url.URL{Scheme: scheme, Host: name, Path: strings.TrimRight("/"+path, "/")}
+	if port != "" {
+		u.Host = net.JoinHostPort(name, port)
+	}
+	return u.String()
+}
+
+// ollamaRequest Ollama /api/chat 请求体
+type ollamaRequest struct {
+	Model    string                   `json:"model"`
+	Messages []map[string]interface{} `json:"messages"`
+	Stream   bool                     `json:"stream"`
+	Options  *ollamaOptions           `json:"options,omitempty"`
+}
+
+// ollamaOptions Ollama 的采样参数放在 options 里，max_tokens 对应 num_predict
+type ollamaOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	NumPredict  *int     `json:"num_predict,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+}
+
+// toOllamaMessages 把 OpenAI content parts 转换为 Ollama 的 content + images（纯 base64，不带 data: 前缀）；
+// Ollama 不能拉取远程图片，http(s) 地址会被忽略
+func toOllamaMessages(messages []map[string]interface{}) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(messages))
+	for _, message := range messages {
+		parts, ok := message["content"].([]interface{})
+		if !ok {
+			out = append(out, message)
+			continue
+		}
+		var images []string
+		for _, item := range parts {
+			part, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if url, ok := imagePartURL(part); ok && strings.HasPrefix(url, "data:") {
+				if _, payload, found := strings.Cut(url, ","); found {
+					images = append(images, payload)
+				}
+			}
+		}
+		converted := map[string]interface{}{"role": message["role"], "content": messageText(parts)}
+		if len(images) > 0 {
+			converted["images"] = images
+		}
+		out = append(out, converted)
+	}
+	return out
+}
+
+func newOllamaOptions(opts ChatOptions) *ollamaOptions {
+	if opts.Temperature == nil && opts.TopP == nil && opts.MaxTokens == nil && len(opts.Stop) == 0 && opts.Seed == nil {
+		return nil
+	}
+	return &ollamaOptions{Temperature: opts.Temperature, TopP: opts.TopP, NumPredict: opts.MaxTokens, Stop: opts.Stop, Seed: opts.Seed}
+}
+
+// ollamaChatResponse 非流式响应，流式时每行 NDJSON 也是这个结构
+type ollamaChatResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done  bool   `json:"done"`
+	Error string `json:"error"`
+	// PromptEvalCount/EvalCount 为输入和输出的 token 数，只在最后一个对象里出现
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+// ollamaProvider 对接本机 Ollama 的 /api/chat
+type ollamaProvider struct {
+	api *APIService
+	cfg ProviderConfig
+}
+
+func newOllamaProvider(api *APIService, cfg ProviderConfig) Provider {
+	return &ollamaProvider{api: api, cfg: cfg}
+}
+
+func (p *ollamaProvider) Config() ProviderConfig {
+	return p.cfg
+}
+
+func (p *ollamaProvider) requestOptions(req ProviderRequest, stream bool) (HTTPRequestOptions, error) {
+	requestBody, err := json.Marshal(ollamaRequest{
+		Model: modelOrDefault(p.cfg, req.Options.Model), Messages: toOllamaMessages(req.Messages), Stream: stream, Options: newOllamaOptions(req.Options),
+	})
+	if err != nil {
+		return HTTPRequestOptions{}, fmt.Errorf("marshal request: %w", err)
+	}
+	token, headers, err := authOptions(p.api, p.cfg, req.APIKey)
+	if err != nil {
+		return HTTPRequestOptions{}, err
+	}
+	return HTTPRequestOptions{Context: req.Context, Method: "POST", URL: p.cfg.ChatURL(), Token: token, Headers: headers, Payload: requestBody, Retry: p.cfg.Retry}, nil
+}
+
+func (p *ollamaProvider) Chat(req ProviderRequest) (string, error) {
+	opts, err := p.requestOptions(req, false)
+	if err != nil {
+		return "", err
+	}
+	response, err := p.api.makeRequest(opts)
+	if err != nil {
+		return "", err
+	}
+	var resp ollamaChatResponse
+	if err := json.Unmarshal(response, &resp); err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("ollama: %s", resp.Error)
+	}
+	setUsage(req.Usage, resp.PromptEvalCount, resp.EvalCount, 0)
+	return resp.Message.Content, nil
+}
+
+func (p *ollamaProvider) ChatStream(req ProviderRequest, onDelta func(string)) (string, error) {
+	opts, err := p.requestOptions(req, true)
+	if err != nil {
+		return "", err
+	}
+	response, err := p.api.openStream(opts)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	return readOllamaStream(response.Body, onDelta)
+}
+
+// readOllamaStream 解析 Ollama 的 NDJSON 流，每行一个 JSON 对象，done 为 true 时结束
+func readOllamaStream(r io.Reader, onDelta func(string)) (string, error) {
+	var content strings.Builder
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), sseMaxLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return content.String(), fmt.Errorf("unmarshal chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return content.String(), fmt.Errorf("ollama: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			onDelta(chunk.Message.Content)
+		}
+		if chunk.Done {
+			return content.String(), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return content.String(), fmt.Errorf("read stream: %w", err)
+	}
+	return content.String(), nil
+}
+
+// ListModels 通过 /api/tags 列出本机已拉取的模型
+func (p *ollamaProvider) ListModels() ([]string, error) {
+	token, headers, err := authOptions(p.api, p.cfg, "")
+	if err != nil {
+		return nil, err
+	}
+	response, err := p.api.makeRequest(HTTPRequestOptions{
+		Method: "GET", URL: strings.TrimRight(p.cfg.BaseURL, "/") + "/api/tags", Token: token, Headers: headers,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(response, &resp); err != nil {
+		return nil, fmt.Errorf("unmarshal tags: %w", err)
+	}
+	models := make([]string, 0, len(resp.Models))
+	for _, m := range resp.Models {
+		models = append(models, m.Name)
+	}
+	return models, nil
+}
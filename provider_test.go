@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProviderRegistry_registerConfiguredProvider(t *testing.T) {
+	var gotPath, gotKey, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotKey = r.Header.Get("api-key")
+		gotAuth = r.Header.Get("Authorization")
+		w.WriteHeader(200)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"hi"}}]}`))
+	}))
+	defer server.Close()
+
+	app := NewApp()
+	api := NewAPIService(context.Background(), app)
+
+	err := api.providers.RegisterJSON(`[{"id":"gateway","baseUrl":"` + server.URL + `","authScheme":"header","authHeader":"api-key","apiKey":"secret","defaultModel":"gpt-4o-mini"}]`)
+	if err != nil {
+		t.Fatalf("RegisterJSON() error: %v", err)
+	}
+
+	content, err := api.Chat(context.Background(), "gateway", ChatOptions{}, []map[string]interface{}{{"role": "user", "content": "hello"}}, "")
+	if err != nil {
+		t.Fatalf("Chat() error: %v", err)
+	}
+	if content != "hi" {
+		t.Errorf("Chat() content = %q, want %q", content, "hi")
+	}
+	if gotPath != "/v1/chat/completions" {
+		t.Errorf("request path = %q, want %q", gotPath, "/v1/chat/completions")
+	}
+	if gotKey != "secret" || gotAuth != "" {
+		t.Errorf("auth headers = (api-key %q, Authorization %q), want key in api-key only", gotKey, gotAuth)
+	}
+
+	if _, err := api.Chat(context.Background(), "missing", ChatOptions{}, nil, ""); err == nil {
+		t.Error("Chat() expected error for unknown provider")
+	}
+}
+
+// newAnthropicTestService 注册指向 handler 的 claude 提供方，configure 可在注册前修改配置
+func newAnthropicTestService(t *testing.T, handler http.HandlerFunc, configure ...func(*ProviderConfig)) *APIService {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	api := NewAPIService(context.Background(), NewApp())
+	api.emit = func(ctx context.Context, name string, data ...interface{}) {}
+	cfg := ProviderConfig{
+		ID: "claude", Kind: ProviderKindAnthropic, BaseURL: server.URL,
+		AuthScheme: AuthSchemeHeader, AuthHeader: "x-api-key", APIKey: "sk-ant",
+		DefaultModel: "claude-test", MaxTokens: 256, Capabilities: ProviderCapabilities{Streaming: true},
+		Retry: &RetryPolicy{MaxAttempts: 1},
+	}
+	for _, fn := range configure {
+		fn(&cfg)
+	}
+	if err := api.providers.Register(cfg); err != nil {
+		t.Fatalf("Register() error: %v", err)
+	}
+	return api
+}
+
+func TestAnthropicProvider_chat(t *testing.T) {
+	var got anthropicRequest
+	var gotHeader http.Header
+	api := newAnthropicTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"Bonjour"}],"stop_reason":"end_turn"}`))
+	})
+
+	content, err := api.Chat(context.Background(), "claude", ChatOptions{}, []map[string]interface{}{
+		{"role": "system", "content": "Translate to French."},
+		{"role": "user", "content": "Hello"},
+		{"role": "user", "content": "World"},
+	}, "")
+	if err != nil {
+		t.Fatalf("Chat() error: %v", err)
+	}
+	if content != "Bonjour" {
+		t.Errorf("Chat() content = %q, want %q", content, "Bonjour")
+	}
+	if gotHeader.Get("x-api-key") != "sk-ant" || gotHeader.Get("anthropic-version") != anthropicAPIVersion {
+		t.Errorf("headers x-api-key=%q anthropic-version=%q", gotHeader.Get("x-api-key"), gotHeader.Get("anthropic-version"))
+	}
+	if got.System != "Translate to French." || got.MaxTokens != 256 || got.Model != "claude-test" {
+		t.Errorf("request system=%q max_tokens=%d model=%q", got.System, got.MaxTokens, got.Model)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Role != "user" || len(got.Messages[0].Content) != 2 {
+		t.Errorf("request messages = %+v, want one merged user message with 2 blocks", got.Messages)
+	}
+}
+
+func TestAnthropicProvider_chatStream(t *testing.T) {
+	api := newAnthropicTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"message_start","message":{"id":"msg_1"}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`,
+			`{"type":"ping"}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" there"}}`,
+			`{"type":"message_stop"}`,
+		}
+		for _, event := range events {
+			_, _ = w.Write([]byte("event: x\ndata: " + event + "\n\n"))
+		}
+	})
+
+	var deltas []string
+	content, err := api.ChatStream(context.Background(), "claude", ChatOptions{}, []map[string]interface{}{{"role": "user", "content": "hey"}}, "", func(d string) {
+		deltas = append(deltas, d)
+	})
+	if err != nil {
+		t.Fatalf("ChatStream() error: %v", err)
+	}
+	if content != "Hi there" || len(deltas) != 2 {
+		t.Errorf("ChatStream() content = %q, deltas = %v", content, deltas)
+	}
+}
+
+func TestAnthropicProvider_errorBody(t *testing.T) {
+	api := newAnthropicTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(529)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+	})
+
+	_, err := api.Chat(context.Background(), "claude", ChatOptions{}, []map[string]interface{}{{"role": "user", "content": "hey"}}, "")
+	var apiErr *AnthropicAPIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Chat() error = %v, want *AnthropicAPIError", err)
+	}
+	if apiErr.StatusCode != 529 || apiErr.Type != "overloaded_error" || apiErr.Message != "Overloaded" {
+		t.Errorf("AnthropicAPIError = %+v", apiErr)
+	}
+}
+
+func TestChatOptions_forwardedToProviders(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = nil
+		_ = json.Unmarshal(body, &got)
+		switch r.URL.Path {
+		case "/api/chat":
+			_, _ = w.Write([]byte(`{"message":{"content":"ok"},"done":true}`))
+		case "/v1/messages":
+			_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"ok"}]}`))
+		case "/pop-ask":
+			_, _ = w.Write([]byte(`{"code":200,"data":"ok"}`))
+		default:
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	api := NewAPIService(context.Background(), NewApp())
+	for _, cfg := range []ProviderConfig{
+		{ID: "oai", Kind: ProviderKindOpenAI},
+		{ID: "local", Kind: ProviderKindOllama},
+		{ID: "claude", Kind: ProviderKindAnthropic},
+		{ID: "edge", Kind: ProviderKindPopAsk, ChatPath: "/pop-ask"},
+	} {
+		cfg.BaseURL, cfg.AuthScheme = server.URL, AuthSchemeNone
+		if err := api.providers.Register(cfg); err != nil {
+			t.Fatalf("Register(%s) error: %v", cfg.ID, err)
+		}
+	}
+
+	temperature, topP, maxTokens, seed := 1.5, 0.9, 64, 7
+	opts := ChatOptions{Model: "m1", Temperature: &temperature, TopP: &topP, MaxTokens: &maxTokens, Stop: []string{"END"}, Seed: &seed}
+	messages := []map[string]interface{}{{"role": "user", "content": "hi"}}
+
+	tests := []struct {
+		provider string
+		want     map[string]interface{}
+		path     func(map[string]interface{}) map[string]interface{}
+	}{
+		{provider: "oai", want: map[string]interface{}{"model": "m1", "temperature": 1.5, "top_p": 0.9, "max_tokens": 64.0, "seed": 7.0}},
+		{provider: "edge", want: map[string]interface{}{"model": "m1", "temperature": 1.5, "top_p": 0.9, "max_tokens": 64.0, "seed": 7.0}},
+		{provider: "claude", want: map[string]interface{}{"model": "m1", "temperature": 1.0, "top_p": 0.9, "max_tokens": 64.0}},
+		{
+			provider: "local", want: map[string]interface{}{"temperature": 1.5, "top_p": 0.9, "num_predict": 64.0, "seed": 7.0},
+			path: func(body map[string]interface{}) map[string]interface{} {
+				options, _ := body["options"].(map[string]interface{})
+				return options
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			if _, err := api.Chat(context.Background(), tt.provider, opts, messages, ""); err != nil {
+				t.Fatalf("Chat() error: %v", err)
+			}
+			body := got
+			if tt.path != nil {
+				body = tt.path(got)
+			}
+			for key, want := range tt.want {
+				if body[key] != want {
+					t.Errorf("request %s = %v, want %v", key, body[key], want)
+				}
+			}
+		})
+	}
+
+	tooHot := 3.0
+	if _, err := api.Chat(context.Background(), "oai", ChatOptions{Temperature: &tooHot}, messages, ""); err == nil {
+		t.Error("Chat() with temperature 3 error = nil")
+	}
+}
+
+func TestAPIService_AskAboutImage(t *testing.T) {
+	// 3000x100 的截图超过 DefaultMaxImageDimension，上传前应当被缩小
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 3000, 100)))
+	screenshot := encodeDataURL("image/png", buf.Bytes())
+
+	var got anthropicRequest
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"A chart"}]}`))
+	}
+	api := newAnthropicTestService(t, handler, func(cfg *ProviderConfig) { cfg.Capabilities.Vision = true })
+
+	response, _ := api.AskAboutImage("req", "claude", "What does this show?", screenshot, "", ChatOptions{})
+	if response.Code != 200 || response.Data != "A chart" {
+		t.Fatalf("AskAboutImage() = %+v", response)
+	}
+	blocks := got.Messages[0].Content
+	if len(blocks) != 2 || blocks[1]["type"] != "image" {
+		t.Fatalf("request content = %v, want text and image blocks", blocks)
+	}
+	source, _ := blocks[1]["source"].(map[string]interface{})
+	data, _ := base64.StdEncoding.DecodeString(source["data"].(string))
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width != DefaultMaxImageDimension {
+		t.Errorf("uploaded image = %+v (err %v), want width %d", config, err, DefaultMaxImageDimension)
+	}
+
+	// 不支持视觉时退回到 OCR 文本，没有 OCR 文本则返回 invalid_request
+	api = newAnthropicTestService(t, handler)
+	if response, _ = api.AskAboutImage("req", "claude", "Summarize", screenshot, "Q3 revenue up 12%", ChatOptions{}); response.Code != 200 {
+		t.Fatalf("AskAboutImage() with OCR fallback = %+v", response)
+	}
+	if text, _ := got.Messages[0].Content[0]["text"].(string); !strings.Contains(text, "Q3 revenue up 12%") {
+		t.Errorf("fallback prompt = %q, want OCR text", text)
+	}
+	if response, _ = api.AskAboutImage("req", "claude", "Summarize", screenshot, "", ChatOptions{}); response.Code != 400 {
+		t.Errorf("AskAboutImage() without OCR text code = %d, want 400", response.Code)
+	}
+}
+
+func TestPrepareImage_byteBudget(t *testing.T) {
+	// 尺寸在限制内但体积超出时，逐步降低质量和尺寸直到满足体积限制
+	defer func(limit int) { DefaultMaxImageBytes = limit }(DefaultMaxImageBytes)
+	DefaultMaxImageBytes = 100 << 10
+	img := image.NewRGBA(image.Rect(0, 0, 1200, 900))
+	rng := rand.New(rand.NewSource(1))
+	_, _ = rng.Read(img.Pix)
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	if buf.Len() <= DefaultMaxImageBytes {
+		t.Fatalf("fixture is only %d bytes", buf.Len())
+	}
+
+	prepared, err := prepareImage(encodeDataURL("image/png", buf.Bytes()))
+	if err != nil {
+		t.Fatalf("prepareImage() error: %v", err)
+	}
+	mediaType, data, _ := parseDataURL(prepared)
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || len(data) > DefaultMaxImageBytes || mediaType != "image/jpeg" {
+		t.Errorf("prepareImage() = %s, %d bytes, %+v, %v", mediaType, len(data), config, err)
+	}
+	if config.Width >= 1200 || config.Width < imageMinDimension {
+		t.Errorf("prepareImage() width = %d, want downscaled", config.Width)
+	}
+
+	// 缩到最小尺寸仍然过大时返回错误
+	DefaultMaxImageBytes = 100
+	if _, err := prepareImage(encodeDataURL("image/png", buf.Bytes())); err == nil {
+		t.Error("prepareImage() with an unreachable budget should fail")
+	}
+}
+
+func TestReadOllamaStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"message":{"role":"assistant","content":"Hel"},"done":false}`,
+		``,
+		`{"message":{"role":"assistant","content":"lo"},"done":false}`,
+		`{"message":{"role":"assistant","content":""},"done":true,"total_duration":12}`,
+		`{"message":{"role":"assistant","content":"ignored"},"done":false}`,
+	}, "\n")
+	var deltas []string
+	content, err := readOllamaStream(strings.NewReader(stream), func(d string) { deltas = append(deltas, d) })
+	if err != nil || content != "Hello" || len(deltas) != 2 {
+		t.Errorf("readOllamaStream() = %q, %v, deltas %v", content, err, deltas)
+	}
+
+	content, err = readOllamaStream(strings.NewReader(`{"message":{"content":"par"}}`+"\n"+`{"error":"model not found"}`), func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "model not found") || content != "par" {
+		t.Errorf("readOllamaStream() error chunk = %q, %v", content, err)
+	}
+	if _, err := readOllamaStream(strings.NewReader("not json\n"), func(string) {}); err == nil {
+		t.Error("readOllamaStream() should fail on a malformed line")
+	}
+	// 没有 done 的流在 EOF 时返回已收到的内容
+	if content, err := readOllamaStream(strings.NewReader(`{"message":{"content":"cut"}}`), func(string) {}); err != nil || content != "cut" {
+		t.Errorf("readOllamaStream() at EOF = %q, %v", content, err)
+	}
+}
+
+func TestOllamaHostURL(t *testing.T) {
+	for host, want := range map[string]string{
+		"http://10.0.0.2:11434":   "http://10.0.0.2:11434",
+		"https://ollama.lan/":     "https://ollama.lan",
+		"127.0.0.1:11434":         "http://127.0.0.1:11434",
+		"0.0.0.0":                 "http://0.0.0.0:11434",
+		":8080":                   "http://127.0.0.1:8080",
+		"[::1]":                   "http://[::1]:11434",
+		"ollama.lan:11434/prefix": "http://ollama.lan:11434/prefix",
+		"":                        "",
+	} {
+		if got := ollamaHostURL(host); got != want {
+			t.Errorf("ollamaHostURL(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
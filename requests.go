@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ChatCodeCancelled 请求被用户取消时 ChatResponse 使用的 code
+const ChatCodeCancelled = 499
+
+// ChatStartedEvent 旧的非流式绑定开始请求时推送，前端据此拿到可取消的 requestId
+const ChatStartedEvent = "chat:started"
+
+// CancelledError 请求被 CancelRequest 取消
+type CancelledError struct {
+	RequestID string
+}
+
+func (e *CancelledError) Error() string {
+	return fmt.Sprintf("request %s cancelled", e.RequestID)
+}
+
+// requestEntry 一次 Begin 登记的请求；用指针区分同一 requestID 的先后两次请求
+type requestEntry struct {
+	cancel context.CancelFunc
+}
+
+// RequestRegistry 记录进行中请求的 cancel 函数
+type RequestRegistry struct {
+	mu      sync.Mutex
+	cancels map[string]*requestEntry
+}
+
+// NewRequestRegistry 创建请求注册表
+func NewRequestRegistry() *RequestRegistry {
+	return &RequestRegistry{cancels: make(map[string]*requestEntry)}
+}
+
+// Begin 为 requestID 创建可取消的 context；请求结束后必须调用 done 释放。
+// 复用进行中的 requestID 会取消之前的请求，之前请求的 done 不会移除新请求的登记
+func (r *RequestRegistry) Begin(parent context.Context, requestID string) (context.Context, func()) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	entry := &requestEntry{cancel: cancel}
+	r.mu.Lock()
+	if previous, ok := r.cancels[requestID]; ok {
+		previous.cancel()
+	}
+	r.cancels[requestID] = entry
+	r.mu.Unlock()
+	return ctx, func() {
+		r.mu.Lock()
+		if r.cancels[requestID] == entry {
+			delete(r.cancels, requestID)
+		}
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// Cancel 取消 requestID 对应的请求，请求不存在或已结束时返回 false
+func (r *RequestRegistry) Cancel(requestID string) bool {
+	r.mu.Lock()
+	entry, ok := r.cancels[requestID]
+	delete(r.cancels, requestID)
+	r.mu.Unlock()
+	if ok {
+		entry.cancel()
+	}
+	return ok
+}
+
+// cancelledError 请求因取消而失败时转换为 CancelledError
+func cancelledError(ctx context.Context, requestID string, err error) error {
+	if err != nil && ctx.Err() != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return &CancelledError{RequestID: requestID}
+	}
+	return err
+}
+
+func isCancelled(err error) bool {
+	var cancelled *CancelledError
+	return errors.As(err, &cancelled)
+}
+
+// CancelRequest 取消进行中的请求，HTTP 调用和流式读取都会立即中止
+func (api *APIService) CancelRequest(requestID string) bool {
+	cancelled := api.requests.Cancel(requestID)
+	api.logSvc.Info("CancelRequest %s: %v", requestID, cancelled)
+	return cancelled
+}
+
+func (a *App) CancelRequest(requestID string) bool {
+	return a.apiSvc.CancelRequest(requestID)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRequestRegistry_reusedID(t *testing.T) {
+	r := NewRequestRegistry()
+	first, firstDone := r.Begin(context.Background(), "req-1")
+	second, secondDone := r.Begin(context.Background(), "req-1")
+	if first.Err() == nil {
+		t.Error("reusing an ID should cancel the earlier request")
+	}
+
+	// 先前请求结束时不能移除新请求的登记
+	firstDone()
+	if !r.Cancel("req-1") {
+		t.Fatal("Cancel() = false after the earlier request finished, want true")
+	}
+	if second.Err() == nil {
+		t.Error("Cancel() did not cancel the newer request")
+	}
+	secondDone()
+	if r.Cancel("req-1") {
+		t.Error("Cancel() after both requests finished = true, want false")
+	}
+}
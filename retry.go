@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy 提供方请求的重试策略，可以在 ProviderConfig.Retry 中按提供方配置
+type RetryPolicy struct {
+	// MaxAttempts 包含首次请求在内的最大尝试次数，<= 1 表示不重试
+	MaxAttempts int `json:"maxAttempts"`
+	BaseDelayMs int `json:"baseDelayMs"`
+	MaxDelayMs  int `json:"maxDelayMs"`
+	// RetryStatuses 允许重试的状态码，为空时使用 defaultRetryStatuses
+	RetryStatuses []int `json:"retryStatuses,omitempty"`
+}
+
+// defaultRetryStatuses 只包含服务端没有处理请求的状态，避免对已经计费的对话重复请求
+var defaultRetryStatuses = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+	529, // Anthropic overloaded
+}
+
+// DefaultRetryPolicy 未配置重试策略的提供方使用的默认值
+func DefaultRetryPolicy() RetryPolicy {
+	limits := currentLimits()
+	return RetryPolicy{
+		MaxAttempts: limits.RetryMaxAttempts,
+		BaseDelayMs: int(limits.RetryBaseDelay / time.Millisecond),
+		MaxDelayMs:  int(limits.RetryMaxDelay / time.Millisecond),
+	}
+}
+
+// retryableStatus 判断状态码是否在可重试列表中
+func (p RetryPolicy) retryableStatus(status int) bool {
+	statuses := p.RetryStatuses
+	if len(statuses) == 0 {
+		statuses = defaultRetryStatuses
+	}
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// retryDelay 返回第 attempt 次失败后的等待时间；ok 为 false 表示不应重试
+func (p RetryPolicy) retryDelay(attempt int, err error) (delay time.Duration, ok bool) {
+	if attempt >= p.MaxAttempts {
+		return 0, false
+	}
+	var statusErr *HTTPStatusError
+	switch {
+	case errors.As(err, &statusErr):
+		if !p.retryableStatus(statusErr.StatusCode) {
+			return 0, false
+		}
+		if retryAfter, ok := parseRetryAfter(statusErr.Header.Get("Retry-After")); ok {
+			// 服务端要求等待太久时直接失败，交给上层（例如 fallback）处理
+			if retryAfter > currentLimits().RetryAfterMax {
+				return 0, false
+			}
+			return retryAfter, true
+		}
+	case !isConnectError(err):
+		return 0, false
+	}
+	return p.backoff(attempt), true
+}
+
+// backoff 带抖动的指数退避：base * 2^(attempt-1)，取上限后在 [d/2, d) 内随机
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base := time.Duration(p.BaseDelayMs) * time.Millisecond
+	maxDelay := time.Duration(p.MaxDelayMs) * time.Millisecond
+	if base <= 0 || maxDelay <= 0 {
+		limits := currentLimits()
+		if base <= 0 {
+			base = limits.RetryBaseDelay
+		}
+		if maxDelay <= 0 {
+			maxDelay = limits.RetryMaxDelay
+		}
+	}
+	delay := base << uint(attempt-1)
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter 解析秒数或 HTTP 日期格式的 Retry-After
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		delay := time.Until(at)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// isConnectError 请求还没发到服务端的网络错误（连接失败、DNS 失败），重试不会造成重复请求
+func isConnectError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// sleepContext 等待 d，ctx 取消时提前返回 ctx 的错误
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// withRetry 按 policy 执行 do，每次失败都记录到 LogService
+func (api *APIService) withRetry(opts HTTPRequestOptions, do func() error) error {
+	policy := DefaultRetryPolicy()
+	if opts.Retry != nil {
+		policy = *opts.Retry
+	}
+	for attempt := 1; ; attempt++ {
+		err := do()
+		if err == nil {
+			return nil
+		}
+		delay, ok := policy.retryDelay(attempt, err)
+		if !ok {
+			return err
+		}
+		api.logSvc.Error("Attempt %d/%d to %s failed: %v, retrying in %s", attempt, policy.MaxAttempts, opts.URL, err, delay)
+		if sleepErr := sleepContext(opts.Context, delay); sleepErr != nil {
+			return err
+		}
+	}
+}
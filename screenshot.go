@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -16,11 +17,12 @@ import (
 // ScreenshotService 截图服务
 type ScreenshotService struct {
 	BaseService
+	commandRunner
 }
 
 // NewScreenshotService 创建新的截图服务
 func NewScreenshotService(ctx context.Context, app *App) *ScreenshotService {
-	service := &ScreenshotService{}
+	service := &ScreenshotService{commandRunner: newCommandRunner()}
 	service.SetContext(ctx)
 	service.SetApp(app)
 	return service
@@ -29,9 +31,12 @@ func NewScreenshotService(ctx context.Context, app *App) *ScreenshotService {
 // CreateScreenshot 创建截图，根据操作系统选择不同的实现
 func (s *ScreenshotService) CreateScreenshot() (string, error) {
 	s.logSvc.Info("Creating screenshot, OS: %s", s.GetOS())
-	if s.IsWindows() {
+	switch {
+	case s.IsWindows():
 		return s.CreateScreenshotWindows()
-	} else {
+	case s.IsLinux():
+		return s.CreateScreenshotLinux()
+	default:
 		return s.CreateScreenshotMac()
 	}
 }
@@ -40,7 +45,12 @@ func (s *ScreenshotService) CreateScreenshot() (string, error) {
 func (s *ScreenshotService) CreateScreenshotWindows() (string, error) {
 	s.logSvc.Info("Creating Windows screenshot using Shift+Win+S")
 
-	// 清空剪贴板，确保检测到新图片
+	// 保存用户的剪贴板后清空，确保检测到新图片；截图读取完成后恢复，期间不记录剪贴板历史
+	defer s.pauseClipboardHistory()()
+	snapshot, err := takeClipboardSnapshot()
+	if err != nil {
+		s.logSvc.Error("Failed to snapshot clipboard, it will not be restored: %v", err)
+	}
 	runtime.ClipboardSetText(s.ctx, "")
 	time.Sleep(100 * time.Millisecond)
 
@@ -60,7 +70,6 @@ func (s *ScreenshotService) CreateScreenshotWindows() (string, error) {
 
 	// 使用更智能的检测方法
 	var imgData []byte
-	var err error
 	maxWaitTime := 30 * time.Second // 最多等待30秒
 	checkInterval := 200 * time.Millisecond
 	startTime := time.Now()
@@ -78,6 +87,8 @@ func (s *ScreenshotService) CreateScreenshotWindows() (string, error) {
 
 		time.Sleep(checkInterval)
 	}
+	// 剪贴板此时应当只有截图（取消时为空）；截图期间用户复制了别的内容时不恢复
+	s.restoreClipboardSnapshot(snapshot, fingerprintOf(nil, imgData))
 
 	if len(imgData) == 0 {
 		s.logSvc.Error("Screenshot timeout or cancelled by user")
@@ -126,9 +137,116 @@ func (s *ScreenshotService) CreateScreenshotMac() (string, error) {
 	return base64WithPrefix, nil
 }
 
+// linuxScreenshotTool Linux 上的一种区域截图工具
+type linuxScreenshotTool struct {
+	name string
+	// binaries 全部可用时才使用该工具
+	binaries []string
+	wayland  bool
+	x11      bool
+	// desktop 与 XDG_CURRENT_DESKTOP 匹配时优先使用，例如 GNOME 会话优先 gnome-screenshot
+	desktop string
+	capture func(s *ScreenshotService, filename string) error
+}
+
+var linuxScreenshotTools = []linuxScreenshotTool{
+	{
+		// wlroots 系合成器（sway、Hyprland 等）：slurp 选区，grim 截取
+		name: "grim+slurp", binaries: []string{"grim", "slurp"}, wayland: true,
+		capture: func(s *ScreenshotService, filename string) error {
+			region, err := s.runCommand("slurp")
+			if err != nil {
+				return fmt.Errorf("slurp: %w", err)
+			}
+			_, err = s.runCommand("grim", "-g", strings.TrimSpace(string(region)), filename)
+			return err
+		},
+	},
+	{
+		name: "gnome-screenshot", binaries: []string{"gnome-screenshot"}, wayland: true, x11: true, desktop: "GNOME",
+		capture: func(s *ScreenshotService, filename string) error {
+			_, err := s.runCommand("gnome-screenshot", "-a", "-f", filename)
+			return err
+		},
+	},
+	{
+		name: "spectacle", binaries: []string{"spectacle"}, wayland: true, x11: true, desktop: "KDE",
+		capture: func(s *ScreenshotService, filename string) error {
+			_, err := s.runCommand("spectacle", "-b", "-n", "-r", "-o", filename)
+			return err
+		},
+	},
+	{
+		name: "maim", binaries: []string{"maim"}, x11: true,
+		capture: func(s *ScreenshotService, filename string) error {
+			_, err := s.runCommand("maim", "-s", filename)
+			return err
+		},
+	},
+}
+
+// linuxScreenshotTool 按会话类型（X11/Wayland）和桌面环境选出第一个已安装的截图工具
+func (s *ScreenshotService) linuxScreenshotTool() (linuxScreenshotTool, error) {
+	wayland := s.IsWayland()
+	desktop := strings.ToUpper(os.Getenv("XDG_CURRENT_DESKTOP"))
+	var candidates, preferred []linuxScreenshotTool
+	var names []string
+	for _, tool := range linuxScreenshotTools {
+		if (wayland && !tool.wayland) || (!wayland && !tool.x11) {
+			continue
+		}
+		names = append(names, tool.name)
+		if tool.desktop != "" && strings.Contains(desktop, tool.desktop) {
+			preferred = append(preferred, tool)
+		} else {
+			candidates = append(candidates, tool)
+		}
+	}
+	for _, tool := range append(preferred, candidates...) {
+		if s.hasBinaries(tool.binaries...) {
+			return tool, nil
+		}
+	}
+	session := "X11"
+	if wayland {
+		session = "Wayland"
+	}
+	return linuxScreenshotTool{}, fmt.Errorf("no screenshot tool found for %s session, please install one of: %s", session, strings.Join(names, ", "))
+}
+
+// CreateScreenshotLinux Linux系统截图实现，用户取消选区时返回错误
+func (s *ScreenshotService) CreateScreenshotLinux() (string, error) {
+	tool, err := s.linuxScreenshotTool()
+	if err != nil {
+		s.logSvc.Error("%v", err)
+		return "", err
+	}
+	s.logSvc.Info("Creating Linux screenshot using %s (wayland: %v)", tool.name, s.IsWayland())
+
+	timestamp := time.Now().Format("20060102_150405")
+	filename := filepath.Join(os.TempDir(), fmt.Sprintf("PopAsk_Screenshot_%s.png", timestamp))
+	defer os.Remove(filename)
+	if err := tool.capture(s, filename); err != nil {
+		s.logSvc.Error("Failed to execute %s: %v", tool.name, err)
+		return "", fmt.Errorf("failed to execute %s: %w", tool.name, err)
+	}
+
+	// gnome-screenshot 等工具在用户按 Esc 取消时正常退出但不生成文件
+	imgData, err := os.ReadFile(filename)
+	if err != nil || len(imgData) == 0 {
+		s.logSvc.Error("Screenshot cancelled by user or file missing: %v", err)
+		return "", fmt.Errorf("screenshot cancelled by user")
+	}
+	s.logSvc.Info("Successfully captured Linux screenshot, size: %d bytes", len(imgData))
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(imgData), nil
+}
+
 // getClipboardImage 获取剪贴板中的图片数据
 func (s *ScreenshotService) getClipboardImage() ([]byte, error) {
 	// 使用 golang.design/x/clipboard 获取剪贴板图片
+	if err := initClipboard(); err != nil {
+		return nil, fmt.Errorf("init clipboard: %w", err)
+	}
 	imgData := clipboard.Read(clipboard.FmtImage)
 	if len(imgData) == 0 {
 		return nil, fmt.Errorf("no image found in clipboard")
@@ -152,3 +270,8 @@ func (a *App) CreateScreenshotMac(ctx context.Context) (string, error) {
 	screenshotSvc := NewScreenshotService(ctx, a)
 	return screenshotSvc.CreateScreenshotMac()
 }
+
+func (a *App) CreateScreenshotLinux(ctx context.Context) (string, error) {
+	screenshotSvc := NewScreenshotService(ctx, a)
+	return screenshotSvc.CreateScreenshotLinux()
+}
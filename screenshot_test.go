@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// fakeLookPath 只有 installed 中的命令存在
+func fakeLookPath(installed ...string) func(string) (string, error) {
+	return func(file string) (string, error) {
+		for _, name := range installed {
+			if name == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+}
+
+// newFakeScreenshotService 只有 installed 中的命令存在；运行截图命令时把最后一个参数当作输出文件写入
+func newFakeScreenshotService(t *testing.T, installed []string, writeFile bool) (*ScreenshotService, *[]string) {
+	t.Helper()
+	s := NewScreenshotService(context.Background(), NewApp())
+	var calls []string
+	s.lookPath = fakeLookPath(installed...)
+	s.runCommand = func(name string, args ...string) ([]byte, error) {
+		calls = append(calls, strings.TrimSpace(name+" "+strings.Join(args, " ")))
+		if name == "slurp" {
+			return []byte("10,20 300x200\n"), nil
+		}
+		if writeFile && len(args) > 0 {
+			if err := os.WriteFile(args[len(args)-1], []byte("png"), 0o600); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+	return s, &calls
+}
+
+func TestScreenshotService_linuxBackend(t *testing.T) {
+	tests := []struct {
+		name      string
+		session   string
+		desktop   string
+		installed []string
+		wantTool  string
+		wantCall  string
+	}{
+		{
+			name: "wayland wlroots", session: "wayland", desktop: "sway",
+			installed: []string{"grim", "slurp", "maim"}, wantTool: "grim+slurp", wantCall: "grim -g 10,20 300x200",
+		},
+		{
+			name: "wayland gnome prefers gnome-screenshot", session: "wayland", desktop: "ubuntu:GNOME",
+			installed: []string{"grim", "slurp", "gnome-screenshot"}, wantTool: "gnome-screenshot", wantCall: "gnome-screenshot -a -f",
+		},
+		{
+			name: "wayland ignores x11-only maim", session: "wayland",
+			installed: []string{"maim", "spectacle"}, wantTool: "spectacle", wantCall: "spectacle -b -n -r -o",
+		},
+		{
+			name: "x11 maim", session: "x11",
+			installed: []string{"grim", "slurp", "maim"}, wantTool: "maim", wantCall: "maim -s",
+		},
+		{
+			name: "grim without slurp", session: "wayland",
+			installed: []string{"grim", "gnome-screenshot"}, wantTool: "gnome-screenshot", wantCall: "gnome-screenshot -a -f",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WAYLAND_DISPLAY", "")
+			t.Setenv("XDG_SESSION_TYPE", tt.session)
+			t.Setenv("XDG_CURRENT_DESKTOP", tt.desktop)
+			s, calls := newFakeScreenshotService(t, tt.installed, true)
+
+			tool, err := s.linuxScreenshotTool()
+			if err != nil {
+				t.Fatalf("linuxScreenshotTool() error: %v", err)
+			}
+			if tool.name != tt.wantTool {
+				t.Errorf("linuxScreenshotTool() = %s, want %s", tool.name, tt.wantTool)
+			}
+
+			dataURL, err := s.CreateScreenshotLinux()
+			if err != nil {
+				t.Fatalf("CreateScreenshotLinux() error: %v", err)
+			}
+			if dataURL != "data:image/png;base64,cG5n" {
+				t.Errorf("CreateScreenshotLinux() = %q", dataURL)
+			}
+			last := (*calls)[len(*calls)-1]
+			if !strings.HasPrefix(last, tt.wantCall) {
+				t.Errorf("last command = %q, want prefix %q", last, tt.wantCall)
+			}
+		})
+	}
+}
+
+func TestScreenshotService_linuxErrors(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("XDG_SESSION_TYPE", "x11")
+	t.Setenv("XDG_CURRENT_DESKTOP", "")
+
+	s, _ := newFakeScreenshotService(t, nil, true)
+	_, err := s.CreateScreenshotLinux()
+	if err == nil || !strings.Contains(err.Error(), "maim") || !strings.Contains(err.Error(), "X11") {
+		t.Errorf("CreateScreenshotLinux() with no tools error = %v, want install hint", err)
+	}
+
+	// 用户按 Esc 取消时 gnome-screenshot 正常退出但不生成文件
+	s, _ = newFakeScreenshotService(t, []string{"gnome-screenshot"}, false)
+	if _, err := s.CreateScreenshotLinux(); err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("CreateScreenshotLinux() without output file error = %v, want cancelled", err)
+	}
+}
@@ -5,10 +5,34 @@ const client = new OpenAI({
   apiKey: Deno.env.get("OPENAI_API_KEY"),
 })
 
-type Message = { role: "user" | "assistant" | "system"; content: string }
+// content 可以是字符串，也可以是 OpenAI content parts（文本 + image_url），看图提问时由客户端发送
+type ContentPart =
+  | { type: "text"; text: string }
+  | { type: "image_url"; image_url: { url: string } }
+type Message = { role: "user" | "assistant" | "system"; content: string | ContentPart[] }
 
-const openAIRequest = async (messages: Message[], model = "gpt-3.5-turbo") => {
+// 客户端可选的采样参数，字段名与 OpenAI 一致，未传时使用 OpenAI 默认值
+type Sampling = {
+  temperature?: number
+  top_p?: number
+  max_tokens?: number
+  stop?: string[]
+  seed?: number
+}
+
+const pickSampling = (body: Sampling): Sampling => {
+  const sampling: Sampling = {}
+  if (typeof body.temperature === "number") sampling.temperature = body.temperature
+  if (typeof body.top_p === "number") sampling.top_p = body.top_p
+  if (typeof body.max_tokens === "number") sampling.max_tokens = body.max_tokens
+  if (Array.isArray(body.stop)) sampling.stop = body.stop.filter((s) => typeof s === "string")
+  if (typeof body.seed === "number") sampling.seed = body.seed
+  return sampling
+}
+
+const openAIRequest = async (messages: Message[], model = "gpt-3.5-turbo", sampling: Sampling = {}) => {
   const chatCompletion = await client.chat.completions.create({
+    ...sampling,
     model,
     messages,
     stream: false,
@@ -16,6 +40,41 @@ const openAIRequest = async (messages: Message[], model = "gpt-3.5-turbo") => {
   return chatCompletion.choices[0].message.content ?? ""
 }
 
+// stream: true 时把 OpenAI 的 chunk 原样以 SSE 转发，客户端按 OpenAI 流式格式解析
+const openAIStreamRequest = async (messages: Message[], model = "gpt-3.5-turbo", sampling: Sampling = {}) => {
+  const stream = await client.chat.completions.create({
+    ...sampling,
+    model,
+    messages,
+    stream: true,
+  })
+  const encoder = new TextEncoder()
+  const send = (controller: ReadableStreamDefaultController, data: string) =>
+    controller.enqueue(encoder.encode(`data: ${data}\n\n`))
+  const body = new ReadableStream({
+    async start(controller) {
+      try {
+        for await (const chunk of stream) {
+          send(controller, JSON.stringify(chunk))
+        }
+        send(controller, "[DONE]")
+      } catch (e) {
+        console.error(e)
+        const message = e instanceof Error ? e.message : "OpenAI stream failed"
+        send(controller, JSON.stringify({ error: { message } }))
+      } finally {
+        controller.close()
+      }
+    },
+  })
+  return new Response(body, {
+    headers: {
+      "Content-Type": "text/event-stream",
+      "Cache-Control": "no-cache",
+    },
+  })
+}
+
 // 规范响应体: { code, data, message }
 const ok = (data: string) =>
   new Response(JSON.stringify({ code: 200, data, message: "ok" }), {
@@ -29,7 +88,7 @@ const err = (code: number, message: string) =>
 
 Deno.serve(async (req) => {
   try {
-    const body = await req.json() as {
+    const body = await req.json() as Sampling & {
       message?: string
       messages?: Message[]
       model?: string
@@ -44,7 +103,11 @@ Deno.serve(async (req) => {
     } else {
       return err(400, "Missing or invalid message(s): send { message } or { messages }")
     }
-    const content = await openAIRequest(messages, model)
+    const sampling = pickSampling(body)
+    if (body.stream === true) {
+      return await openAIStreamRequest(messages, model, sampling)
+    }
+    const content = await openAIRequest(messages, model, sampling)
     return ok(content)
   } catch (e) {
     console.error(e)
@@ -68,6 +68,11 @@ func (b *BaseService) IsLinux() bool {
 	return goRuntime.GOOS == "linux"
 }
 
+// IsWayland 判断当前 Linux 会话是否为 Wayland（否则按 X11 处理）
+func (b *BaseService) IsWayland() bool {
+	return os.Getenv("WAYLAND_DISPLAY") != "" || strings.EqualFold(os.Getenv("XDG_SESSION_TYPE"), "wayland")
+}
+
 // GetOS 获取操作系统名称
 func (b *BaseService) GetOS() string {
 	return goRuntime.GOOS
@@ -119,6 +124,32 @@ func (b *BaseService) IsDevelopment() bool {
 	return false
 }
 
+// commandRunner 调用外部命令行工具（截图、剪贴板等）的服务嵌入它，
+// lookPath、runCommand 默认为 exec.LookPath 和执行命令取 stdout，测试时替换为假命令
+type commandRunner struct {
+	lookPath   func(file string) (string, error)
+	runCommand func(name string, args ...string) ([]byte, error)
+}
+
+func newCommandRunner() commandRunner {
+	return commandRunner{
+		lookPath: exec.LookPath,
+		runCommand: func(name string, args ...string) ([]byte, error) {
+			return exec.Command(name, args...).Output()
+		},
+	}
+}
+
+// hasBinaries 判断 binaries 是否全部已安装
+func (r commandRunner) hasBinaries(binaries ...string) bool {
+	for _, binary := range binaries {
+		if _, err := r.lookPath(binary); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
 // ExecuteCommand 执行系统命令并返回输出
 func (b *BaseService) ExecuteCommand(name string, args ...string) (string, error) {
 	cmd := exec.Command(name, args...)
@@ -166,6 +197,40 @@ func (b *BaseService) EnsureDirectory(dir string) error {
 	return os.MkdirAll(dir, 0755)
 }
 
+// DataDirEnv 覆盖应用数据目录的环境变量，便于便携安装和测试
+const DataDirEnv = "POPASK_DATA_DIR"
+
+// AppDataDir 返回当前方案的应用数据目录（会话历史等）；非默认方案在 profiles/<方案名> 下
+func (b *BaseService) AppDataDir() (string, error) {
+	dir, err := baseDataDir()
+	if err != nil {
+		return "", err
+	}
+	return profileDir(dir, b.ActiveProfile()), nil
+}
+
+// baseDataDir 返回应用数据目录，与日志目录放在同一处：macOS 为 ~/Library/Application Support/PopAsk，
+// Windows 为 %APPDATA%\PopAsk，其他系统为 ~/.popask；可通过 POPASK_DATA_DIR 覆盖
+func baseDataDir() (string, error) {
+	if dir := os.Getenv(DataDirEnv); dir != "" {
+		return dir, nil
+	}
+	switch goRuntime.GOOS {
+	case "windows", "darwin":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "PopAsk"), nil
+	default:
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(homeDir, ".popask"), nil
+	}
+}
+
 // FileExists 检查文件是否存在
 func (b *BaseService) FileExists(path string) bool {
 	_, err := os.Stat(path)
@@ -3,9 +3,12 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	goRuntime "runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	hook "github.com/robotn/gohook"
@@ -17,23 +20,50 @@ type ShortcutItem struct {
 	Label    string `json:"label"`
 	Value    string `json:"value"`
 	Shortcut string `json:"shortcut"`
+	// Provider/Model 指定该提示词使用的提供方和模型，为空时使用前端默认设置
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	// ReplaceSelection 为 true 时不弹出窗口，直接用回答替换原程序中选中的文本
+	ReplaceSelection bool `json:"replaceSelection,omitempty"`
 }
 
 // ShortcutService 快捷键服务
 type ShortcutService struct {
 	BaseService
-	keyRecords   []string
+	keyRecords []string
+	// mu 保护 items、shortcutList、hookChan 和 lastTrigger：前端同步列表、配置文件重新加载和按键回调在不同的 goroutine 中
+	mu sync.Mutex
+	// items 前端同步的提示词列表，shortcutList 为叠加配置文件中快捷键绑定后的结果
+	items        []ShortcutItem
 	shortcutList []map[string]interface{}
 	hookChan     chan hook.Event
 	lastTrigger  map[string]time.Time // 记录每个快捷键的最后触发时间
+	// dailyRemaining 前端同步的今日剩余次数，-1 表示尚未同步；替换选区不经过前端，据此执行每日限制
+	dailyRemaining atomic.Int64
+	emit           func(ctx context.Context, eventName string, optionalData ...interface{})
+	// startHook/endHook 启动和停止全局键盘钩子，测试中替换为空操作
+	startHook func()
+	endHook   func()
 }
 
+// SelectionReplacedEvent Go 侧用回答替换选区后推送，前端据此计入每日用量
+const SelectionReplacedEvent = "selection:replaced"
+
 // NewShortcutService 创建新的快捷键服务
 func NewShortcutService(ctx context.Context, app *App) *ShortcutService {
 	service := &ShortcutService{
 		keyRecords:  []string{},
 		lastTrigger: make(map[string]time.Time),
+		emit:        runtime.EventsEmit,
+		startHook: func() {
+			go func() {
+				ev := hook.Start()
+				<-hook.Process(ev)
+			}()
+		},
+		endHook: hook.End,
 	}
+	service.dailyRemaining.Store(-1)
 	service.SetContext(ctx)
 	service.SetApp(app)
 	return service
@@ -77,27 +107,33 @@ func parseShortcutFromPrompt(prompt map[string]interface{}) (hookKeys []string,
 	return hookKeys, shortcutStr, valueStr, true
 }
 
-func (s *ShortcutService) getSelectionWithRetry() (string, error) {
-	var text string
+func (s *ShortcutService) getSelectionWithRetry() (Selection, error) {
+	var selection Selection
 	var err error
 	for attempt := 0; attempt < selectionMaxAttempts; attempt++ {
 		if attempt > 0 {
 			time.Sleep(selectionRetryDelay)
 		}
-		text, err = s.GetApp().GetSelection(s.GetContext())
-		if text != "" || err != nil {
-			return text, err
+		selection, err = s.GetApp().getSelectionContent(s.GetContext())
+		if selection.Text != "" || err != nil {
+			return selection, err
 		}
 	}
-	return text, err
+	return selection, err
 }
 
 func (s *ShortcutService) runShortcutCallback(shortcutKey, promptValue string, e hook.Event) {
-	if lastTime, exists := s.lastTrigger[shortcutKey]; exists && time.Since(lastTime) < shortcutThrottleDuration {
+	s.mu.Lock()
+	lastTime, exists := s.lastTrigger[shortcutKey]
+	throttled := exists && time.Since(lastTime) < shortcutThrottleDuration
+	if !throttled {
+		s.lastTrigger[shortcutKey] = time.Now()
+	}
+	s.mu.Unlock()
+	if throttled {
 		s.logSvc.Info("Shortcut %s triggered too frequently, ignoring", shortcutKey)
 		return
 	}
-	s.lastTrigger[shortcutKey] = time.Now()
 	s.logSvc.Info("Shortcut triggered: %s", shortcutKey)
 
 	isOpenWindowShortcut := promptValue == "Open Window"
@@ -105,50 +141,199 @@ func (s *ShortcutService) runShortcutCallback(shortcutKey, promptValue string, e
 	autoAsking := !(isOpenWindowShortcut || isOCRShortcut)
 
 	if isOpenWindowShortcut {
-		s.emitGetSelection(shortcutKey, promptValue, "", autoAsking, false, true)
+		s.emitGetSelection(shortcutKey, promptValue, Selection{}, autoAsking, false, true)
 		e.Rawcode = 0
 		return
 	}
 
-	var text string
+	var selection Selection
 	var err error
 	if isOCRShortcut {
-		text, err = s.GetApp().CreateScreenshot(s.GetContext())
+		selection.Text, err = s.GetApp().CreateScreenshot(s.GetContext())
 		if err != nil {
 			s.logSvc.Error("Failed to create screenshot for OCR: %v", err)
 			return
 		}
 	} else {
-		text, err = s.getSelectionWithRetry()
+		selection, err = s.getSelectionWithRetry()
 		if err != nil {
 			s.logSvc.Error("Failed to get selection after retries: %v", err)
 			return
 		}
 	}
 
-	s.emitGetSelection(shortcutKey, promptValue, text, autoAsking, isOCRShortcut, isOpenWindowShortcut)
+	if autoAsking && selection.Text != "" && s.replacesSelection(shortcutKey) {
+		// 提问可能持续很久，放到单独的 goroutine，避免阻塞后续按键事件的处理
+		go s.replaceSelectionWithAnswer(shortcutKey, promptValue, selection)
+		e.Rawcode = 0
+		return
+	}
+
+	s.emitGetSelection(shortcutKey, promptValue, selection, autoAsking, isOCRShortcut, isOpenWindowShortcut)
 	e.Rawcode = 0
 }
 
-func (s *ShortcutService) emitGetSelection(shortcutKey, promptValue, text string, autoAsking, isOCR, isOpenWindow bool) {
-	s.logSvc.Info("Emitting GET_SELECTION event with text length: %d", len(text))
-	runtime.EventsEmit(s.GetContext(), "GET_SELECTION", map[string]interface{}{
-		"text":         text,
+// replaceSelectionWithAnswer 在 Go 侧直接提问并把回答粘贴回原程序。窗口始终不显示，
+// 焦点仍在原程序上，模拟的粘贴才会落到选区里。提问失败或达到每日限制时改为弹出窗口
+func (s *ShortcutService) replaceSelectionWithAnswer(shortcutKey, promptValue string, selection Selection) {
+	answer, err := s.askForReplacement(shortcutKey, promptValue, selection)
+	if isCancelled(err) {
+		s.logSvc.Info("Replacing selection for %s cancelled", shortcutKey)
+		return
+	}
+	if err != nil {
+		// 让用户在窗口里看到原因（用量提示或错误）
+		s.logSvc.Error("Failed to replace selection for %s, showing window instead: %v", shortcutKey, err)
+		s.emitGetSelection(shortcutKey, promptValue, selection, true, false, false)
+		return
+	}
+	if err := s.GetApp().clipboardSvc.ReplaceSelection(answer); err != nil {
+		// 回答已经计入用量并写入历史，窗口里不再重新提问
+		s.logSvc.Error("Failed to paste answer for %s: %v", shortcutKey, err)
+		s.emitGetSelection(shortcutKey, promptValue, selection, false, false, false)
+	}
+}
+
+// askForReplacement 与窗口中的提问一样执行每日限制、记录历史并计入用量。
+// 请求 ID 固定为 "replace:<快捷键>"：可以用 CancelRequest 取消，重复按下快捷键会取消上一次请求
+func (s *ShortcutService) askForReplacement(shortcutKey, promptValue string, selection Selection) (string, error) {
+	if s.dailyRemaining.Load() == 0 {
+		return "", errors.New("daily usage limit reached")
+	}
+	provider, model := s.shortcutTarget(shortcutKey)
+	if provider == "" {
+		provider = PopAskProviderID
+	}
+	text := selection.Markdown
+	if text == "" {
+		text = selection.Text
+	}
+	// 与前端 messageGenerator 的拼接方式一致
+	question := promptValue + text
+	messages := []map[string]interface{}{{"role": "user", "content": question}}
+	requestID := "replace:" + shortcutKey
+	s.logSvc.Info("Replacing selection via %s, text length: %d", provider, len(text))
+	response, _ := s.GetApp().apiSvc.chatResponse(requestID, provider, ChatOptions{Model: model}, messages, "")
+	if response.Code == ChatCodeCancelled {
+		return "", &CancelledError{RequestID: requestID}
+	}
+	if response.Code != 200 {
+		if response.Error != nil {
+			return "", response.Error
+		}
+		return "", fmt.Errorf("chat failed with code %d: %v", response.Code, response.Data)
+	}
+	answer, _ := response.Data.(string)
+	// 快捷键未指定模型时 response.Model 为提供方默认模型
+	if response.Model == "" {
+		response.Model = model
+	}
+
+	s.decrementDailyRemaining()
+	s.recordReplacement(promptValue, question, answer, response)
+	s.emit(s.GetContext(), SelectionReplacedEvent, map[string]interface{}{
+		"requestId": requestID,
+		"shortcut":  shortcutKey,
+		"provider":  response.Provider,
+		"model":     response.Model,
+	})
+	return answer, nil
+}
+
+// recordReplacement 把替换选区的一问一答写入 Ask 历史
+func (s *ShortcutService) recordReplacement(promptValue, question, answer string, response ChatResponse) {
+	history := s.GetApp().history()
+	if history == nil {
+		return
+	}
+	session, err := history.CreateSession(HistorySession{Kind: HistoryKindAsk, Prompt: promptValue})
+	if err == nil {
+		_, err = history.AddMessage(session.ID, HistoryMessage{Role: "user", Content: question, Prompt: promptValue})
+	}
+	if err == nil {
+		message := HistoryMessage{Role: "assistant", Content: answer, Provider: response.Provider, Model: response.Model}
+		if response.Usage != nil {
+			message.Usage = *response.Usage
+		}
+		_, err = history.AddMessage(session.ID, message)
+	}
+	if err != nil {
+		s.logSvc.Error("Failed to record replaced selection in history: %v", err)
+	}
+}
+
+// decrementDailyRemaining 计入一次用量，最少减到 0；尚未同步（-1）时不变。并发替换时重试直到成功，不会漏计
+func (s *ShortcutService) decrementDailyRemaining() {
+	for {
+		remaining := s.dailyRemaining.Load()
+		if remaining <= 0 || s.dailyRemaining.CompareAndSwap(remaining, remaining-1) {
+			return
+		}
+	}
+}
+
+// setDailyRemaining 记录前端同步的今日剩余次数
+func (s *ShortcutService) setDailyRemaining(remaining int) {
+	s.dailyRemaining.Store(int64(max(0, remaining)))
+}
+
+func (s *ShortcutService) emitGetSelection(shortcutKey, promptValue string, selection Selection, autoAsking, isOCR, isOpenWindow bool) {
+	s.logSvc.Info("Emitting GET_SELECTION event with text length: %d, markdown length: %d", len(selection.Text), len(selection.Markdown))
+	provider, model := s.shortcutTarget(shortcutKey)
+	s.emit(s.GetContext(), "GET_SELECTION", map[string]interface{}{
+		"text":         selection.Text,
+		"html":         selection.HTML,
+		"markdown":     selection.Markdown,
 		"shortcut":     shortcutKey,
 		"prompt":       promptValue,
 		"autoAsking":   autoAsking,
 		"isOCR":        isOCR,
 		"isOpenWindow": isOpenWindow,
+		"provider":     provider,
+		"model":        model,
 	})
 }
 
+// shortcutPrompt 返回快捷键对应的提示词项，没有时返回 nil
+func (s *ShortcutService) shortcutPrompt(shortcutKey string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, prompt := range s.shortcutList {
+		if prompt["shortcut"] == shortcutKey {
+			return prompt
+		}
+	}
+	return nil
+}
+
+// shortcutTarget 返回快捷键绑定的提供方和模型
+func (s *ShortcutService) shortcutTarget(shortcutKey string) (string, string) {
+	prompt := s.shortcutPrompt(shortcutKey)
+	provider, _ := prompt["provider"].(string)
+	model, _ := prompt["model"].(string)
+	return provider, model
+}
+
+// replacesSelection 快捷键是否开启了"替换选区"
+func (s *ShortcutService) replacesSelection(shortcutKey string) bool {
+	replace, _ := s.shortcutPrompt(shortcutKey)["replaceSelection"].(bool)
+	return replace
+}
+
 // RegisterKeyboardShortcut 注册键盘快捷键
 func (s *ShortcutService) RegisterKeyboardShortcut() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.registerLocked()
+}
+
+// registerLocked 按 shortcutList 重新注册快捷键，调用方持有 s.mu
+func (s *ShortcutService) registerLocked() {
 	s.logSvc.Info("Registering keyboard shortcuts")
 
 	if s.hookChan != nil {
 		s.logSvc.Info("Clearing existing keyboard hooks")
-		hook.End()
+		s.endHook()
 		close(s.hookChan)
 		s.hookChan = nil
 		time.Sleep(shortcutHookCleanupDelay)
@@ -190,10 +375,7 @@ func (s *ShortcutService) RegisterKeyboardShortcut() {
 	}
 	s.logSvc.Info("Successfully registered %d keyboard shortcuts", len(registeredList))
 
-	go func() {
-		ev := hook.Start()
-		<-hook.Process(ev)
-	}()
+	s.startHook()
 }
 
 // SetShortcutList 设置快捷键列表
@@ -207,18 +389,54 @@ func (s *ShortcutService) SetShortcutList(jsonData string) error {
 		return fmt.Errorf("failed to unmarshal prompt list: %v", err)
 	}
 
-	s.shortcutList = make([]map[string]interface{}, len(shortcutItems))
-	for i, item := range shortcutItems {
-		s.shortcutList[i] = map[string]interface{}{
-			"label":    item.Label,
-			"value":    item.Value,
-			"shortcut": item.Shortcut,
-		}
-	}
+	s.mu.Lock()
+	s.items = shortcutItems
+	s.applyBindingsLocked()
+	s.mu.Unlock()
 	s.logSvc.Info("Successfully updated shortcut list with %d items", len(shortcutItems))
 	return nil
 }
 
+// applyBindingsLocked 用配置文件 [shortcuts] 中按标签设置的快捷键覆盖提示词自带的快捷键，调用方持有 s.mu
+func (s *ShortcutService) applyBindingsLocked() {
+	bindings := s.UserConfig().Shortcuts
+	list := make([]map[string]interface{}, len(s.items))
+	for i, item := range s.items {
+		if shortcut, ok := bindings[item.Label]; ok {
+			item.Shortcut = shortcut
+		}
+		list[i] = map[string]interface{}{
+			"label":            item.Label,
+			"value":            item.Value,
+			"shortcut":         item.Shortcut,
+			"provider":         item.Provider,
+			"model":            item.Model,
+			"replaceSelection": item.ReplaceSelection,
+		}
+	}
+	s.shortcutList = list
+}
+
+// clear 清空提示词列表并注销已注册的快捷键，切换方案后等待前端同步新方案的列表
+func (s *ShortcutService) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = nil
+	s.shortcutList = nil
+	s.registerLocked()
+}
+
+// reloadBindings 配置文件中的快捷键绑定变化后重新注册快捷键；前端还没有同步提示词列表时不需要注册
+func (s *ShortcutService) reloadBindings() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.items) == 0 {
+		return
+	}
+	s.applyBindingsLocked()
+	s.registerLocked()
+}
+
 // addKeyRecord 添加按键记录，维护最多3条记录的FIFO行为
 func (s *ShortcutService) addKeyRecord(record string) {
 	s.logSvc.Info("addKeyRecord: %s", record)
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestShortcutService_askForReplacement(t *testing.T) {
+	var asked string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		asked = string(body)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"Bonjour"}}]}`))
+	}))
+	defer server.Close()
+
+	app := NewApp()
+	app.historySvc = newTestHistoryService(t)
+	app.apiSvc = NewAPIService(context.Background(), app)
+	if err := app.apiSvc.providers.Register(ProviderConfig{ID: "local", BaseURL: server.URL, AuthScheme: AuthSchemeNone, DefaultModel: "m0"}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewShortcutService(context.Background(), app)
+	var events []string
+	var models []interface{}
+	s.emit = func(ctx context.Context, name string, data ...interface{}) {
+		events = append(events, name)
+		models = append(models, data[0].(map[string]interface{})["model"])
+	}
+	if err := s.SetShortcutList(`[{"label":"Translate","value":"Translate: ","shortcut":"ctrl+shift+t","provider":"local","model":"m1","replaceSelection":true},` +
+		`{"label":"Fix","value":"Fix: ","shortcut":"ctrl+shift+f","provider":"local","replaceSelection":true}]`); err != nil {
+		t.Fatal(err)
+	}
+
+	// 达到每日限制时不提问
+	s.setDailyRemaining(0)
+	if _, err := s.askForReplacement("ctrl+shift+t", "Translate: ", Selection{Text: "Hello"}); err == nil || asked != "" {
+		t.Fatalf("askForReplacement() at the daily limit = %v, request %q", err, asked)
+	}
+
+	s.setDailyRemaining(2)
+	answer, err := s.askForReplacement("ctrl+shift+t", "Translate: ", Selection{Text: "Hello"})
+	if err != nil || answer != "Bonjour" {
+		t.Fatalf("askForReplacement() = %q, %v", answer, err)
+	}
+	var request struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	_ = json.Unmarshal([]byte(asked), &request)
+	if request.Model != "m1" || len(request.Messages) != 1 || request.Messages[0].Content != "Translate: Hello" {
+		t.Errorf("request = %s", asked)
+	}
+	if got := s.dailyRemaining.Load(); got != 1 {
+		t.Errorf("dailyRemaining = %d, want 1", got)
+	}
+	if strings.Join(events, ",") != SelectionReplacedEvent {
+		t.Errorf("events = %v, want %s", events, SelectionReplacedEvent)
+	}
+
+	sessions, _ := app.historySvc.ListSessions(HistoryKindAsk)
+	if len(sessions) != 1 || sessions[0].Provider != "local" || sessions[0].Model != "m1" || sessions[0].MessageCount != 2 {
+		t.Fatalf("history sessions = %+v", sessions)
+	}
+	messages, _ := app.historySvc.Messages(sessions[0].ID)
+	if messages[0].Content != "Translate: Hello" || messages[1].Content != "Bonjour" {
+		t.Errorf("history messages = %+v", messages)
+	}
+
+	// 快捷键未指定模型时，事件和历史记录提供方的默认模型
+	if _, err := s.askForReplacement("ctrl+shift+f", "Fix: ", Selection{Text: "teh"}); err != nil {
+		t.Fatalf("askForReplacement() without a model error: %v", err)
+	}
+	if len(models) != 2 || models[0] != "m1" || models[1] != "m0" {
+		t.Errorf("selection:replaced models = %v, want [m1 m0]", models)
+	}
+	if sessions, _ := app.historySvc.ListSessions(HistoryKindAsk); len(sessions) != 2 || sessions[0].Model != "m0" && sessions[1].Model != "m0" {
+		t.Errorf("history sessions = %+v, want one answered by m0", sessions)
+	}
+}
+
+func TestShortcutService_decrementDailyRemaining(t *testing.T) {
+	s := NewShortcutService(context.Background(), NewApp())
+	s.decrementDailyRemaining()
+	if got := s.dailyRemaining.Load(); got != -1 {
+		t.Errorf("dailyRemaining before sync = %d, want -1", got)
+	}
+	s.setDailyRemaining(50)
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.decrementDailyRemaining()
+		}()
+	}
+	wg.Wait()
+	if got := s.dailyRemaining.Load(); got != 10 {
+		t.Errorf("dailyRemaining after 40 concurrent uses = %d, want 10", got)
+	}
+	s.setDailyRemaining(1)
+	s.decrementDailyRemaining()
+	s.decrementDailyRemaining()
+	if got := s.dailyRemaining.Load(); got != 0 {
+		t.Errorf("dailyRemaining below the floor = %d, want 0", got)
+	}
+}
+
+// 配置文件重新加载和前端同步快捷键列表在不同的 goroutine 中，用 -race 运行时能发现未加锁的访问
+func TestShortcutService_reloadDuringSync(t *testing.T) {
+	clearConfigEnv(t)
+	app := NewApp()
+	var path string
+	app.configSvc, path = newTestConfigService(t, "")
+	s := NewShortcutService(context.Background(), app)
+	s.startHook = func() {}
+	s.endHook = func() {}
+	app.shortcutSvc = s
+
+	const list = `[{"label":"Translate","value":"Translate: ","shortcut":"ctrl+shift+t"}]`
+	if err := s.SetShortcutList(list); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			_ = s.SetShortcutList(list)
+			s.RegisterKeyboardShortcut()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			content := fmt.Sprintf("version = 1\n\n[shortcuts]\nTranslate = \"ctrl+shift+%d\"\n", i%10)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Error(err)
+				return
+			}
+			// 与文件监听的定时器一样在单独的 goroutine 中重新加载
+			app.configSvc.reload()
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		s.shortcutTarget("ctrl+shift+t")
+		currentLimits()
+	}
+	wg.Wait()
+
+	want := app.configSvc.Get().Shortcuts["Translate"]
+	s.reloadBindings()
+	if s.shortcutPrompt(want) == nil {
+		t.Errorf("shortcut %q from the config not applied: %v", want, s.shortcutList)
+	}
+}
@@ -0,0 +1,546 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	VaultKeySourceKeyring    = "keyring"
+	VaultKeySourcePassphrase = "passphrase"
+
+	vaultFile    = "vault.json"
+	vaultVersion = 1
+	vaultKeySize = 32
+
+	// 系统钥匙串中保存数据密钥的条目；非默认方案的条目名后加方案名，见 vaultKeyringAccount
+	vaultKeyringService = "PopAsk"
+	vaultKeyringUser    = "vault-key"
+
+	// scrypt 参数，约 100ms，口令只在解锁时使用一次
+	vaultScryptN = 1 << 15
+	vaultScryptR = 8
+	vaultScryptP = 1
+)
+
+var (
+	// ErrVaultLocked 数据密钥不可用：钥匙串读取失败，或口令模式下尚未解锁
+	ErrVaultLocked = errors.New("vault is locked")
+	// ErrVaultNotSetUp 系统钥匙串不可用，vault 需要先设置口令才能保存密钥
+	ErrVaultNotSetUp = fmt.Errorf("%w: system keyring unavailable, set a vault passphrase", ErrVaultLocked)
+	// ErrVaultWrongPassphrase 口令错误
+	ErrVaultWrongPassphrase = errors.New("wrong vault passphrase")
+	// ErrSecretNotFound 密钥不存在
+	ErrSecretNotFound = errors.New("secret not found")
+)
+
+// vaultKDF 口令模式下派生密钥的参数
+type vaultKDF struct {
+	Salt []byte `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// vaultData 保存在 vault.json 中的内容。所有密钥都用同一个随机数据密钥（AES-256-GCM）加密；
+// 数据密钥保存在系统钥匙串中，或用口令派生的密钥加密后保存在 WrappedKey 中
+type vaultData struct {
+	Version        int               `json:"version"`
+	KeySource      string            `json:"keySource"`
+	KDF            *vaultKDF         `json:"kdf,omitempty"`
+	WrappedKey     []byte            `json:"wrappedKey,omitempty"`
+	EncryptHistory bool              `json:"encryptHistory"`
+	Secrets        map[string][]byte `json:"secrets"`
+}
+
+// VaultStatus 返回给前端的状态，不包含任何密钥内容
+type VaultStatus struct {
+	Initialized    bool     `json:"initialized"`
+	KeySource      string   `json:"keySource"`
+	Locked         bool     `json:"locked"`
+	EncryptHistory bool     `json:"encryptHistory"`
+	Secrets        []string `json:"secrets"`
+}
+
+// VaultService 加密保存 API key 等密钥，并可选地加密会话历史。
+// 前端只能写入、删除和查询密钥是否存在，密钥明文只在后端发起请求时使用
+type VaultService struct {
+	BaseService
+	mu   sync.Mutex
+	path string
+	// keyringUser 当前方案在系统钥匙串中的条目名
+	keyringUser string
+	data        vaultData
+	// key/aead 为解锁后的数据密钥，锁定时为 nil
+	key  []byte
+	aead cipher.AEAD
+}
+
+// NewVaultService 创建新的密钥服务并加载 vault.json。没有 vault 时生成数据密钥并存入系统钥匙串；
+// 钥匙串不可用（例如没有 Secret Service 的 Linux）时需要先设置口令
+func NewVaultService(ctx context.Context, app *App) *VaultService {
+	service := &VaultService{}
+	service.SetContext(ctx)
+	service.SetApp(app)
+	if err := service.load(); err != nil {
+		service.logSvc.Error("Failed to load vault: %v", err)
+	}
+	service.applyHistoryCipher()
+	return service
+}
+
+// vaultKeyringAccount 返回方案的钥匙串条目名，每个方案使用独立的数据密钥
+func vaultKeyringAccount(profile string) string {
+	if profile == "" || profile == DefaultProfile {
+		return vaultKeyringUser
+	}
+	return vaultKeyringUser + ":" + profile
+}
+
+func (v *VaultService) load() error {
+	dir, err := v.AppDataDir()
+	if err != nil {
+		return fmt.Errorf("resolve data dir: %w", err)
+	}
+	v.path = v.JoinPath(dir, vaultFile)
+	v.keyringUser = vaultKeyringAccount(v.ActiveProfile())
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	raw, err := os.ReadFile(v.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v.initKeyringLocked()
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", v.path, err)
+	}
+	if err := json.Unmarshal(raw, &v.data); err != nil {
+		return fmt.Errorf("decode %s: %w", v.path, err)
+	}
+	if v.data.Version > vaultVersion {
+		return fmt.Errorf("unsupported vault version %d", v.data.Version)
+	}
+	if v.data.Secrets == nil {
+		v.data.Secrets = map[string][]byte{}
+	}
+	if v.data.KeySource != VaultKeySourceKeyring {
+		v.logSvc.Info("Vault is passphrase protected, waiting for unlock")
+		return nil
+	}
+	encoded, err := keyring.Get(vaultKeyringService, v.keyringUser)
+	if err != nil {
+		return fmt.Errorf("read vault key from keyring: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("decode vault key: %w", err)
+	}
+	return v.setKeyLocked(key)
+}
+
+// initKeyringLocked 生成新的数据密钥并存入系统钥匙串
+func (v *VaultService) initKeyringLocked() error {
+	key := make([]byte, vaultKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	if err := keyring.Set(vaultKeyringService, v.keyringUser, base64.StdEncoding.EncodeToString(key)); err != nil {
+		return fmt.Errorf("keyring unavailable, set a vault passphrase: %w", err)
+	}
+	v.data = vaultData{Version: vaultVersion, KeySource: VaultKeySourceKeyring, Secrets: map[string][]byte{}}
+	if err := v.setKeyLocked(key); err != nil {
+		return err
+	}
+	v.logSvc.Info("Vault created with key in system keyring")
+	return v.saveLocked()
+}
+
+func (v *VaultService) setKeyLocked(key []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	v.key = key
+	v.aead = aead
+	return nil
+}
+
+func (v *VaultService) saveLocked() error {
+	data, err := json.MarshalIndent(v.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := v.EnsureDirectory(filepath.Dir(v.path)); err != nil {
+		return err
+	}
+	// 先写临时文件再改名，避免写到一半时损坏
+	tmp := v.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write vault: %w", err)
+	}
+	if err := os.Rename(tmp, v.path); err != nil {
+		return fmt.Errorf("write vault: %w", err)
+	}
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealBytes 加密，结果为 nonce 加密文
+func sealBytes(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func openBytes(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed data too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
+
+// secretAD 把密钥名作为附加数据，防止密文被换到其他名字下
+func secretAD(name string) []byte {
+	return []byte("secret:" + name)
+}
+
+func (v *VaultService) cipherLocked() (cipher.AEAD, error) {
+	if v.aead == nil && v.data.KeySource == "" {
+		return nil, ErrVaultNotSetUp
+	}
+	if v.aead == nil {
+		return nil, ErrVaultLocked
+	}
+	return v.aead, nil
+}
+
+// SetSecret 加密保存密钥，value 为空时删除
+func (v *VaultService) SetSecret(name, value string) error {
+	if name == "" {
+		return errors.New("secret name is required")
+	}
+	if value == "" {
+		return v.DeleteSecret(name)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	aead, err := v.cipherLocked()
+	if err != nil {
+		return err
+	}
+	sealed, err := sealBytes(aead, []byte(value), secretAD(name))
+	if err != nil {
+		return fmt.Errorf("encrypt secret: %w", err)
+	}
+	v.data.Secrets[name] = sealed
+	return v.saveLocked()
+}
+
+// HasSecret 返回是否保存了该密钥，不需要解锁
+func (v *VaultService) HasSecret(name string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	_, ok := v.data.Secrets[name]
+	return ok
+}
+
+// DeleteSecret 删除密钥，不存在时不报错
+func (v *VaultService) DeleteSecret(name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.data.Secrets[name]; !ok {
+		return nil
+	}
+	delete(v.data.Secrets, name)
+	return v.saveLocked()
+}
+
+// secret 解密密钥，只供后端使用，不能绑定到前端
+func (v *VaultService) secret(name string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	sealed, ok := v.data.Secrets[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	aead, err := v.cipherLocked()
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := openBytes(aead, sealed, secretAD(name))
+	if err != nil {
+		return "", fmt.Errorf("decrypt secret %s: %w", name, err)
+	}
+	return string(plaintext), nil
+}
+
+// Status 返回 vault 状态和已保存的密钥名
+func (v *VaultService) Status() VaultStatus {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	names := make([]string, 0, len(v.data.Secrets))
+	for name := range v.data.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return VaultStatus{
+		Initialized:    v.data.KeySource != "",
+		KeySource:      v.data.KeySource,
+		Locked:         v.aead == nil,
+		EncryptHistory: v.data.EncryptHistory,
+		Secrets:        names,
+	}
+}
+
+func deriveVaultKey(passphrase string, kdf *vaultKDF) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), kdf.Salt, kdf.N, kdf.R, kdf.P, vaultKeySize)
+}
+
+// Unlock 用口令解锁 vault
+func (v *VaultService) Unlock(passphrase string) error {
+	v.mu.Lock()
+	if v.data.KeySource != VaultKeySourcePassphrase || v.data.KDF == nil {
+		v.mu.Unlock()
+		return errors.New("vault is not passphrase protected")
+	}
+	kek, err := deriveVaultKey(passphrase, v.data.KDF)
+	if err == nil {
+		err = v.unwrapLocked(kek)
+	}
+	v.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	v.applyHistoryCipher()
+	return nil
+}
+
+func (v *VaultService) unwrapLocked(kek []byte) error {
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return err
+	}
+	key, err := openBytes(aead, v.data.WrappedKey, []byte(vaultKeyringUser))
+	if err != nil {
+		return ErrVaultWrongPassphrase
+	}
+	return v.setKeyLocked(key)
+}
+
+// Lock 丢弃内存中的数据密钥，只在口令模式下有意义
+func (v *VaultService) Lock() {
+	v.mu.Lock()
+	if v.data.KeySource == VaultKeySourcePassphrase {
+		v.key = nil
+		v.aead = nil
+	}
+	v.mu.Unlock()
+	v.applyHistoryCipher()
+}
+
+// SetPassphrase 改用口令保护数据密钥并删除钥匙串中的条目；passphrase 为空时改回系统钥匙串。
+// vault 尚未创建时直接用口令创建；已创建时需要先解锁
+func (v *VaultService) SetPassphrase(passphrase string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var key []byte
+	switch {
+	case v.data.KeySource == "":
+		key = make([]byte, vaultKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		v.data = vaultData{Version: vaultVersion, Secrets: map[string][]byte{}}
+	case v.aead == nil:
+		return ErrVaultLocked
+	default:
+		key = v.key
+	}
+
+	if passphrase == "" {
+		if err := keyring.Set(vaultKeyringService, v.keyringUser, base64.StdEncoding.EncodeToString(key)); err != nil {
+			return fmt.Errorf("store vault key in keyring: %w", err)
+		}
+		v.data.KeySource = VaultKeySourceKeyring
+		v.data.KDF = nil
+		v.data.WrappedKey = nil
+	} else {
+		kdf := &vaultKDF{Salt: make([]byte, 16), N: vaultScryptN, R: vaultScryptR, P: vaultScryptP}
+		if _, err := rand.Read(kdf.Salt); err != nil {
+			return err
+		}
+		kek, err := deriveVaultKey(passphrase, kdf)
+		if err != nil {
+			return err
+		}
+		aead, err := newAEAD(kek)
+		if err != nil {
+			return err
+		}
+		if v.data.WrappedKey, err = sealBytes(aead, key, []byte(vaultKeyringUser)); err != nil {
+			return err
+		}
+		if v.data.KeySource == VaultKeySourceKeyring {
+			if err := keyring.Delete(vaultKeyringService, v.keyringUser); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+				v.logSvc.Error("Failed to remove vault key from keyring: %v", err)
+			}
+		}
+		v.data.KeySource = VaultKeySourcePassphrase
+		v.data.KDF = kdf
+	}
+	v.data.Version = vaultVersion
+	if err := v.setKeyLocked(key); err != nil {
+		return err
+	}
+	if err := v.saveLocked(); err != nil {
+		return err
+	}
+	v.logSvc.Info("Vault key source set to %s", v.data.KeySource)
+	return nil
+}
+
+// SetHistoryEncryption 开启或关闭会话历史加密，并重写已有记录
+func (v *VaultService) SetHistoryEncryption(enabled bool) error {
+	v.mu.Lock()
+	if enabled && v.aead == nil {
+		v.mu.Unlock()
+		return ErrVaultLocked
+	}
+	previous := v.data.EncryptHistory
+	v.data.EncryptHistory = enabled
+	if err := v.saveLocked(); err != nil {
+		v.data.EncryptHistory = previous
+		v.mu.Unlock()
+		return err
+	}
+	v.mu.Unlock()
+
+	history := v.historyService()
+	if history == nil {
+		return nil
+	}
+	v.applyHistoryCipher()
+	if err := history.reseal(); err != nil {
+		return fmt.Errorf("rewrite history: %w", err)
+	}
+	return nil
+}
+
+func (v *VaultService) historyService() *HistoryService {
+	if app := v.GetApp(); app != nil {
+		return app.history()
+	}
+	return nil
+}
+
+// applyHistoryCipher 把当前数据密钥交给历史服务；历史未加密时也设置，以便读取之前加密的记录
+func (v *VaultService) applyHistoryCipher() {
+	history := v.historyService()
+	if history == nil {
+		return
+	}
+	v.mu.Lock()
+	aead, encrypt := v.aead, v.data.EncryptHistory
+	v.mu.Unlock()
+	history.setCipher(aead, encrypt)
+}
+
+// lookupVaultSecret 返回 vault 中的密钥；没有 vault 或没有该密钥时返回 ErrSecretNotFound，
+// 调用方据此区分 vault 锁定（ErrVaultLocked）和没有保存密钥
+func (b *BaseService) lookupVaultSecret(name string) (string, error) {
+	var vault *VaultService
+	if app := b.GetApp(); app != nil {
+		vault = app.vault()
+	}
+	if vault == nil {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	return vault.secret(name)
+}
+
+// historySealedPrefix 加密后的历史记录前缀；未加密的记录是 JSON 对象，不会以它开头
+var historySealedPrefix = []byte("popask-sealed:")
+
+// sealHistoryValue 按需加密一条历史记录
+func sealHistoryValue(aead cipher.AEAD, encrypt bool, plaintext []byte) ([]byte, error) {
+	if !encrypt {
+		return plaintext, nil
+	}
+	if aead == nil {
+		return nil, ErrVaultLocked
+	}
+	sealed, err := sealBytes(aead, plaintext, historySealedPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, historySealedPrefix...), sealed...), nil
+}
+
+// openHistoryValue 解密历史记录，未加密的记录原样返回
+func openHistoryValue(aead cipher.AEAD, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, historySealedPrefix) {
+		return data, nil
+	}
+	if aead == nil {
+		return nil, ErrVaultLocked
+	}
+	return openBytes(aead, data[len(historySealedPrefix):], historySealedPrefix)
+}
+
+// 以下为密钥相关的前端绑定，没有读取密钥明文的绑定
+func (a *App) SetSecret(name, value string) error {
+	return a.vault().SetSecret(name, value)
+}
+
+func (a *App) HasSecret(name string) bool {
+	return a.vault().HasSecret(name)
+}
+
+func (a *App) DeleteSecret(name string) error {
+	return a.vault().DeleteSecret(name)
+}
+
+func (a *App) GetVaultStatus() VaultStatus {
+	return a.vault().Status()
+}
+
+func (a *App) UnlockVault(passphrase string) error {
+	return a.vault().Unlock(passphrase)
+}
+
+func (a *App) LockVault() {
+	a.vault().Lock()
+}
+
+func (a *App) SetVaultPassphrase(passphrase string) error {
+	return a.vault().SetPassphrase(passphrase)
+}
+
+func (a *App) SetHistoryEncryption(enabled bool) error {
+	return a.vault().SetHistoryEncryption(enabled)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestVaultService_keyring(t *testing.T) {
+	keyring.MockInit()
+	dir := t.TempDir()
+	t.Setenv(DataDirEnv, dir)
+
+	v := NewVaultService(context.Background(), NewApp())
+	if status := v.Status(); status.KeySource != VaultKeySourceKeyring || status.Locked {
+		t.Fatalf("Status() = %+v, want unlocked keyring vault", status)
+	}
+	if err := v.SetSecret(OpenAIProviderID, "sk-test-123"); err != nil {
+		t.Fatalf("SetSecret() error: %v", err)
+	}
+	if !v.HasSecret(OpenAIProviderID) || v.HasSecret("bianxie") {
+		t.Error("HasSecret() mismatch")
+	}
+	raw, _ := os.ReadFile(filepath.Join(dir, vaultFile))
+	if strings.Contains(string(raw), "sk-test-123") {
+		t.Errorf("vault file contains plaintext secret:\n%s", raw)
+	}
+
+	// 重新加载时从钥匙串取回数据密钥
+	v = NewVaultService(context.Background(), NewApp())
+	if got, err := v.secret(OpenAIProviderID); err != nil || got != "sk-test-123" {
+		t.Errorf("secret() after reload = %q, %v", got, err)
+	}
+	if status := v.Status(); len(status.Secrets) != 1 || status.Secrets[0] != OpenAIProviderID {
+		t.Errorf("Status().Secrets = %v", status.Secrets)
+	}
+	if err := v.DeleteSecret(OpenAIProviderID); err != nil || v.HasSecret(OpenAIProviderID) {
+		t.Errorf("DeleteSecret() error %v, still present %v", err, v.HasSecret(OpenAIProviderID))
+	}
+	if _, err := v.secret(OpenAIProviderID); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("secret() after delete error = %v, want not found", err)
+	}
+}
+
+func TestVaultService_passphraseFallback(t *testing.T) {
+	keyring.MockInitWithError(errors.New("secret service not available"))
+	t.Setenv(DataDirEnv, t.TempDir())
+
+	v := NewVaultService(context.Background(), NewApp())
+	if status := v.Status(); status.Initialized || !status.Locked {
+		t.Fatalf("Status() without keyring = %+v", status)
+	}
+	if err := v.SetSecret("bianxie", "key"); !errors.Is(err, ErrVaultNotSetUp) || !errors.Is(err, ErrVaultLocked) {
+		t.Errorf("SetSecret() before a passphrase is set error = %v, want ErrVaultNotSetUp", err)
+	}
+	if err := v.SetPassphrase("correct horse"); err != nil {
+		t.Fatalf("SetPassphrase() error: %v", err)
+	}
+	if err := v.SetSecret("bianxie", "key"); err != nil {
+		t.Fatalf("SetSecret() error: %v", err)
+	}
+
+	v = NewVaultService(context.Background(), NewApp())
+	if status := v.Status(); status.KeySource != VaultKeySourcePassphrase || !status.Locked || !v.HasSecret("bianxie") {
+		t.Fatalf("Status() after reload = %+v", status)
+	}
+	if _, err := v.secret("bianxie"); !errors.Is(err, ErrVaultLocked) {
+		t.Errorf("secret() before unlock error = %v", err)
+	}
+	if err := v.Unlock("wrong"); !errors.Is(err, ErrVaultWrongPassphrase) {
+		t.Errorf("Unlock(wrong) error = %v", err)
+	}
+	if err := v.Unlock("correct horse"); err != nil {
+		t.Fatalf("Unlock() error: %v", err)
+	}
+	if got, err := v.secret("bianxie"); err != nil || got != "key" {
+		t.Errorf("secret() after unlock = %q, %v", got, err)
+	}
+	v.Lock()
+	if !v.Status().Locked {
+		t.Error("Lock() did not lock the vault")
+	}
+}
+
+func TestVaultService_historyEncryption(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv(DataDirEnv, t.TempDir())
+	app := NewApp()
+	app.historySvc = NewHistoryService(context.Background(), app)
+	defer app.historySvc.Close()
+	app.vaultSvc = NewVaultService(context.Background(), app)
+	h := app.historySvc
+
+	session, _ := h.CreateSession(HistorySession{})
+	h.AddMessage(session.ID, HistoryMessage{Role: "user", Content: "top secret question"})
+
+	rawMessage := func() []byte {
+		var raw []byte
+		h.view(func(tx *bolt.Tx) error {
+			raw = append(raw, tx.Bucket(historyMessagesBucket).Bucket([]byte(session.ID)).Get(sequenceKey(1))...)
+			return nil
+		})
+		return raw
+	}
+
+	if err := app.vaultSvc.SetHistoryEncryption(true); err != nil {
+		t.Fatalf("SetHistoryEncryption(true) error: %v", err)
+	}
+	if raw := rawMessage(); !bytes.HasPrefix(raw, historySealedPrefix) || bytes.Contains(raw, []byte("top secret")) {
+		t.Errorf("message not encrypted at rest: %q", raw)
+	}
+	h.AddMessage(session.ID, HistoryMessage{Role: "assistant", Content: "sealed answer"})
+	messages, err := h.Messages(session.ID)
+	if err != nil || len(messages) != 2 || messages[0].Content != "top secret question" {
+		t.Fatalf("Messages() with encryption = %+v, %v", messages, err)
+	}
+	if result, err := h.Search(HistorySearchQuery{Query: "sealed"}); err != nil || result.Total != 1 {
+		t.Errorf("Search() over encrypted history = %+v, %v", result, err)
+	}
+
+	h.setCipher(nil, true)
+	if _, err := h.Messages(session.ID); !errors.Is(err, ErrVaultLocked) {
+		t.Errorf("Messages() without key error = %v, want locked", err)
+	}
+	app.vaultSvc.applyHistoryCipher()
+
+	if err := app.vaultSvc.SetHistoryEncryption(false); err != nil {
+		t.Fatalf("SetHistoryEncryption(false) error: %v", err)
+	}
+	if raw := rawMessage(); !bytes.Contains(raw, []byte("top secret")) {
+		t.Errorf("message still encrypted after disabling: %q", raw)
+	}
+}
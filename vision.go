@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// defaultImagePrompt 用户没有输入问题时使用的提示
+const defaultImagePrompt = "Describe this image."
+
+// textPart、imageURLPart 构造 OpenAI 风格的 content part，结构与前端 JSON 解码后的一致
+func textPart(text string) map[string]interface{} {
+	return map[string]interface{}{"type": "text", "text": text}
+}
+
+func imageURLPart(url string) map[string]interface{} {
+	return map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": url}}
+}
+
+// imagePartURL 取出 image_url part 的地址，兼容 image_url 为字符串的写法
+func imagePartURL(part map[string]interface{}) (string, bool) {
+	if part["type"] != "image_url" {
+		return "", false
+	}
+	switch v := part["image_url"].(type) {
+	case string:
+		return v, v != ""
+	case map[string]interface{}:
+		url, _ := v["url"].(string)
+		return url, url != ""
+	}
+	return "", false
+}
+
+// messageText 返回消息内容中的文本，content parts 中的图片被忽略
+func messageText(content interface{}) string {
+	switch c := content.(type) {
+	case string:
+		return c
+	case []interface{}:
+		var texts []string
+		for _, item := range c {
+			if part, ok := item.(map[string]interface{}); ok && part["type"] == "text" {
+				if text, _ := part["text"].(string); text != "" {
+					texts = append(texts, text)
+				}
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
+
+// visionModel 图片请求未指定模型时优先使用提供方的 VisionModel
+func visionModel(cfg ProviderConfig, model string) string {
+	if model != "" {
+		return model
+	}
+	if cfg.VisionModel != "" {
+		return cfg.VisionModel
+	}
+	return cfg.DefaultModel
+}
+
+// imageRequest 构造"看图提问"的消息：支持视觉的提供方直接发送图片，
+// 否则退回到 OCR 文本；两者都没有时返回 invalid_request 错误
+func (api *APIService) imageRequest(providerID, prompt, imageDataURL, ocrText string, opts ChatOptions) ([]map[string]interface{}, ChatOptions, error) {
+	provider, err := api.providers.Get(providerID)
+	if err != nil {
+		return nil, opts, err
+	}
+	if strings.TrimSpace(prompt) == "" {
+		prompt = defaultImagePrompt
+	}
+	cfg := provider.Config()
+	if cfg.Capabilities.Vision && imageDataURL != "" {
+		url, err := prepareImage(imageDataURL)
+		if err != nil {
+			return nil, opts, &APIError{Category: ErrorCategoryInvalidRequest, Provider: providerID, Message: err.Error()}
+		}
+		opts.Model = visionModel(cfg, opts.Model)
+		api.logSvc.Info("Sending image to %s (%s), image size: %d", providerID, opts.Model, len(url))
+		return []map[string]interface{}{{
+			"role":    "user",
+			"content": []interface{}{textPart(prompt), imageURLPart(url)},
+		}}, opts, nil
+	}
+	if strings.TrimSpace(ocrText) == "" && imageDataURL != "" && api.app != nil && api.app.ocrSvc != nil {
+		// 前端没有传 OCR 结果（例如快捷键直接触发）时在 Go 侧识别
+		result, err := api.app.ocrSvc.RecognizeImage(imageDataURL)
+		if err != nil {
+			api.logSvc.Error("OCR fallback for %s failed: %v", providerID, err)
+		}
+		ocrText = result.Text
+	}
+	if strings.TrimSpace(ocrText) == "" {
+		return nil, opts, &APIError{
+			Category: ErrorCategoryInvalidRequest, Provider: providerID,
+			Message: fmt.Sprintf("provider %s cannot read images and no OCR text is available", providerID),
+		}
+	}
+	api.logSvc.Info("Provider %s has no vision support, falling back to OCR text (%d chars)", providerID, len(ocrText))
+	return []map[string]interface{}{{
+		"role":    "user",
+		"content": prompt + "\n\nText recognized from the image:\n" + ocrText,
+	}}, opts, nil
+}
+
+// AskAboutImage 针对截图提问；imageDataURL 为 CreateScreenshot 返回的 data URL，
+// ocrText 在提供方不支持图片时作为退路，为空时由 OCRService 在 Go 侧识别
+func (api *APIService) AskAboutImage(requestID, providerID, prompt, imageDataURL, ocrText string, options ChatOptions) (ChatResponse, error) {
+	messages, options, err := api.imageRequest(providerID, prompt, imageDataURL, ocrText, options)
+	if err != nil {
+		api.logSvc.Error("AskAboutImage: %v", err)
+		return invalidRequestResponse(providerID, err), nil
+	}
+	return api.chatResponse(requestID, providerID, options, messages, "")
+}
+
+// AskAboutImageStream 流式版本，结果通过 chat:delta / chat:done / chat:error 推送
+func (api *APIService) AskAboutImageStream(requestID, providerID, prompt, imageDataURL, ocrText string, options ChatOptions) (string, error) {
+	messages, options, err := api.imageRequest(providerID, prompt, imageDataURL, ocrText, options)
+	if err != nil {
+		return "", err
+	}
+	if err := options.Validate(); err != nil {
+		return "", err
+	}
+	return api.runStream(requestID, providerID, func(ctx context.Context, onDelta func(string)) (string, string, error) {
+		content, err := api.ChatStream(ctx, providerID, options, messages, "", onDelta)
+		return content, providerID, err
+	}), nil
+}
+
+func (a *App) AskAboutImage(requestID, providerID, prompt, imageDataURL, ocrText string, options ChatOptions) (ChatResponse, error) {
+	return a.apiSvc.AskAboutImage(requestID, providerID, prompt, imageDataURL, ocrText, options)
+}
+
+func (a *App) AskAboutImageStream(requestID, providerID, prompt, imageDataURL, ocrText string, options ChatOptions) (string, error) {
+	return a.apiSvc.AskAboutImageStream(requestID, providerID, prompt, imageDataURL, ocrText, options)
+}